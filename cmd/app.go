@@ -15,6 +15,12 @@ func Execute() error {
 	}
 	rootCmd.AddCommand(service)
 	rootCmd.AddCommand(cronjobCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(mockCmd)
+	rootCmd.AddCommand(importOpenAPICmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(generateTypesCmd)
+	rootCmd.AddCommand(generateTestsCmd)
 
 	err := rootCmd.Execute()
 	if err != nil {
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/export"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportVersion string
+	exportPages   string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <dir>",
+	Short: "Write the documentation of the catalogue to a directory",
+	Long: "Write the combined OpenAPI document, one OpenAPI document per host and a page per operation " +
+		"to a directory, the documents being the ones served by the live endpoints",
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !openapiutils.IsValidVersion(exportVersion) {
+			return fmt.Errorf("version must be 3.0 or 3.1")
+		}
+		if exportPages != constants.ExportPagesMarkdown && exportPages != constants.ExportPagesHTML {
+			return fmt.Errorf("pages must be %s or %s", constants.ExportPagesMarkdown, constants.ExportPagesHTML)
+		}
+		return nil
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		app := Invoke(func(exportUC export.IExportUC) error {
+			_, err := exportUC.Export(context.Background(), &entity.ExportRequest{
+				Dir:     args[0],
+				Version: exportVersion,
+				Pages:   exportPages,
+			})
+			return err
+		})
+		if app.Err() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportVersion, "version", openapiutils.Version30, "OpenAPI version of the documents, 3.0 or 3.1")
+	exportCmd.Flags().StringVar(&exportPages, "pages", constants.ExportPagesMarkdown,
+		"format of the operation pages, markdown or html")
+}
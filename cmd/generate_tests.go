@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/testgen"
+	"github.com/spf13/cobra"
+)
+
+var generateTestsReq = &entity.GenerateTestsRequest{}
+
+var generateTestsCmd = &cobra.Command{
+	Use:   "generate_tests <dir>",
+	Short: "Generate a curl script, Go tests and a k6 script from the recorded samples",
+	Long: "Write to a directory a curl script replaying the recorded samples of an api or a tag, Go table tests " +
+		"asserting their status and response schema and a k6 script replaying their traffic mix",
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if (generateTestsReq.ApiId == "") == (generateTestsReq.Tag == "") {
+			return fmt.Errorf("either --api or --tag must be given")
+		}
+		return nil
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		app := Invoke(func(testgenUC testgen.ITestgenUC) error {
+			return GenerateTests(testgenUC, args[0])
+		})
+		if app.Err() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	flags := generateTestsCmd.Flags()
+	flags.StringVar(&generateTestsReq.ApiId, "api", "", "id of the api")
+	flags.StringVar(&generateTestsReq.Tag, "tag", "", "tag of the apis, e.g. gateway.chotot.org/ads")
+	flags.StringVar(&generateTestsReq.Host, "host", "", "only keep the apis of the tag on this host")
+	flags.IntVar(&generateTestsReq.Samples, "samples", constants.TestgenSamplesPerApi, "samples replayed per api")
+	flags.StringVar(&generateTestsReq.Package, "package", constants.TestgenDefaultPackage,
+		"package of the Go tests")
+}
+
+// GenerateTests writes the generated files to dir and prints what was
+// generated.
+func GenerateTests(testgenUC testgen.ITestgenUC, dir string) error {
+	resp, err := testgenUC.GenerateTests(context.Background(), generateTestsReq)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range resp.Files {
+		mode := os.FileMode(0o644)
+		if file.Name == constants.TestgenCurlFile {
+			mode = 0o755
+		}
+		if err := os.WriteFile(filepath.Join(dir, file.Name), []byte(file.Content), mode); err != nil {
+			return err
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]int{
+		"operations": resp.Operations,
+		"samples":    resp.Samples,
+	})
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/typegen"
+	"github.com/spf13/cobra"
+)
+
+var generateTypesReq = &entity.GenerateTypesRequest{}
+
+var generateTypesCmd = &cobra.Command{
+	Use:   "generate_types",
+	Short: "Generate Go or TypeScript types from the structures of an api or a type",
+	Long: "Print the Go structs or TypeScript interfaces of the query parameters, request and response bodies " +
+		"of an api, or of a type of the types collection, as inferred from the observed traffic",
+	Args: cobra.NoArgs,
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if (generateTypesReq.ApiId == "") == (generateTypesReq.TypeName == "") {
+			return fmt.Errorf("either --api or --type must be given")
+		}
+		return nil
+	},
+	Run: func(_ *cobra.Command, _ []string) {
+		app := Invoke(func(typegenUC typegen.ITypegenUC) error {
+			resp, err := typegenUC.GenerateTypes(context.Background(), generateTypesReq)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(os.Stdout, resp.Code)
+			return err
+		})
+		if app.Err() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	flags := generateTypesCmd.Flags()
+	flags.StringVar(&generateTypesReq.ApiId, "api", "", "id of the api")
+	flags.StringVar(&generateTypesReq.TypeName, "type", "", "name of the type of the types collection")
+	flags.StringVar(&generateTypesReq.Language, "lang", constants.TypegenLanguageGo, "language, go or typescript")
+	flags.StringVar(&generateTypesReq.Package, "package", constants.TypegenDefaultPackage,
+		"package clause of the Go code")
+}
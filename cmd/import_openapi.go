@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/ct-logic-api-document/internal/usecase/baseline"
+	"github.com/spf13/cobra"
+)
+
+var importOpenAPICmd = &cobra.Command{
+	Use:   "import_openapi <file>",
+	Short: "Import an OpenAPI document as the declared baseline of its apis",
+	Long: "Import the operations of an OpenAPI 3.x document, in JSON or YAML, as the baselines of their apis " +
+		"and print how they compare with the observed traffic",
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		app := Invoke(func(baselineUC baseline.IBaselineUC) error {
+			return ImportOpenAPI(baselineUC, args[0])
+		})
+		if app.Err() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+// ImportOpenAPI imports the document at path and prints the comparison.
+func ImportOpenAPI(baselineUC baseline.IBaselineUC, path string) error {
+	resp, err := baselineUC.ImportOpenAPI(context.Background(), path)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resp)
+}
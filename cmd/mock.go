@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/carousell/ct-go/pkg/logger"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/handler"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Serve the documented apis from their samples",
+	Long:  "Serve every documented api path, answering with a stored sample or a body generated from its structure",
+	Run: func(_ *cobra.Command, _ []string) {
+		Invoke(StartMock).Run()
+	},
+}
+
+func StartMock(
+	lc fx.Lifecycle,
+	conf *config.Config,
+	mockHandler *handler.MockHandler,
+) {
+	log := logger.MustNamed("mock")
+	server := &http.Server{
+		Addr:    conf.Mock.HTTPAddr,
+		Handler: handler.NewMockHTTPHandler(mockHandler),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go func() {
+				log.Infof("mock server starting at: %s", conf.Mock.HTTPAddr)
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("failed to start mock server: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: server.Shutdown,
+	})
+}
@@ -10,9 +10,19 @@ import (
 	"github.com/ct-logic-api-document/internal/controller"
 	"github.com/ct-logic-api-document/internal/handler"
 	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	"github.com/ct-logic-api-document/internal/usecase/baseline"
 	buildstructure "github.com/ct-logic-api-document/internal/usecase/build_structure"
+	"github.com/ct-logic-api-document/internal/usecase/catalogue"
+	"github.com/ct-logic-api-document/internal/usecase/deprecation"
+	"github.com/ct-logic-api-document/internal/usecase/drift"
+	"github.com/ct-logic-api-document/internal/usecase/export"
 	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
 	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+	"github.com/ct-logic-api-document/internal/usecase/mock"
+	"github.com/ct-logic-api-document/internal/usecase/postman"
+	"github.com/ct-logic-api-document/internal/usecase/testgen"
+	"github.com/ct-logic-api-document/internal/usecase/typegen"
+	watchstructure "github.com/ct-logic-api-document/internal/usecase/watch_structure"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -81,9 +91,20 @@ func Invoke(invokers ...interface{}) *fx.App {
 		fx.Provide(
 			mongodb.NewMongoStorage,
 			loadstructure.NewLoadStructureUC,
+			drift.NewDriftUC,
+			deprecation.NewDeprecationUC,
 			fetchdata.NewFetchDataUC,
 			buildstructure.NewBuildStructureUC,
+			catalogue.NewCatalogueUC,
+			watchstructure.NewWatchStructureUC,
+			mock.NewMockUC,
+			baseline.NewBaselineUC,
+			export.NewExportUC,
+			typegen.NewTypegenUC,
+			testgen.NewTestgenUC,
+			postman.NewPostmanUC,
 			handler.NewHandler,
+			handler.NewMockHandler,
 			controller.NewCronJob,
 		),
 		fx.Supply(conf),
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/carousell/ct-go/pkg/logger"
+	watchstructure "github.com/ct-logic-api-document/internal/usecase/watch_structure"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Merge new samples into structures in near real time",
+	Long:  "Subscribe to the sample change streams and merge new samples into the structures of their api",
+	Run: func(_ *cobra.Command, _ []string) {
+		Invoke(StartWatch).Run()
+	},
+}
+
+func StartWatch(
+	lc fx.Lifecycle,
+	watchStructureUC watchstructure.IWatchStructureUC,
+) {
+	log := logger.MustNamed("watch")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go func() {
+				defer close(done)
+				log.Info("watching sample changes")
+				if err := watchStructureUC.Watch(ctx); err != nil {
+					log.Fatalf("failed to watch sample changes: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
@@ -20,6 +20,66 @@ type Config struct {
 		DBName           string `env:"MONGO_DB_NAME" envDefault:"ct_api_document"`
 		Debug            bool   `env:"MONGO_DEBUG" envDefault:"false"`
 	}
+	Lease struct {
+		CronJobTTL        time.Duration `env:"LEASE_CRONJOB_TTL" envDefault:"1m"`
+		BuildStructureTTL time.Duration `env:"LEASE_BUILD_STRUCTURE_TTL" envDefault:"30s"`
+	}
+	Watch struct {
+		Debounce time.Duration `env:"WATCH_DEBOUNCE" envDefault:"5s"`
+		MaxDelay time.Duration `env:"WATCH_MAX_DELAY" envDefault:"1m"`
+	}
+	Auth struct {
+		// the internal endpoints are open unless Enabled, PublicDocs keeps the
+		// read-only documents open while the other endpoints require credentials
+		Enabled       bool          `env:"AUTH_ENABLED" envDefault:"true"`
+		PublicDocs    bool          `env:"AUTH_PUBLIC_DOCS" envDefault:"true"`
+		KeysFile      string        `env:"AUTH_KEYS_FILE"` // vault
+		HMACMaxSkew   time.Duration `env:"AUTH_HMAC_MAX_SKEW" envDefault:"5m"`
+		JWKSFile      string        `env:"AUTH_JWKS_FILE"`
+		JWTIssuer     string        `env:"AUTH_JWT_ISSUER"`
+		JWTAudience   string        `env:"AUTH_JWT_AUDIENCE"`
+		JWTScopeClaim string        `env:"AUTH_JWT_SCOPE_CLAIM" envDefault:"scope"`
+	}
+	Ingestion struct {
+		// Mode is one of constants.IngestionMode*
+		Mode string `env:"INGESTION_MODE" envDefault:"merge"`
+	}
+	Rejections struct {
+		// MaxLineSize truncates the rejected lines stored, a truncated line is
+		// not replayed
+		MaxLineSize int `env:"REJECTIONS_MAX_LINE_SIZE" envDefault:"262144"`
+	}
+	Drift struct {
+		// StructureTTL is how long the structures calls are validated against
+		// are cached
+		StructureTTL time.Duration `env:"DRIFT_STRUCTURE_TTL" envDefault:"1m"`
+		// ReportWindow is the period the report_drift cronjob summarizes
+		ReportWindow time.Duration `env:"DRIFT_REPORT_WINDOW" envDefault:"24h"`
+	}
+	Deprecation struct {
+		// UnseenPeriod flags the apis not called for as long as deprecated
+		// candidates
+		UnseenPeriod time.Duration `env:"DEPRECATION_UNSEEN_PERIOD" envDefault:"720h"`
+		// FieldUnseenPeriod reports the response fields missing from the calls
+		// of their api for as long
+		FieldUnseenPeriod time.Duration `env:"DEPRECATION_FIELD_UNSEEN_PERIOD" envDefault:"720h"`
+		// SoftDelete soft deletes the candidates still not called GracePeriod
+		// after they were flagged
+		SoftDelete  bool          `env:"DEPRECATION_SOFT_DELETE" envDefault:"false"`
+		GracePeriod time.Duration `env:"DEPRECATION_GRACE_PERIOD" envDefault:"720h"`
+	}
+	Samples struct {
+		// RedactKeys are masked in the samples served besides the default ones
+		RedactKeys []string `env:"SAMPLES_REDACT_KEYS" envSeparator:","`
+	}
+	Mock struct {
+		HTTPAddr string `env:"MOCK_HTTP_ADDR" envDefault:"localhost:8081"`
+		// Latency delays the responses by the median latency observed for the
+		// api and status code
+		Latency bool `env:"MOCK_LATENCY" envDefault:"false"`
+		// RoutesTTL is how long the apis are cached before being reloaded
+		RoutesTTL time.Duration `env:"MOCK_ROUTES_TTL" envDefault:"1m"`
+	}
 }
 
 func Load() (*Config, error) {
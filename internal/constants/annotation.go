@@ -0,0 +1,22 @@
+package constants
+
+import "github.com/carousell/ct-go/pkg/container"
+
+const (
+	AnnotationTargetRequest  = "request"
+	AnnotationTargetResponse = "response"
+)
+
+var AnnotationTargets = container.List[string]{
+	AnnotationTargetRequest,
+	AnnotationTargetResponse,
+}
+
+// AnnotationSchemaKeys are the schema keywords an annotation may set, the
+// structure itself (type, properties, items...) stays the inferred one.
+var AnnotationSchemaKeys = container.List[string]{
+	"title",
+	"description",
+	"example",
+	"deprecated",
+}
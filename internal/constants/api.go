@@ -0,0 +1,18 @@
+package constants
+
+import "github.com/carousell/ct-go/pkg/container"
+
+const (
+	DefaultApisLimit = 20
+	MaxApisLimit     = 100
+	DefaultApisSort  = "path"
+)
+
+var ApiSortFields = container.List[string]{
+	"path",
+	"host",
+	"method",
+	"created_at",
+	"last_seen_at",
+	"structured_at",
+}
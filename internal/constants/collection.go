@@ -7,4 +7,13 @@ const (
 	RequestStructuresCollection  = "request_structures"
 	ResponseStructuresCollection = "response_structures"
 	TypesCollection              = "types"
+	LeasesCollection             = "leases"
+	WatchCheckpointsCollection   = "watch_checkpoints"
+	AnnotationsCollection        = "annotations"
+	SchemaOverridesCollection    = "schema_overrides"
+	DriftsCollection             = "drifts"
+	BaselinesCollection          = "baselines"
+	StatsCollection              = "stats"
+	ConsumersCollection          = "consumers"
+	RejectionsCollection         = "rejections"
 )
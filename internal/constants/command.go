@@ -8,3 +8,20 @@ const (
 const (
 	CommandBuildStructure = "build_structure"
 )
+
+const (
+	CommandReportDrift = "report_drift"
+)
+
+const (
+	CommandDetectDeprecated = "detect_deprecated"
+)
+
+const (
+	CommandReplayRejections = "replay_rejections"
+)
+
+const (
+	LeasePrefixCronJob        = "cronjob:"
+	LeasePrefixBuildStructure = "build_structure:"
+)
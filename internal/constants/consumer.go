@@ -0,0 +1,40 @@
+package constants
+
+import "github.com/carousell/ct-go/pkg/container"
+
+// The kinds of the consumers of an api, the ones of ConsumerFieldKinds are also
+// counted by the fields they send.
+const (
+	// ConsumerKindApp is the family of the user agent, with its version
+	ConsumerKindApp = "app"
+	// ConsumerKindConsumer is the kong consumer the call was authenticated as
+	ConsumerKindConsumer = "consumer"
+	// ConsumerKindIdKey is the hash of the x-chotot-id-key header
+	ConsumerKindIdKey = "id_key"
+	// ConsumerKindSubnet is the subnet of the client ip
+	ConsumerKindSubnet = "subnet"
+)
+
+var ConsumerKinds = container.List[string]{
+	ConsumerKindApp,
+	ConsumerKindConsumer,
+	ConsumerKindIdKey,
+	ConsumerKindSubnet,
+}
+
+var ConsumerFieldKinds = container.List[string]{ConsumerKindApp, ConsumerKindConsumer}
+
+const (
+	HeaderUserAgent = "user-agent"
+	HeaderIdKey     = "x-chotot-id-key"
+)
+
+const (
+	// ConsumerIdKeyHashLength is the number of hex characters of the hash of
+	// an id key which are kept
+	ConsumerIdKeyHashLength = 16
+	ConsumerSubnetBitsIPv4  = 24
+	ConsumerSubnetBitsIPv6  = 48
+	// ConsumerMaxFields bounds the fields of a call counted for its consumers
+	ConsumerMaxFields = 50
+)
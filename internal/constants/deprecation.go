@@ -0,0 +1,5 @@
+package constants
+
+// DeprecatedCandidateExtensionKey is the extension of the operations of the
+// apis flagged by the detect_deprecated cronjob.
+const DeprecatedCandidateExtensionKey = "x-deprecated-candidate"
@@ -0,0 +1,19 @@
+package constants
+
+const (
+	IngestionModeMerge = "merge"
+	// IngestionModeValidate also validates each ingested call against the
+	// structures of its api and records the drifts
+	IngestionModeValidate = "validate"
+)
+
+const (
+	DriftKindUndeclaredField   = "undeclared_field"
+	DriftKindTypeMismatch      = "type_mismatch"
+	DriftKindMissingRequired   = "missing_required"
+	DriftKindUnknownStatusCode = "unknown_status_code"
+)
+
+// DriftTargetParameter is the target of the drifts of the query parameters,
+// the drifts of the bodies target TypeRequest and TypeResponse.
+const DriftTargetParameter = "parameter"
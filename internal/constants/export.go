@@ -0,0 +1,7 @@
+package constants
+
+// The formats the export command renders the operation pages in.
+const (
+	ExportPagesMarkdown = "markdown"
+	ExportPagesHTML     = "html"
+)
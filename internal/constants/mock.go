@@ -0,0 +1,23 @@
+package constants
+
+// HeaderPrefer selects the answer of the mock server, e.g. "code=404" or
+// "code=200, example=sample_2".
+const HeaderPrefer = "Prefer"
+
+const (
+	PreferCode    = "code"
+	PreferExample = "example"
+)
+
+const (
+	// MockSourceExample is a representative example of the structure
+	MockSourceExample = "example"
+	// MockSourceSample is the latest stored sample
+	MockSourceSample = "sample"
+	// MockSourceSchema is a body generated from the schema
+	MockSourceSchema = "schema"
+)
+
+// MockLatencySamples is the number of latest samples the latency of a mocked
+// response is observed on.
+const MockLatencySamples = 100
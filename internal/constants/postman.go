@@ -0,0 +1,15 @@
+package constants
+
+// PostmanSchema is the schema of the Postman collections in the v2.1 format.
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanBodyModeRaw is the mode of the bodies written as text.
+const PostmanBodyModeRaw = "raw"
+
+// PostmanLanguageJSON highlights the raw bodies and the examples as JSON.
+const PostmanLanguageJSON = "json"
+
+const (
+	HeaderContentType   = "Content-Type"
+	MIMEApplicationJSON = "application/json"
+)
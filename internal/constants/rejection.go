@@ -0,0 +1,35 @@
+package constants
+
+import "github.com/carousell/ct-go/pkg/container"
+
+// The reasons a log line is rejected by the ingestion.
+const (
+	RejectionReasonInvalidUtf8 = "invalid_utf8"
+	// RejectionReasonInvalidEscape is a line kong did not escape as a quoted
+	// string
+	RejectionReasonInvalidEscape = "invalid_escape"
+	RejectionReasonInvalidJson   = "invalid_json"
+	// RejectionReasonInvalidLog is a JSON line missing its request or response
+	RejectionReasonInvalidLog  = "invalid_log"
+	RejectionReasonStoreFailed = "store_failed"
+)
+
+var RejectionReasons = container.List[string]{
+	RejectionReasonInvalidUtf8,
+	RejectionReasonInvalidEscape,
+	RejectionReasonInvalidJson,
+	RejectionReasonInvalidLog,
+	RejectionReasonStoreFailed,
+}
+
+// The stages of the ingestion a line is rejected at, a line rejected before
+// being pre processed is kept as read from its file.
+const (
+	RejectionStagePreProcess = "pre_process"
+	RejectionStageProcess    = "process"
+)
+
+const (
+	DefaultRejectionsLimit = 20
+	MaxRejectionsLimit     = 100
+)
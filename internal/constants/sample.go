@@ -0,0 +1,29 @@
+package constants
+
+// HeaderCorrelationId is the header kong identifies a call with, in the
+// request and response messages of its logs.
+const HeaderCorrelationId = "x-correlation-id"
+
+const (
+	DefaultSamplesLimit = 20
+	MaxSamplesLimit     = 100
+	// MaxSamplesScanned bounds the samples a page of samples scans to apply
+	// the filters mongo can not, the next page resumes the scan.
+	MaxSamplesScanned = 2000
+	// SamplesPairingBatchSize is the number of sample requests whose responses
+	// are fetched together.
+	SamplesPairingBatchSize = 100
+)
+
+const (
+	// MaxExamples is the number of examples kept per request body and per
+	// status code of the responses.
+	MaxExamples = 3
+	// MaxParameterExamples is the number of distinct values kept as examples
+	// of a parameter.
+	MaxParameterExamples = 3
+)
+
+// MaxExampleBodySize is the size of the largest body kept as an example, in
+// bytes, larger ones would bloat the documents and the structures.
+const MaxExampleBodySize = 64 << 10
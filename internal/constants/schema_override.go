@@ -0,0 +1,23 @@
+package constants
+
+import "github.com/carousell/ct-go/pkg/container"
+
+const (
+	SchemaSourceKey      = "x-source"
+	SchemaSourceInferred = "inferred"
+	SchemaSourceOverride = "override"
+)
+
+// OverrideTypes are the types an override rule may pin.
+var OverrideTypes = container.List[string]{
+	"string",
+	"number",
+	"integer",
+	"boolean",
+	"object",
+	"array",
+}
+
+// OverrideValidationSamples is the number of recent samples of each target an
+// override is validated against.
+const OverrideValidationSamples = 20
@@ -0,0 +1,37 @@
+package constants
+
+import (
+	"regexp"
+
+	"github.com/carousell/ct-go/pkg/container"
+)
+
+const (
+	SecuritySchemeTypeHTTP   = "http"
+	SecuritySchemeTypeAPIKey = "apiKey"
+
+	HTTPSchemeBearer = "bearer"
+	HTTPSchemeBasic  = "basic"
+
+	CredentialInHeader = "header"
+	CredentialInQuery  = "query"
+	CredentialInCookie = "cookie"
+)
+
+// CredentialHeaders are the headers carrying an api key.
+var CredentialHeaders = container.List[string]{
+	"x-chotot-id-key",
+	"x-api-key",
+	"apikey",
+}
+
+// CredentialQueryParameters are the query parameters carrying an api key.
+var CredentialQueryParameters = container.List[string]{
+	"api_key",
+	"apikey",
+	"access_key",
+}
+
+// SessionCookieRegexp matches the names of the cookies holding a session,
+// the other cookies are not credentials.
+var SessionCookieRegexp = regexp.MustCompile(`(?i)(session|token|auth|sid)`)
@@ -0,0 +1,29 @@
+package constants
+
+import "time"
+
+// The latency headers kong adds to the responses it proxies, in milliseconds.
+const (
+	HeaderUpstreamLatency = "x-kong-upstream-latency"
+	HeaderProxyLatency    = "x-kong-proxy-latency"
+)
+
+// StatsLatencyBucketsMs are the upper bounds of the latency histogram of the
+// hourly stats, the calls slower than the last one fall into
+// StatsLatencyOverflowBucket.
+var StatsLatencyBucketsMs = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+const StatsLatencyOverflowBucket = "inf"
+
+const (
+	// StatsDefaultWindow is the period the stats endpoint returns when no range
+	// is given
+	StatsDefaultWindow = 24 * time.Hour
+	// StatsDocumentWindow is the period summarized into the x-traffic extension
+	// of the operations
+	StatsDocumentWindow = 7 * 24 * time.Hour
+)
+
+// TrafficExtensionKey is the extension of the operations summarizing their
+// traffic over StatsDocumentWindow.
+const TrafficExtensionKey = "x-traffic"
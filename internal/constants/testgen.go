@@ -0,0 +1,22 @@
+package constants
+
+// The files the test generator writes, the Go tests being named after their
+// package, e.g. apitest_test.go.
+const (
+	TestgenCurlFile = "replay.sh"
+	TestgenK6File   = "k6.js"
+)
+
+const (
+	// TestgenDefaultPackage is the package of the generated Go tests when none
+	// is given
+	TestgenDefaultPackage = "apitest"
+	// TestgenSamplesPerApi is the number of samples replayed per api when none
+	// is given
+	TestgenSamplesPerApi = 5
+	// TestgenDistributionSamples is the number of latest samples of an api the
+	// distributions of its parameters are taken from
+	TestgenDistributionSamples = 200
+	// TestgenK6Bodies bounds the distinct request bodies a k6 script picks from
+	TestgenK6Bodies = 20
+)
@@ -0,0 +1,11 @@
+package constants
+
+// The languages types are generated in.
+const (
+	TypegenLanguageGo         = "go"
+	TypegenLanguageTypeScript = "typescript"
+)
+
+// TypegenDefaultPackage is the package of the generated Go code when none is
+// given.
+const TypegenDefaultPackage = "types"
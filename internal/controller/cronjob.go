@@ -2,13 +2,19 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	"github.com/carousell/ct-go/pkg/cronjob"
 	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
 	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
 	buildstructure "github.com/ct-logic-api-document/internal/usecase/build_structure"
+	"github.com/ct-logic-api-document/internal/usecase/deprecation"
+	"github.com/ct-logic-api-document/internal/usecase/drift"
 	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 )
 
 type CronJobOptions struct {
@@ -17,8 +23,12 @@ type CronJobOptions struct {
 }
 
 func NewCronJob(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
 	fetchDataUC fetchdata.IFetchDataUC,
 	buildStructureUC buildstructure.IBuildStructureUC,
+	driftUC drift.IDriftUC,
+	deprecationUC deprecation.IDeprecationUC,
 ) (map[string]CronJobOptions, error) {
 	ctx := context.Background()
 	logctx.AppendName(ctx, "cron_job")
@@ -35,6 +45,18 @@ func NewCronJob(
 			Name:    constants.CommandBuildStructure,
 			Handler: buildStructureUC.BuildStructure,
 		},
+		constants.CommandReportDrift: {
+			Name:    constants.CommandReportDrift,
+			Handler: driftUC.ReportDrift,
+		},
+		constants.CommandDetectDeprecated: {
+			Name:    constants.CommandDetectDeprecated,
+			Handler: deprecationUC.DetectDeprecated,
+		},
+		constants.CommandReplayRejections: {
+			Name:    constants.CommandReplayRejections,
+			Handler: fetchDataUC.ReplayRejections,
+		},
 	}
 	argsWithProg := os.Args
 	cronJobType := argsWithProg[2]
@@ -42,6 +64,24 @@ func NewCronJob(
 	if !ok {
 		panic("cronjob type not found")
 	}
-	cronjob.StartCronjobWithMetric(cronJobOpt.Handler, cronJobType)
+	cronjob.StartCronjobWithMetric(withLease(conf, storage, cronJobOpt), cronJobType)
 	return cronJobHandlerMap, nil
 }
+
+// withLease makes sure only one instance of a cronjob runs at a time, a run
+// which overlaps with a previous one still holding the lease is skipped.
+func withLease(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	cronJobOpt CronJobOptions,
+) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		leaseName := constants.LeasePrefixCronJob + cronJobOpt.Name
+		err := storage.WithLease(ctx, leaseName, conf.Lease.CronJobTTL, cronJobOpt.Handler)
+		if errors.Is(err, mongodbutils.ErrLeaseNotAcquired) {
+			logctx.Infow(ctx, "cronjob is already running, skipped", "cronjob", cronJobOpt.Name)
+			return nil
+		}
+		return err
+	}
+}
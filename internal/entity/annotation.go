@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"github.com/ct-logic-api-document/internal/constants"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Annotation is the human documentation of a field of the request or response
+// schema of an api, stored apart from the inferred structure so rebuilding the
+// structure keeps it.
+type Annotation struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	// Target is constants.AnnotationTargetRequest or constants.AnnotationTargetResponse
+	Target string `json:"target" bson:"target"`
+	// Pointer is the JSON pointer of the annotated schema in the body schema of Target, e.g. /properties/price
+	Pointer string `json:"pointer" bson:"pointer"`
+	// Schema holds the keywords overlaid onto the annotated schema
+	Schema map[string]any `json:"schema" bson:"schema"`
+	// Orphaned is set when Pointer no longer resolves in the structure of Target
+	Orphaned bool `json:"orphaned" bson:"-"`
+}
+
+type UpdateApiRequest struct {
+	ApiId       string   `json:"-"`
+	Title       *string  `json:"title"`
+	Description *string  `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+type SaveAnnotationRequest struct {
+	ApiId   string         `json:"-"`
+	Target  string         `json:"target"`
+	Pointer string         `json:"pointer"`
+	Schema  map[string]any `json:"schema"`
+}
+
+type DeleteAnnotationRequest struct {
+	ApiId   string
+	Target  string
+	Pointer string
+}
+
+// Resolve returns the schema the annotation applies to among the body schemas
+// of the structures, which may be nil. ok is false when the annotation is orphaned.
+func (a *Annotation) Resolve(requestStructure *RequestStructure, responseStructure *ResponseStructure) (map[string]any, bool) {
+	var bodySchema map[string]any
+	switch {
+	case a.Target == constants.AnnotationTargetRequest && requestStructure != nil:
+		bodySchema = requestStructure.Schema()
+	case a.Target == constants.AnnotationTargetResponse && responseStructure != nil:
+		bodySchema = responseStructure.Schema()
+	}
+	if len(bodySchema) == 0 {
+		return nil, false
+	}
+	return openapiutils.ResolveSchema(bodySchema, a.Pointer)
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
@@ -14,4 +16,74 @@ type Api struct {
 	Method                  string     `json:"method" bson:"method"`
 	Description             string     `json:"description" bson:"description"`
 	LatestBuildStructure    *time.Time `json:"latest_build_structure" bson:"latest_build_structure"`
+	// StructuredAt is the last time a structure of the api was saved, by a build or a watch merge
+	StructuredAt *time.Time `json:"structured_at,omitempty" bson:"structured_at,omitempty"`
+	// LastSeenAt is the time of the latest ingested call of the api
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty" bson:"last_seen_at,omitempty"`
+	// Tags replace the tag inferred from the path in the OpenAPI documents
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// DeprecatedCandidateAt is the time the api was flagged as not called
+	// anymore by the detect_deprecated cronjob
+	DeprecatedCandidateAt *time.Time `json:"deprecated_candidate_at,omitempty" bson:"deprecated_candidate_at,omitempty"`
+}
+
+// tagPathDepth is the number of path segments, after the version, grouping the
+// operations of a host into a tag.
+const tagPathDepth = 2
+
+var versionSegmentRegexp = regexp.MustCompile(`^v\d+$`)
+
+// OperationTag groups the operations by host and the first segments of their
+// path, skipping the version and stopping at path parameters, e.g.
+// gateway.chotot.org/v1/private/bank_transfer/contract-history/{id} is tagged
+// gateway.chotot.org/private/bank_transfer.
+func (a *Api) OperationTag() string {
+	segments := []string{a.Host}
+	for _, segment := range strings.Split(a.Path, "/") {
+		if segment == "" || versionSegmentRegexp.MatchString(segment) {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") || len(segments) > tagPathDepth {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// OperationTags returns the tags curated on the api, or else the one inferred
+// from its path.
+func (a *Api) OperationTags() []string {
+	if len(a.Tags) > 0 {
+		return a.Tags
+	}
+	return []string{a.OperationTag()}
+}
+
+type GetApisRequest struct {
+	Host   string
+	Method string
+	// Path matches the apis whose path contains it
+	Path         string
+	HasStructure *bool
+	LastSeenFrom *time.Time
+	LastSeenTo   *time.Time
+	// Sort is a field of constants.ApiSortFields, prefixed by "-" for a descending order
+	Sort   string
+	Limit  int64
+	Offset int64
+}
+
+type GetApisResponse struct {
+	Apis   []*Api `json:"apis"`
+	Total  int64  `json:"total"`
+	Limit  int64  `json:"limit"`
+	Offset int64  `json:"offset"`
+}
+
+type ApiDetail struct {
+	*Api
+	SampleRequestCount  int64 `json:"sample_request_count"`
+	SampleResponseCount int64 `json:"sample_response_count"`
+	StatusCodes         []int `json:"status_codes"`
 }
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApi_OperationTag(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		api     *Api
+		wantTag string
+	}{
+		{
+			name:    "Test OperationTag - skip version",
+			api:     &Api{Host: "gateway.chotot.org", Path: "/v1/private/bank_transfer/contract-history/{id}"},
+			wantTag: "gateway.chotot.org/private/bank_transfer",
+		},
+		{
+			name:    "Test OperationTag - stop at path parameter",
+			api:     &Api{Host: "gateway.chotot.org", Path: "/v2/ads/{id}/images"},
+			wantTag: "gateway.chotot.org/ads",
+		},
+		{
+			name:    "Test OperationTag - root path",
+			api:     &Api{Host: "gateway.chotot.org", Path: "/"},
+			wantTag: "gateway.chotot.org",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantTag, tt.api.OperationTag())
+		})
+	}
+}
+
+func TestApi_OperationTags(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		api      *Api
+		wantTags []string
+	}{
+		{
+			name:     "Test OperationTags - inferred",
+			api:      &Api{Host: "gateway.chotot.org", Path: "/v1/public/ads"},
+			wantTags: []string{"gateway.chotot.org/public/ads"},
+		},
+		{
+			name:     "Test OperationTags - curated",
+			api:      &Api{Host: "gateway.chotot.org", Path: "/v1/public/ads", Tags: []string{"Ads", "Public"}},
+			wantTags: []string{"Ads", "Public"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantTags, tt.api.OperationTags())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Baseline is the declared structure of an api, imported from a hand-maintained
+// OpenAPI document to be compared with the structure observed in the traffic.
+type Baseline struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	// Source is the file the baseline was imported from
+	Source            string         `json:"source" bson:"source"`
+	OperationId       string         `json:"operation_id,omitempty" bson:"operation_id,omitempty"`
+	Parameters        []*Parameter   `json:"parameters" bson:"parameters"`
+	RequestBodySchema map[string]any `json:"request_body_schema,omitempty" bson:"request_body_schema,omitempty"`
+	// ResponseBodySchemas are the declared response body schemas by status
+	// code, as written in the document, e.g. "200" or "default"
+	ResponseBodySchemas map[string]map[string]any `json:"response_body_schemas,omitempty" bson:"response_body_schemas,omitempty"`
+}
+
+type ImportOpenAPIResponse struct {
+	Source      string              `json:"source"`
+	Operations  int                 `json:"operations"`
+	CreatedApis int                 `json:"created_apis"`
+	LinkedApis  int                 `json:"linked_apis"`
+	Comparison  *BaselineComparison `json:"comparison"`
+}
+
+// BaselineComparison lists the differences between the operations of an
+// imported document and the apis observed on its servers.
+type BaselineComparison struct {
+	// UndocumentedEndpoints are observed but not declared
+	UndocumentedEndpoints []*Endpoint `json:"undocumented_endpoints"`
+	// NeverSeenOperations are declared but not observed
+	NeverSeenOperations []*Endpoint `json:"never_seen_operations"`
+	// UndocumentedFields are observed in an endpoint both declared and
+	// observed, but not declared
+	UndocumentedFields []*UndocumentedField `json:"undocumented_fields"`
+}
+
+type Endpoint struct {
+	ApiId  primitive.ObjectID `json:"api_id"`
+	Method string             `json:"method"`
+	Path   string             `json:"path"`
+}
+
+type UndocumentedField struct {
+	Endpoint
+	// Target is constants.TypeRequest, constants.TypeResponse or
+	// constants.DriftTargetParameter
+	Target string `json:"target"`
+	// Field is the path of a body field, e.g. $.ads[*].price, or the name of a
+	// parameter
+	Field string `json:"field"`
+}
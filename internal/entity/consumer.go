@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClientIdentity is who made a call logged by kong, its members are empty
+// when the log does not tell.
+type ClientIdentity struct {
+	App        string
+	AppVersion string
+	Consumer   string
+	// IdKeyHash is the truncated hash of the x-chotot-id-key header, the key
+	// itself is a credential
+	IdKeyHash string
+	Subnet    string
+}
+
+// ConsumerUsage counts the calls of an api by a consumer, or the calls in
+// which the consumer sent a field when Field is set.
+type ConsumerUsage struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	// Kind is one of constants.ConsumerKind*
+	Kind string `json:"kind" bson:"kind"`
+	Name string `json:"name" bson:"name"`
+	// Version is the version of the app, empty for the other kinds
+	Version string `json:"version" bson:"version"`
+	// Target is constants.DriftTargetParameter or constants.TypeRequest, empty
+	// with Field for the calls
+	Target string `json:"target" bson:"target"`
+	// Field is the name of a query parameter or the path of a body field,
+	// e.g. $.ads[*].price
+	Field       string     `json:"field" bson:"field"`
+	Count       int64      `json:"count" bson:"count"`
+	FirstSeenAt *time.Time `json:"first_seen_at" bson:"first_seen_at"`
+	LastSeenAt  *time.Time `json:"last_seen_at" bson:"last_seen_at"`
+}
+
+// ApiConsumer is a consumer of an api with the fields it sends.
+type ApiConsumer struct {
+	Kind        string           `json:"kind"`
+	Name        string           `json:"name"`
+	Version     string           `json:"version,omitempty"`
+	Count       int64            `json:"count"`
+	FirstSeenAt *time.Time       `json:"first_seen_at"`
+	LastSeenAt  *time.Time       `json:"last_seen_at"`
+	Fields      []*ConsumerField `json:"fields,omitempty"`
+}
+
+type ConsumerField struct {
+	Target     string     `json:"target"`
+	Field      string     `json:"field"`
+	Count      int64      `json:"count"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
+
+type GetConsumersRequest struct {
+	ApiId string
+	// Kind only keeps the consumers of a constants.ConsumerKind*
+	Kind string
+	// Field only keeps the consumers sending the query parameter or body field
+	Field string
+	// From only keeps the consumers seen since
+	From *time.Time
+}
+
+type GetConsumersResponse struct {
+	Consumers []*ApiConsumer `json:"consumers"`
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Violation is a mismatch between a call and the structures of its api.
+type Violation struct {
+	// Target is constants.TypeRequest, constants.TypeResponse or
+	// constants.DriftTargetParameter
+	Target string
+	// Kind is one of constants.DriftKind*
+	Kind string
+	// Field is the path of a body field, e.g. $.ads[*].price, the name of a
+	// parameter or a status code
+	Field   string
+	Message string
+}
+
+// Drift aggregates the violations of an api of the same target, kind and
+// field, Count is the number of calls they were found in.
+type Drift struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	Target                  string             `json:"target" bson:"target"`
+	Kind                    string             `json:"kind" bson:"kind"`
+	Field                   string             `json:"field" bson:"field"`
+	// Message is the message of the latest violation
+	Message     string     `json:"message" bson:"message"`
+	Count       int64      `json:"count" bson:"count"`
+	FirstSeenAt *time.Time `json:"first_seen_at" bson:"first_seen_at"`
+	LastSeenAt  *time.Time `json:"last_seen_at" bson:"last_seen_at"`
+}
+
+type GetDriftsRequest struct {
+	ApiId string
+	// From only keeps the drifts seen since
+	From *time.Time
+}
+
+type GetDriftsResponse struct {
+	Drifts []*Drift `json:"drifts"`
+}
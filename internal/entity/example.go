@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Example is a redacted sample body shown in the documents, the builder keeps
+// the samples covering the most fields.
+type Example struct {
+	SampleId  primitive.ObjectID `json:"sample_id" bson:"sample_id"`
+	CreatedAt *time.Time         `json:"created_at" bson:"created_at"`
+	// Value is the redacted JSON body, kept as a string so it is served as it
+	// was recorded
+	Value string `json:"value" bson:"value"`
+	// Fields are the paths of the fields of Value, the items of an array
+	// sharing a path
+	Fields []string `json:"fields" bson:"fields"`
+}
+
+func (e *Example) buildExample() container.Map {
+	example := container.Map{
+		"value": json.RawMessage(e.Value),
+	}
+	if e.CreatedAt != nil {
+		example["summary"] = "Recorded at " + e.CreatedAt.UTC().Format(time.DateTime) + " UTC"
+	}
+	return example
+}
+
+// buildExamples returns the examples object of a media type or parameter,
+// nil when there is no example.
+func buildExamples(examples []*Example) container.Map {
+	if len(examples) == 0 {
+		return nil
+	}
+	built := make(container.Map, len(examples))
+	for i, example := range examples {
+		built[ExampleName(i)] = example.buildExample()
+	}
+	return built
+}
+
+// ExampleName is the name of the i-th example in the documents, e.g. sample_1.
+func ExampleName(i int) string {
+	return fmt.Sprintf("sample_%d", i+1)
+}
@@ -0,0 +1,15 @@
+package entity
+
+type ExportRequest struct {
+	Dir string
+	// Version is the OpenAPI version of the documents, 3.0 when empty
+	Version string
+	// Pages is constants.ExportPagesMarkdown or constants.ExportPagesHTML
+	Pages string
+}
+
+type ExportResponse struct {
+	Dir        string `json:"dir"`
+	Hosts      int    `json:"hosts"`
+	Operations int    `json:"operations"`
+}
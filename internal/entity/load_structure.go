@@ -1,15 +1,25 @@
 package entity
 
-import "github.com/carousell/ct-go/pkg/container"
+import (
+	"time"
 
+	"github.com/carousell/ct-go/pkg/container"
+)
+
+// LoadStructureByApiIdResponse is an OpenAPI document. OpenApi, Info and Paths
+// are omitted when empty so the document can be streamed around its paths.
 type LoadStructureByApiIdResponse struct {
-	OpenApi             string        `json:"openapi"`
-	Info                *Info         `json:"info"`
-	Servers             []*Server     `json:"servers"`
-	Tags                []*Tag        `json:"tags,omitempty"`
-	Paths               container.Map `json:"paths"`
-	SecurityDefinitions container.Map `json:"securityDefinitions,omitempty"`
-	Security            []any         `json:"security,omitempty"`
+	OpenApi    string        `json:"openapi,omitempty"`
+	Info       *Info         `json:"info,omitempty"`
+	Servers    []*Server     `json:"servers"`
+	Tags       []*Tag        `json:"tags,omitempty"`
+	Paths      container.Map `json:"paths,omitempty"`
+	Components *Components   `json:"components,omitempty"`
+	Security   []any         `json:"security,omitempty"`
+}
+
+type Components struct {
+	SecuritySchemes container.Map `json:"securitySchemes,omitempty"`
 }
 
 func (l *LoadStructureByApiIdResponse) LoadDefault() {
@@ -22,19 +32,6 @@ func (l *LoadStructureByApiIdResponse) LoadDefault() {
 	l.Servers = []*Server{}
 	l.Tags = []*Tag{}
 	l.Paths = make(container.Map)
-	l.SecurityDefinitions = container.Map{
-		"Bearer": container.Map{
-			"type":        "apiKey",
-			"name":        "Authorization",
-			"in":          "header",
-			"description": "Enter the token with the `Bearer: ` prefix, e.g. \"Bearer abcde12345\".",
-		},
-	}
-	l.Security = []any{
-		container.Map{
-			"Bearer": []string{},
-		},
-	}
 }
 
 type Info struct {
@@ -51,3 +48,22 @@ type Tag struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
+
+// ApiFilter selects the apis of an aggregated OpenAPI document, empty fields
+// match every api.
+type ApiFilter struct {
+	Host         string
+	PathPrefix   string
+	UpdatedSince *time.Time
+}
+
+type LoadStructureByApiIdRequest struct {
+	ApiId   string
+	Version string
+}
+
+type LoadOpenApiDocumentRequest struct {
+	ApiFilter
+	Tag     string
+	Version string
+}
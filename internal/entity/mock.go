@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+type MockRequest struct {
+	Method string
+	Path   string
+	// Prefer is the Prefer header of the request
+	Prefer string
+}
+
+type MockResponse struct {
+	StatusCode int
+	// Body is a JSON body, empty for the status codes answered without one
+	Body string
+	// Source is one of constants.MockSource*
+	Source string
+	// Latency is how long the response is delayed
+	Latency time.Duration
+}
@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"encoding/json"
+)
+
+// PostmanCollection is a Postman collection in the v2.1 format, only the
+// members the export writes and the import reads are declared.
+type PostmanCollection struct {
+	Info     *PostmanInfo       `json:"info"`
+	Item     []*PostmanItem     `json:"item"`
+	Variable []*PostmanVariable `json:"variable,omitempty"`
+}
+
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem is a request with its saved examples, or a folder of items when
+// Item is set.
+type PostmanItem struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Item        []*PostmanItem     `json:"item,omitempty"`
+	Request     *PostmanRequest    `json:"request,omitempty"`
+	Response    []*PostmanResponse `json:"response,omitempty"`
+}
+
+type PostmanRequest struct {
+	Method      string           `json:"method"`
+	Header      []*PostmanHeader `json:"header"`
+	Body        *PostmanBody     `json:"body,omitempty"`
+	Url         *PostmanUrl      `json:"url"`
+	Description string           `json:"description,omitempty"`
+}
+
+type PostmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type PostmanBody struct {
+	// Mode is "raw" for the bodies written as text, the only ones imported
+	Mode    string              `json:"mode"`
+	Raw     string              `json:"raw,omitempty"`
+	Options *PostmanBodyOptions `json:"options,omitempty"`
+}
+
+type PostmanBodyOptions struct {
+	Raw *PostmanRawOptions `json:"raw,omitempty"`
+}
+
+type PostmanRawOptions struct {
+	Language string `json:"language"`
+}
+
+// PostmanUrl is written as an object, Postman also accepts the raw url as a
+// plain string.
+type PostmanUrl struct {
+	Raw      string             `json:"raw"`
+	Host     []string           `json:"host,omitempty"`
+	Path     []string           `json:"path,omitempty"`
+	Query    []*PostmanQuery    `json:"query,omitempty"`
+	Variable []*PostmanVariable `json:"variable,omitempty"`
+}
+
+func (u *PostmanUrl) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = PostmanUrl{Raw: raw}
+		return nil
+	}
+	type postmanUrl PostmanUrl
+	return json.Unmarshal(data, (*postmanUrl)(u))
+}
+
+type PostmanQuery struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type PostmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// PostmanResponse is a saved example of a request.
+type PostmanResponse struct {
+	Name            string           `json:"name"`
+	OriginalRequest *PostmanRequest  `json:"originalRequest,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	Code            int              `json:"code"`
+	Header          []*PostmanHeader `json:"header"`
+	Body            string           `json:"body,omitempty"`
+	// PreviewLanguage is the language Postman highlights the body in
+	PreviewLanguage string `json:"_postman_previewlanguage,omitempty"`
+}
+
+type ExportPostmanRequest struct {
+	ApiFilter
+	Tag string
+}
+
+type ImportPostmanResponse struct {
+	// Examples is the number of saved examples found in the collection
+	Examples int `json:"examples"`
+	Imported int `json:"imported"`
+	// Skipped are the examples whose url could not be resolved
+	Skipped int `json:"skipped"`
+}
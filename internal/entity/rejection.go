@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+)
+
+// Rejection is a log line the ingestion could not ingest, kept to be replayed
+// once the cause is fixed.
+type Rejection struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	// Source is the file the line was read from
+	Source     string `json:"source" bson:"source"`
+	LineNumber int    `json:"line_number" bson:"line_number"`
+	// Stage is one of constants.RejectionStage*
+	Stage string `json:"stage" bson:"stage"`
+	// Reason is one of constants.RejectionReason*
+	Reason string `json:"reason" bson:"reason"`
+	Error  string `json:"error" bson:"error"`
+	// Line is redacted like the samples served
+	Line string `json:"line" bson:"line"`
+	// RawLine is the line as read, it is never served and only read by the
+	// replay
+	RawLine string `json:"-" bson:"raw_line,omitempty"`
+	// Truncated lines are cut to REJECTIONS_MAX_LINE_SIZE, their raw line is
+	// not kept and they can not be replayed
+	Truncated bool `json:"truncated" bson:"truncated"`
+	// Count is the number of times the line was rejected, replays included
+	Count          int64      `json:"count" bson:"count"`
+	LastRejectedAt *time.Time `json:"last_rejected_at" bson:"last_rejected_at"`
+}
+
+type GetRejectionsRequest struct {
+	Reason string
+	// Source only keeps the lines of the file
+	Source string
+	Limit  int64
+	Offset int64
+}
+
+// GetRejectionsResponse lists the rejected lines from the most recently
+// rejected, Counts is the number of lines by reason of the source.
+type GetRejectionsResponse struct {
+	Counts     map[string]int64 `json:"counts"`
+	Rejections []*Rejection     `json:"rejections"`
+	Total      int64            `json:"total"`
+	Limit      int64            `json:"limit"`
+	Offset     int64            `json:"offset"`
+}
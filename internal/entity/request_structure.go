@@ -11,17 +11,29 @@ type RequestStructure struct {
 	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
 	Parameters              []*Parameter       `json:"parameters" bson:"parameters"`
 	BodySchema              map[string]any     `json:"body_schema" bson:"body_schema"`
+	Version                 int64              `json:"version" bson:"version"`
+	// EffectiveBodySchema is BodySchema with the schema override of the api
+	// applied, BodySchema keeps the inferred one the next samples are merged into
+	EffectiveBodySchema map[string]any `json:"effective_body_schema,omitempty" bson:"effective_body_schema,omitempty"`
+	Security            *SecurityUsage `json:"security,omitempty" bson:"security,omitempty"`
+	// Examples are the redacted sample bodies shown in the documents
+	Examples []*Example `json:"examples,omitempty" bson:"examples,omitempty"`
 }
 
 func (r *RequestStructure) BuildRequestBody() any {
-	if len(r.BodySchema) == 0 {
+	bodySchema := r.Schema()
+	if len(bodySchema) == 0 {
 		return nil
 	}
+	mediaType := container.Map{
+		"schema": bodySchema,
+	}
+	if examples := buildExamples(r.Examples); examples != nil {
+		mediaType["examples"] = examples
+	}
 	return container.Map{
 		"content": container.Map{
-			"application/json": container.Map{
-				"schema": r.BodySchema,
-			},
+			"application/json": mediaType,
 		},
 	}
 }
@@ -36,3 +48,12 @@ func (r *RequestStructure) BuildParameters() any {
 	}
 	return parameters
 }
+
+// Schema returns the body schema documents are built from, the overridden one
+// when the api has a schema override.
+func (r *RequestStructure) Schema() map[string]any {
+	if r.EffectiveBodySchema != nil {
+		return r.EffectiveBodySchema
+	}
+	return r.BodySchema
+}
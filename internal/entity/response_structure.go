@@ -1,29 +1,93 @@
 package entity
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/carousell/ct-go/pkg/container"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"github.com/spf13/cast"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const successStatusCode = "200"
+
 type ResponseStructure struct {
 	mongodbutils.BaseEntity `bson:",inline"`
 	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
 	BodySchema              map[string]any     `json:"body_schema" bson:"body_schema"`
+	Version                 int64              `json:"version" bson:"version"`
+	// EffectiveBodySchema is BodySchema with the schema override of the api
+	// applied, BodySchema keeps the inferred one the next samples are merged into
+	EffectiveBodySchema map[string]any `json:"effective_body_schema,omitempty" bson:"effective_body_schema,omitempty"`
+	// Examples are the redacted sample bodies shown in the documents, by status
+	// code
+	Examples map[string][]*Example `json:"examples,omitempty" bson:"examples,omitempty"`
+	// FieldsSeen are the fields of the sample bodies, with the paths of
+	// Example.Fields, and the last time they were seen
+	FieldsSeen []*FieldSeen `json:"fields_seen,omitempty" bson:"fields_seen,omitempty"`
 }
 
+type FieldSeen struct {
+	Field      string    `json:"field" bson:"field"`
+	LastSeenAt time.Time `json:"last_seen_at" bson:"last_seen_at"`
+}
+
+// BuildResponseBody documents the schema under 200, the other status codes
+// the api was seen answering only get their examples.
 func (r *ResponseStructure) BuildResponseBody() any {
-	if len(r.BodySchema) == 0 {
+	bodySchema := r.Schema()
+	if len(bodySchema) == 0 {
 		return nil
 	}
-	return container.Map{
-		"200": container.Map{
+	mediaType := container.Map{
+		"schema": bodySchema,
+	}
+	if examples := buildExamples(r.Examples[successStatusCode]); examples != nil {
+		mediaType["examples"] = examples
+	}
+	responses := container.Map{
+		successStatusCode: container.Map{
 			"description": "Success",
+			"content": container.Map{
+				"application/json": mediaType,
+			},
+		},
+	}
+	for statusCode, statusExamples := range r.Examples {
+		examples := buildExamples(statusExamples)
+		if statusCode == successStatusCode || examples == nil {
+			continue
+		}
+		responses[statusCode] = container.Map{
+			"description": http.StatusText(cast.ToInt(statusCode)),
 			"content": container.Map{
 				"application/json": container.Map{
-					"schema": r.BodySchema,
+					"examples": examples,
 				},
 			},
-		},
+		}
+	}
+	return responses
+}
+
+// Example returns the example named name of statusCode, the first one when
+// name is empty, nil when there is none.
+func (r *ResponseStructure) Example(statusCode int, name string) *Example {
+	for i, example := range r.Examples[strconv.Itoa(statusCode)] {
+		if name == "" || ExampleName(i) == name {
+			return example
+		}
+	}
+	return nil
+}
+
+// Schema returns the body schema documents are built from, the overridden one
+// when the api has a schema override.
+func (r *ResponseStructure) Schema() map[string]any {
+	if r.EffectiveBodySchema != nil {
+		return r.EffectiveBodySchema
 	}
+	return r.BodySchema
 }
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	jsonpathutils "github.com/ct-logic-api-document/utils/jsonpath"
+)
+
+// Sample is a recorded call of an api, the response is nil when it was not
+// recorded or can not be paired with the request.
+type Sample struct {
+	CorrelationId string          `json:"correlation_id,omitempty"`
+	Request       *SampleRequest  `json:"request"`
+	Response      *SampleResponse `json:"response"`
+}
+
+type GetSamplesRequest struct {
+	ApiId      string
+	StatusCode int
+	From       *time.Time
+	To         *time.Time
+	// Parameters the samples were called with, by name
+	Parameters map[string]string
+	// RequestBody and ResponseBody are predicates on the JSON bodies
+	RequestBody  *jsonpathutils.Predicate
+	ResponseBody *jsonpathutils.Predicate
+	Limit        int64
+	// Cursor is the next cursor of the previous page
+	Cursor string
+}
+
+// GetSamplesResponse lists the samples from the most recent. NextCursor is
+// set while older samples remain to be scanned, a page may hold less than
+// the limit when the filters discard most of the scanned samples.
+type GetSamplesResponse struct {
+	Samples    []*Sample `json:"samples"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
@@ -1,9 +1,11 @@
 package entity
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -13,6 +15,11 @@ type SampleRequest struct {
 	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
 	Parameters              []*Parameter       `json:"parameters" bson:"parameters"`
 	Body                    string             `json:"body" bson:"body"`
+	// Credentials the request authenticated with, nil for the samples
+	// ingested before they were recorded
+	Credentials []*Credential `json:"credentials" bson:"credentials"`
+	// CorrelationId pairs the sample with the sample response of the same call
+	CorrelationId string `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"`
 }
 
 type Parameter struct {
@@ -21,17 +28,51 @@ type Parameter struct {
 	Value    string `json:"value" bson:"value"`
 	In       string `json:"in" bson:"in"`
 	Required bool   `json:"required" bson:"required"`
+	// Examples are distinct redacted values of the parameter, from the most
+	// recent, they are only kept on the structures
+	Examples []string `json:"examples,omitempty" bson:"examples,omitempty"`
 }
 
 func (r *Parameter) buildParameter() any {
-	return container.Map{
+	schema := container.Map{
+		"type": r.Type,
+	}
+	// a parameter seen with several types accepts any value
+	if r.Type == constants.ParameterTypeAny {
+		schema = container.Map{}
+	}
+	parameter := container.Map{
 		"name":     r.Name,
 		"in":       r.In,
 		"required": r.Required,
-		"schema": container.Map{
-			"type": r.Type,
-		},
+		"schema":   schema,
+	}
+	if len(r.Examples) > 0 {
+		examples := make(container.Map, len(r.Examples))
+		for i, value := range r.Examples {
+			examples[ExampleName(i)] = container.Map{
+				"value": r.exampleValue(value),
+			}
+		}
+		parameter["examples"] = examples
+	}
+	return parameter
+}
+
+// exampleValue types value as the schema of the parameter, the values the
+// type was wrongly guessed for are left as strings.
+func (r *Parameter) exampleValue(value string) any {
+	switch r.Type {
+	case constants.ParameterTypeInteger:
+		if integer, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return integer
+		}
+	case constants.ParameterTypeBoolean:
+		if boolean, err := strconv.ParseBool(value); err == nil {
+			return boolean
+		}
 	}
+	return value
 }
 
 type GetSampleRequestByApiIdRequest struct {
@@ -40,4 +81,8 @@ type GetSampleRequestByApiIdRequest struct {
 	Offset int64              `json:"offset"`
 	From   *time.Time         `json:"from"`
 	To     *time.Time         `json:"to"`
+	// Parameters the samples were called with, by name
+	Parameters map[string]string `json:"parameters"`
+	// BeforeId only keeps the samples stored before it
+	BeforeId *primitive.ObjectID `json:"before_id"`
 }
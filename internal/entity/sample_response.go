@@ -12,6 +12,10 @@ type SampleResponse struct {
 	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
 	HttpStatusCode          int                `json:"status_code" bson:"http_status_code"`
 	Body                    string             `json:"body" bson:"body"`
+	// CorrelationId pairs the sample with the sample request of the same call
+	CorrelationId string `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"`
+	// LatencyMs is the time kong took to answer the call
+	LatencyMs int64 `json:"latency_ms,omitempty" bson:"latency_ms,omitempty"`
 }
 
 type GetSampleResponseByApiIdRequest struct {
@@ -20,4 +24,6 @@ type GetSampleResponseByApiIdRequest struct {
 	Offset int64              `json:"offset"`
 	From   *time.Time         `json:"from"`
 	To     *time.Time         `json:"to"`
+	// HttpStatusCode only keeps the samples of the status code when set
+	HttpStatusCode int `json:"status_code"`
 }
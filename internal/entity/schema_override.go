@@ -0,0 +1,49 @@
+package entity
+
+import (
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchemaOverride is the hand-written correction of the inferred structures of
+// an api, its rules are applied onto the inferred body schemas after each build.
+type SchemaOverride struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	Rules                   []*OverrideRule    `json:"rules" bson:"rules"`
+}
+
+// OverrideRule corrects the schema Pointer refers to in the body schema of Target.
+type OverrideRule struct {
+	// Target is constants.AnnotationTargetRequest or constants.AnnotationTargetResponse
+	Target  string `json:"target" bson:"target"`
+	Pointer string `json:"pointer" bson:"pointer"`
+	// Schema replaces the whole subtree, before the other fields are applied
+	Schema map[string]any `json:"schema,omitempty" bson:"schema,omitempty"`
+	// Type pins the type of the schema
+	Type string `json:"type,omitempty" bson:"type,omitempty"`
+	// Required adds the field to, or removes it from, the required ones of its object
+	Required   *bool `json:"required,omitempty" bson:"required,omitempty"`
+	Deprecated *bool `json:"deprecated,omitempty" bson:"deprecated,omitempty"`
+	// Hidden removes the field from the schema
+	Hidden bool `json:"hidden,omitempty" bson:"hidden,omitempty"`
+}
+
+type SaveSchemaOverrideRequest struct {
+	ApiId string          `json:"-"`
+	Rules []*OverrideRule `json:"rules"`
+}
+
+// SchemaOverrideValidation reports whether the recent samples of an api still
+// match its structures once overridden.
+type SchemaOverrideValidation struct {
+	Valid   bool                `json:"valid"`
+	Samples []*SampleValidation `json:"samples"`
+}
+
+type SampleValidation struct {
+	Target   string             `json:"target"`
+	SampleId primitive.ObjectID `json:"sample_id"`
+	Valid    bool               `json:"valid"`
+	Errors   []string           `json:"errors,omitempty"`
+}
@@ -0,0 +1,161 @@
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+)
+
+var securitySchemeNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9.\-_]`)
+
+// Credential is a way a request authenticates, described as an OpenAPI
+// security scheme. Its value is never recorded.
+type Credential struct {
+	// Type is constants.SecuritySchemeTypeHTTP or constants.SecuritySchemeTypeAPIKey
+	Type string `json:"type" bson:"type"`
+	// Scheme is the http authentication scheme, bearer or basic
+	Scheme       string `json:"scheme,omitempty" bson:"scheme,omitempty"`
+	BearerFormat string `json:"bearer_format,omitempty" bson:"bearer_format,omitempty"`
+	// In and Name locate an api key, in a header, the query or a cookie
+	In   string `json:"in,omitempty" bson:"in,omitempty"`
+	Name string `json:"name,omitempty" bson:"name,omitempty"`
+}
+
+// SchemeName is the name of the credential among the security schemes of a
+// document, e.g. bearerAuth or header.x-chotot-id-key.
+func (c *Credential) SchemeName() string {
+	if c.Type == constants.SecuritySchemeTypeHTTP {
+		return c.Scheme + "Auth"
+	}
+	return c.In + "." + securitySchemeNameRegexp.ReplaceAllString(c.Name, "_")
+}
+
+func (c *Credential) BuildSecurityScheme() container.Map {
+	if c.Type == constants.SecuritySchemeTypeHTTP {
+		scheme := container.Map{
+			"type":   c.Type,
+			"scheme": c.Scheme,
+		}
+		if c.BearerFormat != "" {
+			scheme["bearerFormat"] = c.BearerFormat
+		}
+		return scheme
+	}
+	return container.Map{
+		"type": c.Type,
+		"in":   c.In,
+		"name": c.Name,
+	}
+}
+
+// CredentialUsage counts the samples which authenticated with exactly
+// Credentials, none for the anonymous ones.
+type CredentialUsage struct {
+	Credentials []*Credential `json:"credentials" bson:"credentials"`
+	Count       int64         `json:"count" bson:"count"`
+}
+
+func (u *CredentialUsage) schemeNames() []string {
+	names := make([]string, 0, len(u.Credentials))
+	for _, credential := range u.Credentials {
+		names = append(names, credential.SchemeName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SecurityUsage is how the sample requests of an api authenticated.
+type SecurityUsage struct {
+	Samples int64              `json:"samples" bson:"samples"`
+	Usages  []*CredentialUsage `json:"usages" bson:"usages"`
+}
+
+// Add counts a sample which authenticated with credentials.
+func (s *SecurityUsage) Add(credentials []*Credential) {
+	s.Samples++
+	key := strings.Join((&CredentialUsage{Credentials: credentials}).schemeNames(), " ")
+	for _, usage := range s.Usages {
+		if strings.Join(usage.schemeNames(), " ") != key {
+			continue
+		}
+		usage.Count++
+		// a bearer token may be redacted in some samples and a JWT in others
+		for _, credential := range usage.Credentials {
+			for _, added := range credentials {
+				if credential.BearerFormat == "" && added.SchemeName() == credential.SchemeName() {
+					credential.BearerFormat = added.BearerFormat
+				}
+			}
+		}
+		return
+	}
+	if credentials == nil {
+		credentials = []*Credential{}
+	}
+	s.Usages = append(s.Usages, &CredentialUsage{
+		Credentials: credentials,
+		Count:       1,
+	})
+}
+
+// sortedUsages returns the usages from the most to the least frequent.
+func (s *SecurityUsage) sortedUsages() []*CredentialUsage {
+	usages := make([]*CredentialUsage, len(s.Usages))
+	copy(usages, s.Usages)
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Count != usages[j].Count {
+			return usages[i].Count > usages[j].Count
+		}
+		return strings.Join(usages[i].schemeNames(), " ") < strings.Join(usages[j].schemeNames(), " ")
+	})
+	return usages
+}
+
+// BuildSecuritySchemes returns the security schemes of the credentials seen.
+func (s *SecurityUsage) BuildSecuritySchemes() container.Map {
+	schemes := container.Map{}
+	for _, usage := range s.Usages {
+		for _, credential := range usage.Credentials {
+			name := credential.SchemeName()
+			if _, ok := schemes[name]; ok && credential.BearerFormat == "" {
+				continue
+			}
+			schemes[name] = credential.BuildSecurityScheme()
+		}
+	}
+	return schemes
+}
+
+// BuildSecurity returns the security requirements of an operation, one per
+// combination of credentials seen from the most frequent, the anonymous calls
+// being the empty requirement.
+func (s *SecurityUsage) BuildSecurity() []container.Map {
+	requirements := make([]container.Map, 0, len(s.Usages))
+	for _, usage := range s.sortedUsages() {
+		requirement := container.Map{}
+		for _, name := range usage.schemeNames() {
+			requirement[name] = []string{}
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements
+}
+
+// BuildUsage returns the x-security-usage extension of an operation, the
+// number of samples of each requirement of BuildSecurity.
+func (s *SecurityUsage) BuildUsage() container.Map {
+	usages := make([]container.Map, 0, len(s.Usages))
+	for _, usage := range s.sortedUsages() {
+		usages = append(usages, container.Map{
+			"schemes": usage.schemeNames(),
+			"count":   usage.Count,
+		})
+	}
+	return container.Map{
+		"samples": s.Samples,
+		"usages":  usages,
+	}
+}
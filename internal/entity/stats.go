@@ -0,0 +1,193 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrafficRecord is the traffic of a call logged by kong, the sizes are in
+// bytes and the latencies in milliseconds.
+type TrafficRecord struct {
+	SeenAt            time.Time
+	StatusCode        int
+	LatencyMs         int64
+	UpstreamLatencyMs int64
+	ProxyLatencyMs    int64
+	RequestSize       int64
+	ResponseSize      int64
+}
+
+// ApiStats rolls up the traffic of an api during an hour.
+type ApiStats struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	ApiId                   primitive.ObjectID `json:"api_id" bson:"api_id"`
+	// Hour is the start of the hour, in UTC
+	Hour  time.Time `json:"hour" bson:"hour"`
+	Count int64     `json:"count" bson:"count"`
+	// StatusCodes counts the calls by status code
+	StatusCodes map[string]int64 `json:"status_codes" bson:"status_codes"`
+	// LatencyBuckets counts the calls by the upper bound of their latency in
+	// constants.StatsLatencyBucketsMs
+	LatencyBuckets       map[string]int64 `json:"latency_buckets" bson:"latency_buckets"`
+	LatencyMsSum         int64            `json:"latency_ms_sum" bson:"latency_ms_sum"`
+	LatencyMsMax         int64            `json:"latency_ms_max" bson:"latency_ms_max"`
+	UpstreamLatencyMsSum int64            `json:"upstream_latency_ms_sum" bson:"upstream_latency_ms_sum"`
+	ProxyLatencyMsSum    int64            `json:"proxy_latency_ms_sum" bson:"proxy_latency_ms_sum"`
+	RequestSizeSum       int64            `json:"request_size_sum" bson:"request_size_sum"`
+	RequestSizeMax       int64            `json:"request_size_max" bson:"request_size_max"`
+	ResponseSizeSum      int64            `json:"response_size_sum" bson:"response_size_sum"`
+	ResponseSizeMax      int64            `json:"response_size_max" bson:"response_size_max"`
+	FirstSeenAt          *time.Time       `json:"first_seen_at" bson:"first_seen_at"`
+	LastSeenAt           *time.Time       `json:"last_seen_at" bson:"last_seen_at"`
+}
+
+// LatencyBucket returns the key of the bucket of LatencyBuckets a latency
+// falls into.
+func LatencyBucket(latencyMs int64) string {
+	for _, bound := range constants.StatsLatencyBucketsMs {
+		if latencyMs <= bound {
+			return strconv.FormatInt(bound, 10)
+		}
+	}
+	return constants.StatsLatencyOverflowBucket
+}
+
+// ApiStatsSummary sums up hourly stats of an api, the percentiles being the
+// upper bounds of the buckets they fall into.
+type ApiStatsSummary struct {
+	Calls        int64            `json:"calls"`
+	StatusCodes  map[string]int64 `json:"status_codes"`
+	LatencyMs    *LatencySummary  `json:"latency_ms"`
+	RequestSize  *SizeSummary     `json:"request_size"`
+	ResponseSize *SizeSummary     `json:"response_size"`
+	FirstSeenAt  *time.Time       `json:"first_seen_at"`
+	LastSeenAt   *time.Time       `json:"last_seen_at"`
+}
+
+type LatencySummary struct {
+	Avg         int64 `json:"avg"`
+	P50         int64 `json:"p50"`
+	P95         int64 `json:"p95"`
+	P99         int64 `json:"p99"`
+	Max         int64 `json:"max"`
+	UpstreamAvg int64 `json:"upstream_avg"`
+	ProxyAvg    int64 `json:"proxy_avg"`
+}
+
+type SizeSummary struct {
+	Avg int64 `json:"avg"`
+	Max int64 `json:"max"`
+}
+
+// SummarizeApiStats sums up the hourly stats of an api, nil when there is no
+// call in them.
+func SummarizeApiStats(hours []*ApiStats) *ApiStatsSummary {
+	total := &ApiStats{
+		StatusCodes:    make(map[string]int64),
+		LatencyBuckets: make(map[string]int64),
+	}
+	for _, hour := range hours {
+		total.Count += hour.Count
+		for statusCode, count := range hour.StatusCodes {
+			total.StatusCodes[statusCode] += count
+		}
+		for bucket, count := range hour.LatencyBuckets {
+			total.LatencyBuckets[bucket] += count
+		}
+		total.LatencyMsSum += hour.LatencyMsSum
+		total.LatencyMsMax = max(total.LatencyMsMax, hour.LatencyMsMax)
+		total.UpstreamLatencyMsSum += hour.UpstreamLatencyMsSum
+		total.ProxyLatencyMsSum += hour.ProxyLatencyMsSum
+		total.RequestSizeSum += hour.RequestSizeSum
+		total.RequestSizeMax = max(total.RequestSizeMax, hour.RequestSizeMax)
+		total.ResponseSizeSum += hour.ResponseSizeSum
+		total.ResponseSizeMax = max(total.ResponseSizeMax, hour.ResponseSizeMax)
+		if hour.FirstSeenAt != nil && (total.FirstSeenAt == nil || hour.FirstSeenAt.Before(*total.FirstSeenAt)) {
+			total.FirstSeenAt = hour.FirstSeenAt
+		}
+		if hour.LastSeenAt != nil && (total.LastSeenAt == nil || hour.LastSeenAt.After(*total.LastSeenAt)) {
+			total.LastSeenAt = hour.LastSeenAt
+		}
+	}
+	if total.Count == 0 {
+		return nil
+	}
+	return &ApiStatsSummary{
+		Calls:       total.Count,
+		StatusCodes: total.StatusCodes,
+		LatencyMs: &LatencySummary{
+			Avg:         total.LatencyMsSum / total.Count,
+			P50:         total.latencyPercentile(50),
+			P95:         total.latencyPercentile(95),
+			P99:         total.latencyPercentile(99),
+			Max:         total.LatencyMsMax,
+			UpstreamAvg: total.UpstreamLatencyMsSum / total.Count,
+			ProxyAvg:    total.ProxyLatencyMsSum / total.Count,
+		},
+		RequestSize: &SizeSummary{
+			Avg: total.RequestSizeSum / total.Count,
+			Max: total.RequestSizeMax,
+		},
+		ResponseSize: &SizeSummary{
+			Avg: total.ResponseSizeSum / total.Count,
+			Max: total.ResponseSizeMax,
+		},
+		FirstSeenAt: total.FirstSeenAt,
+		LastSeenAt:  total.LastSeenAt,
+	}
+}
+
+// latencyPercentile returns the upper bound of the bucket the percentile of
+// the latencies falls into, capped by the slowest call.
+func (s *ApiStats) latencyPercentile(percentile int64) int64 {
+	// the rank of the call at the percentile, rounded up
+	rank := (s.Count*percentile + 99) / 100
+	seen := int64(0)
+	for _, bound := range constants.StatsLatencyBucketsMs {
+		seen += s.LatencyBuckets[strconv.FormatInt(bound, 10)]
+		if seen >= rank {
+			return min(bound, s.LatencyMsMax)
+		}
+	}
+	return s.LatencyMsMax
+}
+
+// BuildExtension returns the x-traffic extension of an operation.
+func (s *ApiStatsSummary) BuildExtension(since time.Time) container.Map {
+	extension := container.Map{
+		"since":        since.UTC().Format(time.RFC3339),
+		"calls":        s.Calls,
+		"status_codes": s.StatusCodes,
+		"latency_ms": container.Map{
+			"p50": s.LatencyMs.P50,
+			"p95": s.LatencyMs.P95,
+			"p99": s.LatencyMs.P99,
+		},
+		"request_size":  container.Map{"avg": s.RequestSize.Avg, "max": s.RequestSize.Max},
+		"response_size": container.Map{"avg": s.ResponseSize.Avg, "max": s.ResponseSize.Max},
+	}
+	if s.LastSeenAt != nil {
+		extension["last_seen_at"] = s.LastSeenAt.UTC().Format(time.RFC3339)
+	}
+	return extension
+}
+
+type GetApiStatsRequest struct {
+	ApiId string
+	// From and To bound the hours of the stats, the last
+	// constants.StatsDefaultWindow when they are nil
+	From *time.Time
+	To   *time.Time
+}
+
+type GetApiStatsResponse struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Summary *ApiStatsSummary `json:"summary"`
+	Hours   []*ApiStats      `json:"hours"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+// GenerateTestsRequest selects the apis tests are generated for: the api of
+// ApiId, or else the ones matching ApiFilter and Tag.
+type GenerateTestsRequest struct {
+	ApiId string
+	ApiFilter
+	Tag string
+	// Samples is the number of samples replayed per api,
+	// constants.TestgenSamplesPerApi when 0
+	Samples int
+	// Package is the package of the Go tests, constants.TestgenDefaultPackage
+	// when empty
+	Package string
+}
+
+type GenerateTestsResponse struct {
+	Operations int              `json:"operations"`
+	Samples    int              `json:"samples"`
+	Files      []*GeneratedFile `json:"files"`
+}
+
+// GeneratedFile is an artifact of a generator, Name is relative to the
+// directory it is written to.
+type GeneratedFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
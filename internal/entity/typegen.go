@@ -0,0 +1,18 @@
+package entity
+
+// GenerateTypesRequest generates the types of either an api, from its request
+// and response structures, or of a type of the types collection.
+type GenerateTypesRequest struct {
+	ApiId    string
+	TypeName string
+	// Language is constants.TypegenLanguageGo or constants.TypegenLanguageTypeScript
+	Language string
+	// Package is the package clause of the Go code, constants.TypegenDefaultPackage
+	// when empty
+	Package string
+}
+
+type GenerateTypesResponse struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
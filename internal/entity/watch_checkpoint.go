@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WatchCheckpoint is the resume token of the last change event of a stream
+// whose samples have been merged into the structures.
+type WatchCheckpoint struct {
+	mongodbutils.BaseEntity `bson:",inline"`
+	Name                    string   `json:"name" bson:"name"`
+	ResumeToken             bson.Raw `json:"resume_token" bson:"resume_token"`
+	// WatchedSince is the time since which the stream has received every
+	// insert, it is kept as long as the stream resumes from its checkpoints
+	WatchedSince *time.Time `json:"watched_since" bson:"watched_since"`
+}
@@ -1,5 +1,106 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
-var ErrApiNotFound = errors.New("api not found")
+// Kind classifies an error by the way a caller can react to it, handlers map
+// it onto a status code.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindInvalidArgument
+	KindConflict
+	KindUnavailable
+	KindUnauthenticated
+	KindPermissionDenied
+)
+
+var (
+	ErrApiNotFound    = NotFound("api not found")
+	ErrStaleStructure = Conflict("structure was updated by another writer")
+)
+
+// Error is a domain error of a known kind. Two errors are only equal when
+// they are the same value, errors.Is matches the sentinels above.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(format string, args ...any) *Error {
+	return newError(KindNotFound, nil, format, args...)
+}
+
+func InvalidArgument(format string, args ...any) *Error {
+	return newError(KindInvalidArgument, nil, format, args...)
+}
+
+func Conflict(format string, args ...any) *Error {
+	return newError(KindConflict, nil, format, args...)
+}
+
+func Unavailable(format string, args ...any) *Error {
+	return newError(KindUnavailable, nil, format, args...)
+}
+
+func Unauthenticated(format string, args ...any) *Error {
+	return newError(KindUnauthenticated, nil, format, args...)
+}
+
+func PermissionDenied(format string, args ...any) *Error {
+	return newError(KindPermissionDenied, nil, format, args...)
+}
+
+// Wrap classifies err as kind, the message describes err to the client while
+// err itself is only kept for the logs.
+func Wrap(kind Kind, err error, format string, args ...any) *Error {
+	return newError(kind, err, format, args...)
+}
+
+// KindOf returns the kind of the first domain error in the chain of err,
+// KindInternal when there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// MessageOf returns the message of the first domain error in the chain of
+// err, the part of the error that is safe to show to a client.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return ""
+}
+
+func newError(kind Kind, err error, format string, args ...any) *Error {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
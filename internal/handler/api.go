@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/internal/usecase/catalogue"
+	"github.com/ct-logic-api-document/pkg/auth"
+	jsonpathutils "github.com/ct-logic-api-document/utils/jsonpath"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/labstack/echo/v4"
+)
+
+type ApiHandler struct {
+	CatalogueUC catalogue.ICatalogueUC
+}
+
+func NewApiHandler(catalogueUC catalogue.ICatalogueUC) *ApiHandler {
+	return &ApiHandler{
+		CatalogueUC: catalogueUC,
+	}
+}
+
+func (h *ApiHandler) RegisterHandler(internalGroup *echo.Group, authMiddleware *AuthMiddleware) {
+	readDocs := authMiddleware.Require(auth.ScopeReadDocs)
+	editAnnotations := authMiddleware.Require(auth.ScopeEditAnnotations)
+	internalGroup.GET("/apis", h.GetApis, readDocs)
+	internalGroup.GET("/apis/:api_id", h.GetApiDetail, readDocs)
+	internalGroup.PATCH("/apis/:api_id", h.UpdateApi, editAnnotations)
+	internalGroup.POST("/apis/:api_id/build", h.BuildApi, authMiddleware.Require(auth.ScopeTriggerBuilds))
+	internalGroup.GET("/apis/:api_id/samples", h.GetSamples, authMiddleware.Require(auth.ScopeReadSamples))
+	internalGroup.GET("/apis/:api_id/annotations", h.GetAnnotations, readDocs)
+	internalGroup.PUT("/apis/:api_id/annotations", h.SaveAnnotation, editAnnotations)
+	internalGroup.DELETE("/apis/:api_id/annotations", h.DeleteAnnotation, editAnnotations)
+	internalGroup.GET("/apis/:api_id/overrides", h.GetSchemaOverride, readDocs)
+	internalGroup.PUT("/apis/:api_id/overrides", h.SaveSchemaOverride, editAnnotations)
+	// the validation errors quote the sample payloads
+	internalGroup.POST("/apis/:api_id/overrides/validate", h.ValidateSchemaOverride,
+		authMiddleware.Require(auth.ScopeReadSamples))
+	internalGroup.GET("/apis/:api_id/drifts", h.GetDrifts, readDocs)
+	internalGroup.GET("/apis/:api_id/stats", h.GetApiStats, readDocs)
+	// the consumers expose client subnets and id key hashes
+	internalGroup.GET("/apis/:api_id/consumers", h.GetConsumers, authMiddleware.Require(auth.ScopeReadSamples))
+}
+
+func (h *ApiHandler) GetApis(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req, err := bindGetApisRequest(echoCtx)
+	if err != nil {
+		return err
+	}
+	resp, err := h.CatalogueUC.GetApis(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) GetApiDetail(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.CatalogueUC.GetApiDetail(ctx, echoCtx.Param("api_id"))
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) UpdateApi(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.UpdateApiRequest{}
+	if err := echoCtx.Bind(req); err != nil {
+		return err
+	}
+	req.ApiId = echoCtx.Param("api_id")
+	resp, err := h.CatalogueUC.UpdateApi(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) GetSamples(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req, err := bindGetSamplesRequest(echoCtx)
+	if err != nil {
+		return err
+	}
+	resp, err := h.CatalogueUC.GetSamples(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) BuildApi(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.CatalogueUC.BuildApi(ctx, echoCtx.Param("api_id"))
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) GetAnnotations(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.CatalogueUC.GetAnnotations(ctx, echoCtx.Param("api_id"))
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) SaveAnnotation(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.SaveAnnotationRequest{}
+	if err := echoCtx.Bind(req); err != nil {
+		return err
+	}
+	req.ApiId = echoCtx.Param("api_id")
+	if err := validateSchemaPointer(req.Target, req.Pointer); err != nil {
+		return err
+	}
+	if len(req.Schema) == 0 {
+		return apperrors.InvalidArgument("schema must not be empty")
+	}
+	for key := range req.Schema {
+		if !constants.AnnotationSchemaKeys.Contains(key) {
+			return apperrors.InvalidArgument("schema keys must be among %s",
+				strings.Join(constants.AnnotationSchemaKeys, ", "))
+		}
+	}
+	resp, err := h.CatalogueUC.SaveAnnotation(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) DeleteAnnotation(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.DeleteAnnotationRequest{
+		ApiId:   echoCtx.Param("api_id"),
+		Target:  echoCtx.QueryParam("target"),
+		Pointer: echoCtx.QueryParam("pointer"),
+	}
+	if err := validateSchemaPointer(req.Target, req.Pointer); err != nil {
+		return err
+	}
+	err := h.CatalogueUC.DeleteAnnotation(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.NoContent(http.StatusNoContent)
+}
+
+func (h *ApiHandler) GetSchemaOverride(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.CatalogueUC.GetSchemaOverride(ctx, echoCtx.Param("api_id"))
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) SaveSchemaOverride(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.SaveSchemaOverrideRequest{}
+	if err := echoCtx.Bind(req); err != nil {
+		return err
+	}
+	req.ApiId = echoCtx.Param("api_id")
+	for _, rule := range req.Rules {
+		if err := validateOverrideRule(rule); err != nil {
+			return err
+		}
+	}
+	resp, err := h.CatalogueUC.SaveSchemaOverride(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) ValidateSchemaOverride(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.CatalogueUC.ValidateSchemaOverride(ctx, echoCtx.Param("api_id"))
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) GetDrifts(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.GetDriftsRequest{
+		ApiId: echoCtx.Param("api_id"),
+	}
+	var from time.Time
+	err := echo.QueryParamsBinder(echoCtx).
+		Time("from", &from, time.RFC3339).
+		BindError()
+	if err != nil {
+		return err
+	}
+	if !from.IsZero() {
+		req.From = &from
+	}
+	resp, err := h.CatalogueUC.GetDrifts(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *ApiHandler) GetApiStats(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.GetApiStatsRequest{
+		ApiId: echoCtx.Param("api_id"),
+	}
+	var from, to time.Time
+	err := echo.QueryParamsBinder(echoCtx).
+		Time("from", &from, time.RFC3339).
+		Time("to", &to, time.RFC3339).
+		BindError()
+	if err != nil {
+		return err
+	}
+	if !from.IsZero() {
+		req.From = &from
+	}
+	if !to.IsZero() {
+		req.To = &to
+	}
+	resp, err := h.CatalogueUC.GetApiStats(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func validateOverrideRule(rule *entity.OverrideRule) error {
+	if rule == nil {
+		return apperrors.InvalidArgument("rules must not be null")
+	}
+	if err := validateSchemaPointer(rule.Target, rule.Pointer); err != nil {
+		return err
+	}
+	if rule.Type != "" && !constants.OverrideTypes.Contains(rule.Type) {
+		return apperrors.InvalidArgument("type must be one of %s", strings.Join(constants.OverrideTypes, ", "))
+	}
+	if rule.Pointer == "" && (rule.Hidden || rule.Required != nil) {
+		return apperrors.InvalidArgument("the root schema can not be hidden or required")
+	}
+	return nil
+}
+
+// validateSchemaPointer checks the key of an annotation or override rule, the root pointer ""
+// annotates the whole body schema.
+func validateSchemaPointer(target, pointer string) error {
+	if !constants.AnnotationTargets.Contains(target) {
+		return apperrors.InvalidArgument("target must be one of %s", strings.Join(constants.AnnotationTargets, ", "))
+	}
+	if pointer != "" && !openapiutils.IsValidPointer(pointer) {
+		return apperrors.InvalidArgument("pointer must be a JSON pointer starting with /")
+	}
+	return nil
+}
+
+func bindGetApisRequest(echoCtx echo.Context) (*entity.GetApisRequest, error) {
+	req := &entity.GetApisRequest{
+		Limit: constants.DefaultApisLimit,
+	}
+	var hasStructure string
+	var lastSeenFrom, lastSeenTo time.Time
+	err := echo.QueryParamsBinder(echoCtx).
+		String("host", &req.Host).
+		String("method", &req.Method).
+		String("path", &req.Path).
+		String("has_structure", &hasStructure).
+		Time("last_seen_from", &lastSeenFrom, time.RFC3339).
+		Time("last_seen_to", &lastSeenTo, time.RFC3339).
+		String("sort", &req.Sort).
+		Int64("limit", &req.Limit).
+		Int64("offset", &req.Offset).
+		BindError()
+	if err != nil {
+		return nil, err
+	}
+	if hasStructure != "" {
+		value, err := strconv.ParseBool(hasStructure)
+		if err != nil {
+			return nil, apperrors.InvalidArgument("has_structure must be a boolean")
+		}
+		req.HasStructure = &value
+	}
+	if !lastSeenFrom.IsZero() {
+		req.LastSeenFrom = &lastSeenFrom
+	}
+	if !lastSeenTo.IsZero() {
+		req.LastSeenTo = &lastSeenTo
+	}
+	if req.Sort != "" && !constants.ApiSortFields.Contains(strings.TrimPrefix(req.Sort, "-")) {
+		return nil, apperrors.InvalidArgument("sort must be one of %s", strings.Join(constants.ApiSortFields, ", "))
+	}
+	if req.Limit <= 0 || req.Limit > constants.MaxApisLimit {
+		return nil, apperrors.InvalidArgument("limit must be between 1 and %d", constants.MaxApisLimit)
+	}
+	if req.Offset < 0 {
+		return nil, apperrors.InvalidArgument("offset must not be negative")
+	}
+	return req, nil
+}
+
+// bindGetSamplesRequest reads the filters of the samples, a parameter value
+// is given as param.<name>=<value> and a body predicate as a JSON path such as
+// $.ads[*].price>=100.
+func bindGetSamplesRequest(echoCtx echo.Context) (*entity.GetSamplesRequest, error) {
+	req := &entity.GetSamplesRequest{
+		ApiId: echoCtx.Param("api_id"),
+		Limit: constants.DefaultSamplesLimit,
+	}
+	var from, to time.Time
+	var requestBody, responseBody string
+	err := echo.QueryParamsBinder(echoCtx).
+		Int("status_code", &req.StatusCode).
+		Time("from", &from, time.RFC3339).
+		Time("to", &to, time.RFC3339).
+		String("request_body", &requestBody).
+		String("response_body", &responseBody).
+		Int64("limit", &req.Limit).
+		String("cursor", &req.Cursor).
+		BindError()
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() {
+		req.From = &from
+	}
+	if !to.IsZero() {
+		req.To = &to
+	}
+	for key, values := range echoCtx.QueryParams() {
+		if name, ok := strings.CutPrefix(key, "param."); ok && name != "" {
+			if req.Parameters == nil {
+				req.Parameters = make(map[string]string)
+			}
+			req.Parameters[name] = values[0]
+		}
+	}
+	if requestBody != "" {
+		if req.RequestBody, err = jsonpathutils.ParsePredicate(requestBody); err != nil {
+			return nil, apperrors.InvalidArgument("request_body: %s", err.Error())
+		}
+	}
+	if responseBody != "" {
+		if req.ResponseBody, err = jsonpathutils.ParsePredicate(responseBody); err != nil {
+			return nil, apperrors.InvalidArgument("response_body: %s", err.Error())
+		}
+	}
+	if req.Limit <= 0 || req.Limit > constants.MaxSamplesLimit {
+		return nil, apperrors.InvalidArgument("limit must be between 1 and %d", constants.MaxSamplesLimit)
+	}
+	return req, nil
+}
+
+func (h *ApiHandler) GetConsumers(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req, err := bindGetConsumersRequest(echoCtx)
+	if err != nil {
+		return err
+	}
+	resp, err := h.CatalogueUC.GetConsumers(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func bindGetConsumersRequest(echoCtx echo.Context) (*entity.GetConsumersRequest, error) {
+	req := &entity.GetConsumersRequest{
+		ApiId: echoCtx.Param("api_id"),
+		Kind:  echoCtx.QueryParam("kind"),
+		Field: echoCtx.QueryParam("field"),
+	}
+	var from time.Time
+	err := echo.QueryParamsBinder(echoCtx).
+		Time("from", &from, time.RFC3339).
+		BindError()
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() {
+		req.From = &from
+	}
+	if req.Kind != "" && !constants.ConsumerKinds.Contains(req.Kind) {
+		return nil, apperrors.InvalidArgument("kind must be one of %s", strings.Join(constants.ConsumerKinds, ", "))
+	}
+	return req, nil
+}
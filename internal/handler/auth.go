@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ct-logic-api-document/config"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// AuthMiddleware authenticates the requests of the internal endpoints and
+// checks the scope each route requires.
+type AuthMiddleware struct {
+	enabled       bool
+	publicDocs    bool
+	authenticator auth.Authenticator
+}
+
+// NewAuthMiddleware loads the credentials of the configured authentication
+// methods, a request can authenticate with any of them.
+func NewAuthMiddleware(conf *config.Config) (*AuthMiddleware, error) {
+	m := &AuthMiddleware{
+		enabled:    conf.Auth.Enabled,
+		publicDocs: conf.Auth.PublicDocs,
+	}
+	if !m.enabled {
+		return m, nil
+	}
+	chain := auth.Chain{}
+	if conf.Auth.KeysFile != "" {
+		keys, err := auth.LoadKeys(conf.Auth.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain,
+			auth.NewAPIKeyAuthenticator(keys.APIKeys),
+			auth.NewHMACAuthenticator(keys.HMACKeys, conf.Auth.HMACMaxSkew),
+		)
+	}
+	if conf.Auth.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(conf.Auth.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain,
+			auth.NewJWTAuthenticator(keys, conf.Auth.JWTIssuer, conf.Auth.JWTAudience, conf.Auth.JWTScopeClaim))
+	}
+	m.authenticator = chain
+	return m, nil
+}
+
+// Require returns the middleware of the routes requiring scope. The principal
+// of an authorized request is put in its context.
+func (m *AuthMiddleware) Require(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(echoCtx echo.Context) error {
+			if !m.enabled {
+				return next(echoCtx)
+			}
+			req := echoCtx.Request()
+			principal, err := m.authenticator.Authenticate(req)
+			if errors.Is(err, auth.ErrNoCredentials) {
+				// credentials sent to a public route are still checked
+				if scope == auth.ScopeReadDocs && m.publicDocs {
+					return next(echoCtx)
+				}
+				return apperrors.Unauthenticated("credentials are required")
+			}
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				return apperrors.Unauthenticated("invalid credentials")
+			}
+			if err != nil {
+				return err
+			}
+			if !principal.HasScope(scope) {
+				return apperrors.PermissionDenied("scope %s is required", scope)
+			}
+			echoCtx.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+			return next(echoCtx)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"mime"
+	"net/http"
+	"path"
+
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// docsFS holds the UI with the Swagger UI and Redoc bundles vendored by
+// make docs-ui, so the UI works without internet access.
+//
+//go:embed docs
+var docsFS embed.FS
+
+var docsTemplate = template.Must(template.ParseFS(docsFS, "docs/index.html"))
+
+// docsPage fills the index of the documentation UI.
+type docsPage struct {
+	BasePath string
+}
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// RegisterHandler leaves the UI public, it holds no data and the documents it
+// loads are protected on their own.
+func (h *DocsHandler) RegisterHandler(internalGroup *echo.Group) {
+	internalGroup.GET("/docs", h.Index)
+	internalGroup.GET("/docs/:file", h.Asset)
+}
+
+func (h *DocsHandler) Index(echoCtx echo.Context) error {
+	page := &docsPage{
+		BasePath: internalPrefix,
+	}
+	buf := &bytes.Buffer{}
+	if err := docsTemplate.Execute(buf, page); err != nil {
+		return err
+	}
+	return echoCtx.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
+func (h *DocsHandler) Asset(echoCtx echo.Context) error {
+	file := path.Base(echoCtx.Param("file"))
+	// the index is a template, it is only served rendered
+	if file == "index.html" {
+		return h.Index(echoCtx)
+	}
+	data, err := docsFS.ReadFile(path.Join("docs", file))
+	if err != nil {
+		return apperrors.NotFound("file not found")
+	}
+	return echoCtx.Blob(http.StatusOK, mime.TypeByExtension(path.Ext(file)), data)
+}
@@ -0,0 +1,121 @@
+// Renders the OpenAPI documents of the service with Swagger UI or Redoc. The
+// page state lives in the query string, e.g. ?api_id=<id>&renderer=redoc, so
+// every API and renderer can be linked to.
+(function () {
+  'use strict';
+
+  var RENDERER_SWAGGER_UI = 'swagger-ui';
+  var RENDERER_REDOC = 'redoc';
+
+  var basePath = document.body.dataset.basePath;
+  var picker = document.getElementById('api-picker');
+  var specLink = document.getElementById('spec-link');
+  var container = document.getElementById('docs');
+  var renderers = document.querySelectorAll('input[name="renderer"]');
+
+  function specUrl(apiId) {
+    if (apiId) {
+      return basePath + '/load-structure/' + encodeURIComponent(apiId);
+    }
+    return basePath + '/openapi.json';
+  }
+
+  function currentState() {
+    var params = new URLSearchParams(window.location.search);
+    return {
+      apiId: params.get('api_id') || '',
+      renderer: params.get('renderer') === RENDERER_REDOC ? RENDERER_REDOC : RENDERER_SWAGGER_UI,
+    };
+  }
+
+  function pushState(state) {
+    var params = new URLSearchParams();
+    if (state.apiId) {
+      params.set('api_id', state.apiId);
+    }
+    if (state.renderer !== RENDERER_SWAGGER_UI) {
+      params.set('renderer', state.renderer);
+    }
+    var query = params.toString();
+    window.history.pushState(null, '', window.location.pathname + (query ? '?' + query : ''));
+    render();
+  }
+
+  function render() {
+    var state = currentState();
+    var url = specUrl(state.apiId);
+    picker.value = state.apiId;
+    renderers.forEach(function (input) {
+      input.checked = input.value === state.renderer;
+    });
+    specLink.href = url;
+
+    container.innerHTML = '';
+    var target = document.createElement('div');
+    container.appendChild(target);
+    if (state.renderer === RENDERER_REDOC) {
+      Redoc.init(url, {}, target);
+      return;
+    }
+    SwaggerUIBundle({
+      url: url,
+      domNode: target,
+      deepLinking: true,
+    });
+  }
+
+  // addOption adds the api to the picker, under the group of its tag.
+  function addOption(groups, apiId, label, tag) {
+    var group = groups[tag];
+    if (!group) {
+      group = document.createElement('optgroup');
+      group.label = tag;
+      groups[tag] = group;
+      picker.appendChild(group);
+    }
+    var option = document.createElement('option');
+    option.value = apiId;
+    option.textContent = label;
+    group.appendChild(option);
+  }
+
+  // loadPicker lists every api of the catalogue, the aggregated document
+  // holds one operation per api with the api id as operationId.
+  function loadPicker() {
+    return fetch(specUrl('')).then(function (resp) {
+      if (!resp.ok) {
+        throw new Error('unable to load the catalogue: ' + resp.status);
+      }
+      return resp.json();
+    }).then(function (spec) {
+      var groups = {};
+      Object.keys(spec.paths || {}).forEach(function (path) {
+        var pathItem = spec.paths[path];
+        Object.keys(pathItem).forEach(function (method) {
+          var operation = pathItem[method];
+          if (!operation || !operation.operationId) {
+            return;
+          }
+          var tag = (operation.tags && operation.tags[0]) || 'default';
+          addOption(groups, operation.operationId, method.toUpperCase() + ' ' + path, tag);
+        });
+      });
+      picker.value = currentState().apiId;
+    }).catch(function (err) {
+      console.error(err);
+    });
+  }
+
+  picker.addEventListener('change', function () {
+    pushState({ apiId: picker.value, renderer: currentState().renderer });
+  });
+  renderers.forEach(function (input) {
+    input.addEventListener('change', function () {
+      pushState({ apiId: currentState().apiId, renderer: input.value });
+    });
+  });
+  window.addEventListener('popstate', render);
+
+  loadPicker();
+  render();
+})();
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	httpkit "github.com/carousell/ct-go/pkg/httpclient"
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// headerCorrelationID carries the correlation id set by the gateway, it is
+	// sent back so a client can quote it when reporting an error.
+	headerCorrelationID        = "X-Correlation-ID"
+	correlationIDKey           = "correlation_id"
+	mimeApplicationProblemJSON = "application/problem+json"
+)
+
+// problem is an RFC 7807 problem details object.
+type problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+var kindStatuses = map[apperrors.Kind]int{
+	apperrors.KindNotFound:         http.StatusNotFound,
+	apperrors.KindInvalidArgument:  http.StatusBadRequest,
+	apperrors.KindConflict:         http.StatusConflict,
+	apperrors.KindUnavailable:      http.StatusServiceUnavailable,
+	apperrors.KindUnauthenticated:  http.StatusUnauthorized,
+	apperrors.KindPermissionDenied: http.StatusForbidden,
+}
+
+// correlationIDMiddleware keeps the correlation id of the request, or makes
+// one up, in the context of the request and in the response headers.
+func correlationIDMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(echoCtx echo.Context) error {
+		req := echoCtx.Request()
+		correlationID := req.Header.Get(headerCorrelationID)
+		if correlationID == "" {
+			correlationID = httpkit.GenerateCorrelationID()
+		}
+		echoCtx.Set(correlationIDKey, correlationID)
+		echoCtx.SetRequest(req.WithContext(httpkit.InjectCorrelationIDToContext(req.Context(), correlationID)))
+		echoCtx.Response().Header().Set(headerCorrelationID, correlationID)
+		return next(echoCtx)
+	}
+}
+
+// httpErrorHandler writes the errors returned by the handlers as problem
+// details, domain errors get the status of their kind and the other errors
+// are internal ones whose details are only logged.
+func httpErrorHandler(err error, echoCtx echo.Context) {
+	ctx := echoCtx.Request().Context()
+	correlationID, _ := echoCtx.Get(correlationIDKey).(string)
+	p := buildProblem(err, echoCtx.Request().URL.Path, correlationID)
+	committed := echoCtx.Response().Committed
+	if p.Status >= http.StatusInternalServerError || committed {
+		logctx.Errorw(ctx, "failed to handle request", "err", err, "path", p.Instance, "correlation_id", correlationID)
+	}
+	if committed {
+		// the status and part of the body are sent already
+		return
+	}
+	if echoCtx.Request().Method == http.MethodHead {
+		err = echoCtx.NoContent(p.Status)
+	} else {
+		// JSON keeps a content type set beforehand
+		echoCtx.Response().Header().Set(echo.HeaderContentType, mimeApplicationProblemJSON)
+		err = echoCtx.JSON(p.Status, p)
+	}
+	if err != nil {
+		logctx.Errorw(ctx, "failed to write error response", "err", err)
+	}
+}
+
+func buildProblem(err error, instance, correlationID string) *problem {
+	if status, ok := kindStatuses[apperrors.KindOf(err)]; ok {
+		return newProblem(status, apperrors.MessageOf(err), instance, correlationID)
+	}
+	// the embedded HTTPError of a binding error is not in its chain
+	var bindingErr *echo.BindingError
+	if errors.As(err, &bindingErr) {
+		detail := fmt.Sprintf("%s: %v", bindingErr.Field, bindingErr.Message)
+		return newProblem(bindingErr.Code, detail, instance, correlationID)
+	}
+	// raised by echo itself, e.g. when a request body can not be bound
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail := ""
+		if httpErr.Message != nil {
+			detail = fmt.Sprint(httpErr.Message)
+		}
+		return newProblem(httpErr.Code, detail, instance, correlationID)
+	}
+	return newProblem(http.StatusInternalServerError, "", instance, correlationID)
+}
+
+func newProblem(status int, detail, instance, correlationID string) *problem {
+	return &problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      instance,
+		CorrelationID: correlationID,
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProblem(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{
+			name:       "Test BuildProblem - not found",
+			err:        fmt.Errorf("get api detail: %w", apperrors.ErrApiNotFound),
+			wantStatus: http.StatusNotFound,
+			wantDetail: "api not found",
+		},
+		{
+			name:       "Test BuildProblem - invalid argument hides the wrapped error",
+			err:        apperrors.Wrap(apperrors.KindInvalidArgument, errors.New("encoding/hex: invalid byte"), "invalid api id"),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "invalid api id",
+		},
+		{
+			name:       "Test BuildProblem - conflict",
+			err:        apperrors.ErrStaleStructure,
+			wantStatus: http.StatusConflict,
+			wantDetail: "structure was updated by another writer",
+		},
+		{
+			name:       "Test BuildProblem - unavailable",
+			err:        apperrors.Unavailable("storage is unavailable"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantDetail: "storage is unavailable",
+		},
+		{
+			name:       "Test BuildProblem - echo error",
+			err:        echo.ErrUnsupportedMediaType,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantDetail: "Unsupported Media Type",
+		},
+		{
+			name:       "Test BuildProblem - binding error",
+			err:        echo.NewBindingError("limit", []string{"ten"}, "failed to bind field value to int64", nil),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "limit: failed to bind field value to int64",
+		},
+		{
+			name:       "Test BuildProblem - internal error hides the details",
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, &problem{
+				Type:          "about:blank",
+				Title:         http.StatusText(tt.wantStatus),
+				Status:        tt.wantStatus,
+				Detail:        tt.wantDetail,
+				Instance:      "/internal/apis/1",
+				CorrelationID: "correlation-id",
+			}, buildProblem(tt.err, "/internal/apis/1", "correlation-id"))
+		})
+	}
+}
@@ -6,21 +6,49 @@ import (
 	"regexp"
 
 	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/usecase/catalogue"
+	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
 	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+	"github.com/ct-logic-api-document/internal/usecase/postman"
+	"github.com/ct-logic-api-document/internal/usecase/typegen"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/labstack/echo/v4"
 )
 
+// internalPrefix is the path prefix of every internal endpoint.
+const internalPrefix = "/internal"
+
 type Handler struct {
+	authMiddleware       *AuthMiddleware
 	loadStructureHandler *LoadStructureHandler
+	docsHandler          *DocsHandler
+	apiHandler           *ApiHandler
+	postmanHandler       *PostmanHandler
+	typegenHandler       *TypegenHandler
+	ingestionHandler     *IngestionHandler
 }
 
 func NewHandler(
+	conf *config.Config,
 	loadstructureUC loadstructure.ILoadstructure,
-) *Handler {
+	catalogueUC catalogue.ICatalogueUC,
+	fetchDataUC fetchdata.IFetchDataUC,
+	typegenUC typegen.ITypegenUC,
+	postmanUC postman.IPostmanUC,
+) (*Handler, error) {
+	authMiddleware, err := NewAuthMiddleware(conf)
+	if err != nil {
+		return nil, err
+	}
 	return &Handler{
+		authMiddleware:       authMiddleware,
 		loadStructureHandler: NewLoadStructureHandler(loadstructureUC),
-	}
+		docsHandler:          NewDocsHandler(),
+		apiHandler:           NewApiHandler(catalogueUC),
+		postmanHandler:       NewPostmanHandler(postmanUC, fetchDataUC),
+		typegenHandler:       NewTypegenHandler(typegenUC),
+		ingestionHandler:     NewIngestionHandler(fetchDataUC),
+	}, nil
 }
 
 func RegisterCustomHTTPHandler(
@@ -30,10 +58,17 @@ func RegisterCustomHTTPHandler(
 	handler *Handler,
 ) {
 	e := echo.New()
+	e.HTTPErrorHandler = httpErrorHandler
+	e.Use(correlationIDMiddleware)
 
-	internalGroup := e.Group("/internal")
+	internalGroup := e.Group(internalPrefix)
 
-	handler.loadStructureHandler.RegisterHandler(internalGroup)
+	handler.loadStructureHandler.RegisterHandler(internalGroup, handler.authMiddleware)
+	handler.docsHandler.RegisterHandler(internalGroup)
+	handler.apiHandler.RegisterHandler(internalGroup, handler.authMiddleware)
+	handler.postmanHandler.RegisterHandler(internalGroup, handler.authMiddleware)
+	handler.typegenHandler.RegisterHandler(internalGroup, handler.authMiddleware)
+	handler.ingestionHandler.RegisterHandler(internalGroup, handler.authMiddleware)
 
 	echo.WrapHandler(mux)
 
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
+	"github.com/ct-logic-api-document/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type IngestionHandler struct {
+	FetchDataUC fetchdata.IFetchDataUC
+}
+
+func NewIngestionHandler(fetchDataUC fetchdata.IFetchDataUC) *IngestionHandler {
+	return &IngestionHandler{
+		FetchDataUC: fetchDataUC,
+	}
+}
+
+func (h *IngestionHandler) RegisterHandler(internalGroup *echo.Group, authMiddleware *AuthMiddleware) {
+	// the rejected lines quote the logged payloads
+	internalGroup.GET("/ingestion/rejections", h.GetRejections, authMiddleware.Require(auth.ScopeReadSamples))
+}
+
+func (h *IngestionHandler) GetRejections(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req, err := bindGetRejectionsRequest(echoCtx)
+	if err != nil {
+		return err
+	}
+	resp, err := h.FetchDataUC.GetRejections(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func bindGetRejectionsRequest(echoCtx echo.Context) (*entity.GetRejectionsRequest, error) {
+	req := &entity.GetRejectionsRequest{
+		Limit: constants.DefaultRejectionsLimit,
+	}
+	err := echo.QueryParamsBinder(echoCtx).
+		String("reason", &req.Reason).
+		String("source", &req.Source).
+		Int64("limit", &req.Limit).
+		Int64("offset", &req.Offset).
+		BindError()
+	if err != nil {
+		return nil, err
+	}
+	if req.Reason != "" && !constants.RejectionReasons.Contains(req.Reason) {
+		return nil, apperrors.InvalidArgument("reason must be one of %s",
+			strings.Join(constants.RejectionReasons, ", "))
+	}
+	if req.Limit <= 0 || req.Limit > constants.MaxRejectionsLimit {
+		return nil, apperrors.InvalidArgument("limit must be between 1 and %d", constants.MaxRejectionsLimit)
+	}
+	if req.Offset < 0 {
+		return nil, apperrors.InvalidArgument("offset must not be negative")
+	}
+	return req, nil
+}
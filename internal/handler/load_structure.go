@@ -1,12 +1,23 @@
 package handler
 
 import (
+	"bufio"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
 	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+	"github.com/ct-logic-api-document/pkg/auth"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
 	"github.com/labstack/echo/v4"
 )
 
+// openApiDocumentBufferSize is the size of the chunks the aggregated document
+// is streamed in.
+const openApiDocumentBufferSize = 64 * 1024
+
 type LoadStructureHandler struct {
 	LoadstructureUC loadstructure.ILoadstructure
 }
@@ -17,16 +28,78 @@ func NewLoadStructureHandler(loadstructureUC loadstructure.ILoadstructure) *Load
 	}
 }
 
-func (h *LoadStructureHandler) RegisterHandler(internalGroup *echo.Group) {
-	internalGroup.GET("/load-structure/:api_id", h.LoadStructureByApiId)
+func (h *LoadStructureHandler) RegisterHandler(internalGroup *echo.Group, authMiddleware *AuthMiddleware) {
+	readDocs := authMiddleware.Require(auth.ScopeReadDocs)
+	internalGroup.GET("/load-structure/:api_id", h.LoadStructureByApiId, readDocs)
+	internalGroup.GET("/openapi.json", h.LoadOpenApiDocument, readDocs)
 }
 
 func (h *LoadStructureHandler) LoadStructureByApiId(echoCtx echo.Context) error {
 	ctx := echoCtx.Request().Context()
-	resp, err := h.LoadstructureUC.LoadStructureByApiId(ctx, echoCtx.Param("api_id"))
+	req := &entity.LoadStructureByApiIdRequest{
+		ApiId:   echoCtx.Param("api_id"),
+		Version: echoCtx.QueryParam("version"),
+	}
+	if !openapiutils.IsValidVersion(req.Version) {
+		return apperrors.InvalidArgument("version must be 3.0 or 3.1")
+	}
+	resp, err := h.LoadstructureUC.LoadStructureByApiId(ctx, req)
 	if err != nil {
-		return echoCtx.JSON(http.StatusInternalServerError, err.Error())
+		return err
+	}
+	format := negotiateOpenApiFormat(echoCtx)
+	contentType := echo.MIMEApplicationJSON
+	if format == openapiutils.FormatYAML {
+		contentType = openapiutils.MIMEApplicationYAML
+	}
+	echoCtx.Response().Header().Set(echo.HeaderContentType, contentType)
+	echoCtx.Response().WriteHeader(http.StatusOK)
+	return openapiutils.Encode(echoCtx.Response(), resp, format)
+}
+
+func (h *LoadStructureHandler) LoadOpenApiDocument(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.LoadOpenApiDocumentRequest{
+		ApiFilter: entity.ApiFilter{
+			Host:       echoCtx.QueryParam("host"),
+			PathPrefix: echoCtx.QueryParam("path_prefix"),
+		},
+		Tag:     echoCtx.QueryParam("tag"),
+		Version: echoCtx.QueryParam("version"),
+	}
+	if !openapiutils.IsValidVersion(req.Version) {
+		return apperrors.InvalidArgument("version must be 3.0 or 3.1")
+	}
+	if updatedSince := echoCtx.QueryParam("updated_since"); updatedSince != "" {
+		t, err := time.Parse(time.RFC3339, updatedSince)
+		if err != nil {
+			return apperrors.InvalidArgument("updated_since must be a RFC 3339 time")
+		}
+		req.UpdatedSince = &t
+	}
+
+	resp := echoCtx.Response()
+	resp.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	// the status is sent with the first chunk, an error before it is still
+	// reported while the client gets a truncated, invalid document after it
+	w := bufio.NewWriterSize(resp, openApiDocumentBufferSize)
+	if err := h.LoadstructureUC.LoadOpenApiDocument(ctx, req, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// negotiateOpenApiFormat serves YAML when asked by the format query parameter
+// or the Accept header, JSON otherwise.
+func negotiateOpenApiFormat(echoCtx echo.Context) string {
+	switch echoCtx.QueryParam("format") {
+	case openapiutils.FormatYAML:
+		return openapiutils.FormatYAML
+	case openapiutils.FormatJSON:
+		return openapiutils.FormatJSON
+	}
+	if strings.Contains(echoCtx.Request().Header.Get(echo.HeaderAccept), openapiutils.FormatYAML) {
+		return openapiutils.FormatYAML
 	}
-	echoCtx.Response().Header().Set("Access-Control-Allow-Origin", "*")
-	return echoCtx.JSON(http.StatusOK, resp)
+	return openapiutils.FormatJSON
 }
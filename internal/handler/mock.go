@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/mock"
+	"github.com/labstack/echo/v4"
+)
+
+// headerMockSource tells which of the constants.MockSource* a mocked response
+// was made from.
+const headerMockSource = "X-Mock-Source"
+
+type MockHandler struct {
+	mockUC mock.IMockUC
+}
+
+func NewMockHandler(mockUC mock.IMockUC) *MockHandler {
+	return &MockHandler{
+		mockUC: mockUC,
+	}
+}
+
+// NewMockHTTPHandler returns the handler of the mock server, every path is
+// answered by the api it matches.
+func NewMockHTTPHandler(handler *MockHandler) http.Handler {
+	e := echo.New()
+	e.HideBanner = true
+	e.HTTPErrorHandler = httpErrorHandler
+	e.Use(correlationIDMiddleware)
+	e.Any("/*", handler.Respond)
+	return e
+}
+
+func (h *MockHandler) Respond(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.MockRequest{
+		Method: echoCtx.Request().Method,
+		Path:   echoCtx.Request().URL.Path,
+		Prefer: echoCtx.Request().Header.Get(constants.HeaderPrefer),
+	}
+	resp, err := h.mockUC.Respond(ctx, req)
+	if err != nil {
+		return err
+	}
+	if resp.Latency > 0 {
+		timer := time.NewTimer(resp.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	echoCtx.Response().Header().Set(headerMockSource, resp.Source)
+	if resp.Body == "" {
+		return echoCtx.NoContent(resp.StatusCode)
+	}
+	return echoCtx.JSONBlob(resp.StatusCode, []byte(resp.Body))
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
+	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
+	"github.com/ct-logic-api-document/internal/usecase/postman"
+	"github.com/ct-logic-api-document/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type PostmanHandler struct {
+	PostmanUC   postman.IPostmanUC
+	FetchDataUC fetchdata.IFetchDataUC
+}
+
+func NewPostmanHandler(
+	postmanUC postman.IPostmanUC,
+	fetchDataUC fetchdata.IFetchDataUC,
+) *PostmanHandler {
+	return &PostmanHandler{
+		PostmanUC:   postmanUC,
+		FetchDataUC: fetchDataUC,
+	}
+}
+
+func (h *PostmanHandler) RegisterHandler(internalGroup *echo.Group, authMiddleware *AuthMiddleware) {
+	internalGroup.GET("/export/postman", h.ExportPostmanCollection, authMiddleware.Require(auth.ScopeReadDocs))
+	internalGroup.POST("/import/postman", h.ImportPostmanCollection, authMiddleware.Require(auth.ScopeWriteSamples))
+}
+
+func (h *PostmanHandler) ExportPostmanCollection(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	req := &entity.ExportPostmanRequest{
+		ApiFilter: entity.ApiFilter{
+			Host:       echoCtx.QueryParam("host"),
+			PathPrefix: echoCtx.QueryParam("path_prefix"),
+		},
+		Tag: echoCtx.QueryParam("tag"),
+	}
+	resp, err := h.PostmanUC.ExportPostmanCollection(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
+
+func (h *PostmanHandler) ImportPostmanCollection(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	collection := &entity.PostmanCollection{}
+	if err := echoCtx.Bind(collection); err != nil {
+		return err
+	}
+	if collection.Info == nil || len(collection.Item) == 0 {
+		return apperrors.InvalidArgument("body must be a Postman collection with items")
+	}
+	resp, err := h.FetchDataUC.ImportPostmanCollection(ctx, collection)
+	if err != nil {
+		return err
+	}
+	return echoCtx.JSON(http.StatusOK, resp)
+}
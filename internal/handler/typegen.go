@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/typegen"
+	"github.com/ct-logic-api-document/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type TypegenHandler struct {
+	TypegenUC typegen.ITypegenUC
+}
+
+func NewTypegenHandler(typegenUC typegen.ITypegenUC) *TypegenHandler {
+	return &TypegenHandler{
+		TypegenUC: typegenUC,
+	}
+}
+
+func (h *TypegenHandler) RegisterHandler(internalGroup *echo.Group, authMiddleware *AuthMiddleware) {
+	readDocs := authMiddleware.Require(auth.ScopeReadDocs)
+	internalGroup.GET("/apis/:api_id/types", h.GenerateApiTypes, readDocs)
+	internalGroup.GET("/types/:type_name/code", h.GenerateTypeCode, readDocs)
+}
+
+func (h *TypegenHandler) GenerateApiTypes(echoCtx echo.Context) error {
+	req := bindGenerateTypesRequest(echoCtx)
+	req.ApiId = echoCtx.Param("api_id")
+	return h.generateTypes(echoCtx, req)
+}
+
+func (h *TypegenHandler) GenerateTypeCode(echoCtx echo.Context) error {
+	req := bindGenerateTypesRequest(echoCtx)
+	req.TypeName = echoCtx.Param("type_name")
+	return h.generateTypes(echoCtx, req)
+}
+
+// generateTypes answers the code as plain text, so it can be saved as is.
+func (h *TypegenHandler) generateTypes(echoCtx echo.Context, req *entity.GenerateTypesRequest) error {
+	ctx := echoCtx.Request().Context()
+	resp, err := h.TypegenUC.GenerateTypes(ctx, req)
+	if err != nil {
+		return err
+	}
+	return echoCtx.String(http.StatusOK, resp.Code)
+}
+
+// bindGenerateTypesRequest reads the lang, go by default, and package query
+// parameters.
+func bindGenerateTypesRequest(echoCtx echo.Context) *entity.GenerateTypesRequest {
+	req := &entity.GenerateTypesRequest{
+		Language: echoCtx.QueryParam("lang"),
+		Package:  echoCtx.QueryParam("package"),
+	}
+	if req.Language == "" {
+		req.Language = constants.TypegenLanguageGo
+	}
+	return req
+}
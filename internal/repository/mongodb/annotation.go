@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IAnnotationCollection interface {
+	GetAnnotationsByApiId(ctx context.Context, apiId primitive.ObjectID) ([]*entity.Annotation, error)
+	SaveAnnotation(ctx context.Context, annotation *entity.Annotation) error
+	DeleteAnnotation(ctx context.Context, apiId primitive.ObjectID, target, pointer string) error
+}
+
+type AnnotationCollection struct {
+	mongodbutils.BaseCollection[entity.Annotation, *entity.Annotation]
+}
+
+var _ IAnnotationCollection = (*AnnotationCollection)(nil)
+
+func NewAnnotationCollection(db *mongo.Database) *AnnotationCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.Annotation](db, constants.AnnotationsCollection)
+	return &AnnotationCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+func (a *AnnotationCollection) GetAnnotationsByApiId(ctx context.Context, apiId primitive.ObjectID) ([]*entity.Annotation, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	sort := bson.D{{Key: "target", Value: 1}, {Key: "pointer", Value: 1}}
+	return a.GetByBatch(ctx, filter, sort, 0, 0)
+}
+
+// SaveAnnotation replaces the annotation of the same api, target and pointer.
+func (a *AnnotationCollection) SaveAnnotation(ctx context.Context, annotation *entity.Annotation) error {
+	filter := container.Map{
+		"api_id":  annotation.ApiId,
+		"target":  annotation.Target,
+		"pointer": annotation.Pointer,
+	}
+	_, _, err := a.Upsert(ctx, filter, annotation)
+	return err
+}
+
+func (a *AnnotationCollection) DeleteAnnotation(ctx context.Context, apiId primitive.ObjectID, target, pointer string) error {
+	filter := bson.M{
+		"api_id":  apiId,
+		"target":  target,
+		"pointer": pointer,
+	}
+	return a.Delete(ctx, filter, nil)
+}
@@ -3,12 +3,15 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/carousell/ct-go/pkg/container"
 	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,9 +22,27 @@ type IApiCollection interface {
 	CreateApi(ctx context.Context, api *entity.Api) error
 	GetApiByPath(ctx context.Context, path string) (*entity.Api, error)
 	GetApis(ctx context.Context, limit, offset int64) ([]*entity.Api, error)
+	GetApisByFilter(ctx context.Context, req *entity.GetApisRequest) ([]*entity.Api, error)
+	CountApisByFilter(ctx context.Context, req *entity.GetApisRequest) (int64, error)
+	IterateApis(ctx context.Context, fn func(api *entity.Api) error) error
+	IterateApisByFilter(ctx context.Context, apiFilter *entity.ApiFilter, fn func(api *entity.Api) error) error
 	CountApis(ctx context.Context) (int64, error)
 	UpdateLatestBuildStructure(ctx context.Context, id primitive.ObjectID, latestBuildStructure *time.Time) error
+	// AdvanceLatestBuildStructure moves the build watermark of the api forward
+	// to latestBuildStructure, never backward.
+	AdvanceLatestBuildStructure(ctx context.Context, id primitive.ObjectID, latestBuildStructure time.Time) error
 	GetApiByIdInStr(ctx context.Context, id string) (*entity.Api, error)
+	GetApiById(ctx context.Context, id primitive.ObjectID) (*entity.Api, error)
+	UpdateApiLastSeenAt(ctx context.Context, id primitive.ObjectID, lastSeenAt time.Time) error
+	UpdateApiStructuredAt(ctx context.Context, id primitive.ObjectID, structuredAt time.Time) error
+	UpdateApiInfo(ctx context.Context, id primitive.ObjectID, req *entity.UpdateApiRequest) error
+	// UpdateApiDeprecatedCandidate flags the api as a deprecated candidate
+	// since flaggedAt, a nil flaggedAt clears the flag.
+	UpdateApiDeprecatedCandidate(ctx context.Context, id primitive.ObjectID, flaggedAt *time.Time) error
+	SoftDeleteApi(ctx context.Context, id primitive.ObjectID) error
+	// RestoreApiByPath brings back the soft deleted api of path, without its
+	// deprecated candidate flag, nil when there is none.
+	RestoreApiByPath(ctx context.Context, path string) (*entity.Api, error)
 }
 
 type ApiCollection struct {
@@ -43,7 +64,8 @@ func (a *ApiCollection) CreateApi(ctx context.Context, api *entity.Api) error {
 
 func (a *ApiCollection) GetApiByPath(ctx context.Context, path string) (*entity.Api, error) {
 	filter := container.Map{
-		"path": path,
+		"path":       path,
+		"deleted_at": bson.M{"$exists": false},
 	}
 	return a.Get(ctx, filter)
 }
@@ -58,6 +80,46 @@ func (a *ApiCollection) GetApis(ctx context.Context, limit, offset int64) ([]*en
 	return apis, nil
 }
 
+func (a *ApiCollection) GetApisByFilter(ctx context.Context, req *entity.GetApisRequest) ([]*entity.Api, error) {
+	filter := buildApisFilter(req)
+	return a.GetByBatch(ctx, filter, buildApisSort(req.Sort), req.Limit, req.Offset)
+}
+
+func (a *ApiCollection) CountApisByFilter(ctx context.Context, req *entity.GetApisRequest) (int64, error) {
+	filter := buildApisFilter(req)
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return a.CountByFilter(ctx, filter)
+}
+
+func (a *ApiCollection) IterateApis(ctx context.Context, fn func(api *entity.Api) error) error {
+	filter := container.Map{}
+	sort := bson.D{{Key: "_id", Value: 1}}
+	return a.Iterate(ctx, filter, sort, fn)
+}
+
+// IterateApisByFilter streams the apis matching apiFilter sorted by path, so
+// the methods of a path are adjacent.
+func (a *ApiCollection) IterateApisByFilter(ctx context.Context, apiFilter *entity.ApiFilter,
+	fn func(api *entity.Api) error,
+) error {
+	filter := container.Map{}
+	if apiFilter.Host != "" {
+		filter["host"] = apiFilter.Host
+	}
+	if apiFilter.PathPrefix != "" {
+		filter["path"] = bson.M{"$regex": "^" + regexp.QuoteMeta(apiFilter.PathPrefix)}
+	}
+	if apiFilter.UpdatedSince != nil {
+		filter["$or"] = bson.A{
+			bson.M{"updated_at": bson.M{"$gte": apiFilter.UpdatedSince}},
+			bson.M{"latest_build_structure": bson.M{"$gte": apiFilter.UpdatedSince}},
+			bson.M{"structured_at": bson.M{"$gte": apiFilter.UpdatedSince}},
+		}
+	}
+	sort := bson.D{{Key: "path", Value: 1}}
+	return a.Iterate(ctx, filter, sort, fn)
+}
+
 func (a *ApiCollection) CountApis(ctx context.Context) (int64, error) {
 	filter := container.Map{}
 	return a.CountByFilter(ctx, filter)
@@ -85,13 +147,157 @@ func (a *ApiCollection) UpdateLatestBuildStructure(ctx context.Context, id primi
 	return nil
 }
 
+func (a *ApiCollection) AdvanceLatestBuildStructure(
+	ctx context.Context,
+	id primitive.ObjectID,
+	latestBuildStructure time.Time,
+) error {
+	filter := container.Map{
+		"_id": id,
+	}
+	update := bson.M{"$max": bson.M{"latest_build_structure": latestBuildStructure}}
+	_, err := a.UpdateRaw(ctx, filter, update)
+	return err
+}
+
 func (a *ApiCollection) GetApiByIdInStr(ctx context.Context, id string) (*entity.Api, error) {
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, apperrors.Wrap(apperrors.KindInvalidArgument, err, "api id must be a 24 hex characters object id")
 	}
 	filter := container.Map{
 		"_id": objId,
 	}
 	return a.Get(ctx, filter)
 }
+
+func (a *ApiCollection) GetApiById(ctx context.Context, id primitive.ObjectID) (*entity.Api, error) {
+	filter := container.Map{
+		"_id": id,
+	}
+	return a.Get(ctx, filter)
+}
+
+// UpdateApiLastSeenAt moves the last seen time of the api forward, calls may
+// be ingested out of order.
+func (a *ApiCollection) UpdateApiLastSeenAt(ctx context.Context, id primitive.ObjectID, lastSeenAt time.Time) error {
+	filter := container.Map{
+		"_id": id,
+	}
+	_, err := a.UpdateRaw(ctx, filter, bson.M{"$max": bson.M{"last_seen_at": lastSeenAt}})
+	return err
+}
+
+func (a *ApiCollection) UpdateApiStructuredAt(ctx context.Context, id primitive.ObjectID, structuredAt time.Time) error {
+	filter := container.Map{
+		"_id": id,
+	}
+	_, err := a.UpdateRaw(ctx, filter, bson.M{"$max": bson.M{"structured_at": structuredAt}})
+	return err
+}
+
+// UpdateApiInfo sets the human metadata of req which are not nil, an empty
+// Tags restores the inferred tag.
+func (a *ApiCollection) UpdateApiInfo(ctx context.Context, id primitive.ObjectID, req *entity.UpdateApiRequest) error {
+	filter := container.Map{
+		"_id": id,
+	}
+	update := container.Map{}
+	if req.Title != nil {
+		update["title"] = *req.Title
+	}
+	if req.Description != nil {
+		update["description"] = *req.Description
+	}
+	if req.Tags != nil {
+		update["tags"] = req.Tags
+	}
+	_, err := a.UpdatePartial(ctx, filter, update)
+	return err
+}
+
+func (a *ApiCollection) UpdateApiDeprecatedCandidate(
+	ctx context.Context,
+	id primitive.ObjectID,
+	flaggedAt *time.Time,
+) error {
+	filter := container.Map{
+		"_id": id,
+	}
+	update := bson.M{"$unset": bson.M{"deprecated_candidate_at": ""}}
+	if flaggedAt != nil {
+		update = bson.M{"$set": bson.M{"deprecated_candidate_at": flaggedAt}}
+	}
+	_, err := a.UpdateRaw(ctx, filter, update)
+	return err
+}
+
+func (a *ApiCollection) SoftDeleteApi(ctx context.Context, id primitive.ObjectID) error {
+	_, err := a.SoftDelete(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (a *ApiCollection) RestoreApiByPath(ctx context.Context, path string) (*entity.Api, error) {
+	filter := container.Map{
+		"path":       path,
+		"deleted_at": bson.M{"$exists": true},
+	}
+	update := bson.M{"$unset": bson.M{"deleted_at": "", "deprecated_candidate_at": ""}}
+	updateResult, err := a.UpdateRaw(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if updateResult.MatchedCount == 0 {
+		return nil, nil
+	}
+	return a.GetApiByPath(ctx, path)
+}
+
+func buildApisFilter(req *entity.GetApisRequest) container.Map {
+	filter := container.Map{}
+	if req.Host != "" {
+		filter["host"] = req.Host
+	}
+	if req.Method != "" {
+		filter["method"] = strings.ToUpper(req.Method)
+	}
+	if req.Path != "" {
+		filter["path"] = bson.M{"$regex": regexp.QuoteMeta(req.Path)}
+	}
+	// apis built before structured_at was recorded only have latest_build_structure
+	if req.HasStructure != nil && *req.HasStructure {
+		filter["$or"] = bson.A{
+			bson.M{"structured_at": bson.M{"$exists": true}},
+			bson.M{"latest_build_structure": bson.M{"$ne": nil}},
+		}
+	}
+	if req.HasStructure != nil && !*req.HasStructure {
+		filter["structured_at"] = bson.M{"$exists": false}
+		filter["latest_build_structure"] = nil
+	}
+	lastSeenAt := bson.M{}
+	if req.LastSeenFrom != nil {
+		lastSeenAt["$gte"] = req.LastSeenFrom
+	}
+	if req.LastSeenTo != nil {
+		lastSeenAt["$lte"] = req.LastSeenTo
+	}
+	if len(lastSeenAt) > 0 {
+		filter["last_seen_at"] = lastSeenAt
+	}
+	return filter
+}
+
+// buildApisSort sorts by a field, descending when prefixed by "-", with _id
+// as the tie-breaker so the pages are stable.
+func buildApisSort(sort string) bson.D {
+	if sort == "" {
+		sort = constants.DefaultApisSort
+	}
+	direction := 1
+	if strings.HasPrefix(sort, "-") {
+		direction = -1
+		sort = sort[1:]
+	}
+	return bson.D{{Key: sort, Value: direction}, {Key: "_id", Value: direction}}
+}
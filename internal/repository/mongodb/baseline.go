@@ -0,0 +1,46 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IBaselineCollection interface {
+	GetBaselineByApiId(ctx context.Context, apiId primitive.ObjectID) (*entity.Baseline, error)
+	SaveBaseline(ctx context.Context, baseline *entity.Baseline) error
+}
+
+type BaselineCollection struct {
+	mongodbutils.BaseCollection[entity.Baseline, *entity.Baseline]
+}
+
+var _ IBaselineCollection = (*BaselineCollection)(nil)
+
+func NewBaselineCollection(db *mongo.Database) *BaselineCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.Baseline](db, constants.BaselinesCollection)
+	return &BaselineCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+func (b *BaselineCollection) GetBaselineByApiId(ctx context.Context, apiId primitive.ObjectID) (*entity.Baseline, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	return b.Get(ctx, filter)
+}
+
+// SaveBaseline replaces the baseline of the api, the last import wins.
+func (b *BaselineCollection) SaveBaseline(ctx context.Context, baseline *entity.Baseline) error {
+	filter := container.Map{
+		"api_id": baseline.ApiId,
+	}
+	_, _, err := b.Upsert(ctx, filter, baseline)
+	return err
+}
@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IConsumerCollection interface {
+	// RecordConsumerUsages counts a call of the api seen at seenAt into the
+	// usages of its consumers, only the keys of usages are read.
+	RecordConsumerUsages(ctx context.Context, apiId primitive.ObjectID, usages []*entity.ConsumerUsage, seenAt time.Time) error
+	// GetConsumerUsagesByApiId returns the usages of the api seen since from,
+	// of a kind when it is not empty, the most frequent first.
+	GetConsumerUsagesByApiId(ctx context.Context, apiId primitive.ObjectID, kind string, from *time.Time) ([]*entity.ConsumerUsage, error)
+}
+
+type ConsumerCollection struct {
+	mongodbutils.BaseCollection[entity.ConsumerUsage, *entity.ConsumerUsage]
+}
+
+var _ IConsumerCollection = (*ConsumerCollection)(nil)
+
+func NewConsumerCollection(db *mongo.Database) *ConsumerCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.ConsumerUsage](db, constants.ConsumersCollection)
+	return &ConsumerCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+// EnsureIndexes keeps a single usage document per api and consumer key,
+// concurrent upserts of a new consumer would otherwise both insert.
+func (c *ConsumerCollection) EnsureIndexes(ctx context.Context) error {
+	return c.EnsureUniqueIndex(ctx, bson.D{
+		{Key: "api_id", Value: 1},
+		{Key: "kind", Value: 1},
+		{Key: "name", Value: 1},
+		{Key: "version", Value: 1},
+		{Key: "target", Value: 1},
+		{Key: "field", Value: 1},
+	})
+}
+
+func (c *ConsumerCollection) RecordConsumerUsages(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	usages []*entity.ConsumerUsage,
+	seenAt time.Time,
+) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(usages))
+	for _, usage := range usages {
+		filter := container.Map{
+			"api_id":  apiId,
+			"kind":    usage.Kind,
+			"name":    usage.Name,
+			"version": usage.Version,
+			"target":  usage.Target,
+			"field":   usage.Field,
+		}
+		update := primitive.M{
+			"$inc": primitive.M{"count": 1},
+			"$set": primitive.M{
+				"updated_at": time.Now(),
+			},
+			"$max": primitive.M{"last_seen_at": seenAt},
+			"$min": primitive.M{"first_seen_at": seenAt},
+			"$setOnInsert": primitive.M{
+				"created_at": time.Now(),
+			},
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+	_, err := c.BulkWrite(ctx, models)
+	return err
+}
+
+func (c *ConsumerCollection) GetConsumerUsagesByApiId(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	kind string,
+	from *time.Time,
+) ([]*entity.ConsumerUsage, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	if from != nil {
+		filter["last_seen_at"] = bson.M{"$gte": from}
+	}
+	sort := bson.D{{Key: "count", Value: -1}}
+	return c.GetByBatch(ctx, filter, sort, 0, 0)
+}
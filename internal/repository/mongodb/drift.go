@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IDriftCollection interface {
+	// RecordDrifts counts violations, found in a call of the api seen at
+	// seenAt, into the drifts of the api.
+	RecordDrifts(ctx context.Context, apiId primitive.ObjectID, violations []*entity.Violation, seenAt time.Time) error
+	GetDriftsByApiId(ctx context.Context, apiId primitive.ObjectID, from *time.Time) ([]*entity.Drift, error)
+	// IterateDrifts streams the drifts seen since from, the drifts of an api
+	// are adjacent.
+	IterateDrifts(ctx context.Context, from time.Time, fn func(drift *entity.Drift) error) error
+}
+
+type DriftCollection struct {
+	mongodbutils.BaseCollection[entity.Drift, *entity.Drift]
+}
+
+var _ IDriftCollection = (*DriftCollection)(nil)
+
+func NewDriftCollection(db *mongo.Database) *DriftCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.Drift](db, constants.DriftsCollection)
+	return &DriftCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+// EnsureIndexes keeps a single drift document per api and violation key,
+// concurrent upserts of a new violation would otherwise both insert.
+func (d *DriftCollection) EnsureIndexes(ctx context.Context) error {
+	return d.EnsureUniqueIndex(ctx, bson.D{
+		{Key: "api_id", Value: 1},
+		{Key: "target", Value: 1},
+		{Key: "kind", Value: 1},
+		{Key: "field", Value: 1},
+	})
+}
+
+func (d *DriftCollection) RecordDrifts(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	violations []*entity.Violation,
+	seenAt time.Time,
+) error {
+	for _, violation := range violations {
+		filter := container.Map{
+			"api_id": apiId,
+			"target": violation.Target,
+			"kind":   violation.Kind,
+			"field":  violation.Field,
+		}
+		update := primitive.M{
+			"$inc": primitive.M{"count": 1},
+			"$set": primitive.M{
+				"message":    violation.Message,
+				"updated_at": time.Now(),
+			},
+			"$max": primitive.M{"last_seen_at": seenAt},
+			"$min": primitive.M{"first_seen_at": seenAt},
+			"$setOnInsert": primitive.M{
+				"created_at": time.Now(),
+			},
+		}
+		if _, err := d.UpsertRaw(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DriftCollection) GetDriftsByApiId(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	from *time.Time,
+) ([]*entity.Drift, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	if from != nil {
+		filter["last_seen_at"] = bson.M{"$gte": from}
+	}
+	sort := bson.D{{Key: "count", Value: -1}}
+	return d.GetByBatch(ctx, filter, sort, 0, 0)
+}
+
+func (d *DriftCollection) IterateDrifts(ctx context.Context, from time.Time, fn func(drift *entity.Drift) error) error {
+	filter := container.Map{
+		"last_seen_at": bson.M{"$gte": from},
+	}
+	sort := bson.D{{Key: "api_id", Value: 1}}
+	return d.Iterate(ctx, filter, sort, fn)
+}
@@ -0,0 +1,27 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ILeaseCollection interface {
+	WithLease(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context) error) error
+}
+
+type LeaseCollection struct {
+	mongodbutils.LeaseCollection
+}
+
+var _ ILeaseCollection = (*LeaseCollection)(nil)
+
+func NewLeaseCollection(db *mongo.Database) *LeaseCollection {
+	leaseCollection := mongodbutils.NewLeaseCollection(db, constants.LeasesCollection)
+	return &LeaseCollection{
+		LeaseCollection: *leaseCollection,
+	}
+}
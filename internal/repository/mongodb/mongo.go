@@ -24,6 +24,15 @@ type MongoStorage interface {
 	IRequestStructureCollection
 	IResponseStructureCollection
 	ITypeCollection
+	ILeaseCollection
+	IWatchCheckpointCollection
+	IAnnotationCollection
+	ISchemaOverrideCollection
+	IDriftCollection
+	IBaselineCollection
+	IStatsCollection
+	IConsumerCollection
+	IRejectionCollection
 }
 
 type mongoStorage struct {
@@ -38,6 +47,15 @@ type mongoStorage struct {
 	RequestStructureCollection
 	ResponseStructureCollection
 	TypeCollection
+	LeaseCollection
+	WatchCheckpointCollection
+	AnnotationCollection
+	SchemaOverrideCollection
+	DriftCollection
+	BaselineCollection
+	StatsCollection
+	ConsumerCollection
+	RejectionCollection
 }
 
 var _ MongoStorage = &mongoStorage{}
@@ -68,7 +86,7 @@ func newMongoStorage(log *logger.Logger, conf *config.Config) *mongoStorage { //
 		panic(err)
 	}
 	log.Info("Connected to mongodb")
-	return &mongoStorage{
+	storage := &mongoStorage{
 		log:       log,
 		mgo:       mongoDB,
 		conf:      conf,
@@ -80,7 +98,34 @@ func newMongoStorage(log *logger.Logger, conf *config.Config) *mongoStorage { //
 		RequestStructureCollection:  *NewRequestStructureCollection(mongoDB),
 		ResponseStructureCollection: *NewResponseStructureCollection(mongoDB),
 		TypeCollection:              *NewTypeCollection(mongoDB),
+		LeaseCollection:             *NewLeaseCollection(mongoDB),
+		WatchCheckpointCollection:   *NewWatchCheckpointCollection(mongoDB),
+		AnnotationCollection:        *NewAnnotationCollection(mongoDB),
+		SchemaOverrideCollection:    *NewSchemaOverrideCollection(mongoDB),
+		DriftCollection:             *NewDriftCollection(mongoDB),
+		BaselineCollection:          *NewBaselineCollection(mongoDB),
+		StatsCollection:             *NewStatsCollection(mongoDB),
+		ConsumerCollection:          *NewConsumerCollection(mongoDB),
+		RejectionCollection:         *NewRejectionCollection(mongoDB),
 	}
+	if err := storage.ensureIndexes(ctx); err != nil {
+		log.Fatalf("failed to ensure mongodb indexes: %v", err) //nolint:revive
+	}
+	return storage
+}
+
+// ensureIndexes creates the unique indexes the counter upserts rely on.
+func (m *mongoStorage) ensureIndexes(ctx context.Context) error {
+	if err := m.StatsCollection.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := m.DriftCollection.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := m.ConsumerCollection.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	return m.RejectionCollection.EnsureIndexes(ctx)
 }
 
 func (m *mongoStorage) StopMongoDB() {
@@ -0,0 +1,132 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"github.com/spf13/cast"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IRejectionCollection interface {
+	// RecordRejection stores a rejected line, a line of a source rejected
+	// again replaces the previous rejection and is counted.
+	RecordRejection(ctx context.Context, rejection *entity.Rejection) error
+	// GetRejections returns the rejected lines from the most recently rejected.
+	GetRejections(ctx context.Context, req *entity.GetRejectionsRequest) ([]*entity.Rejection, error)
+	CountRejections(ctx context.Context, req *entity.GetRejectionsRequest) (int64, error)
+	// CountRejectionsByReason returns the number of rejected lines by reason,
+	// of a source when it is not empty.
+	CountRejectionsByReason(ctx context.Context, source string) (map[string]int64, error)
+	IterateRejections(ctx context.Context, fn func(rejection *entity.Rejection) error) error
+	DeleteRejection(ctx context.Context, id primitive.ObjectID) error
+}
+
+type RejectionCollection struct {
+	mongodbutils.BaseCollection[entity.Rejection, *entity.Rejection]
+}
+
+var _ IRejectionCollection = (*RejectionCollection)(nil)
+
+func NewRejectionCollection(db *mongo.Database) *RejectionCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.Rejection](db, constants.RejectionsCollection)
+	return &RejectionCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+// EnsureIndexes keeps a single rejection per line of a source, concurrent
+// fetches rejecting the same line would otherwise both insert.
+func (r *RejectionCollection) EnsureIndexes(ctx context.Context) error {
+	return r.EnsureUniqueIndex(ctx, bson.D{
+		{Key: "source", Value: 1},
+		{Key: "line_number", Value: 1},
+	})
+}
+
+func (r *RejectionCollection) RecordRejection(ctx context.Context, rejection *entity.Rejection) error {
+	filter := container.Map{
+		"source":      rejection.Source,
+		"line_number": rejection.LineNumber,
+	}
+	update := primitive.M{
+		"$inc": primitive.M{"count": 1},
+		"$set": primitive.M{
+			"stage":            rejection.Stage,
+			"reason":           rejection.Reason,
+			"error":            rejection.Error,
+			"line":             rejection.Line,
+			"raw_line":         rejection.RawLine,
+			"truncated":        rejection.Truncated,
+			"last_rejected_at": rejection.LastRejectedAt,
+			"updated_at":       time.Now(),
+		},
+		"$setOnInsert": primitive.M{
+			"created_at": time.Now(),
+		},
+	}
+	_, err := r.UpsertRaw(ctx, filter, update)
+	return err
+}
+
+func (r *RejectionCollection) GetRejections(
+	ctx context.Context,
+	req *entity.GetRejectionsRequest,
+) ([]*entity.Rejection, error) {
+	filter := buildRejectionsFilter(req)
+	sort := bson.D{{Key: "last_rejected_at", Value: -1}, {Key: "_id", Value: -1}}
+	return r.GetByBatch(ctx, filter, sort, req.Limit, req.Offset)
+}
+
+func (r *RejectionCollection) CountRejections(ctx context.Context, req *entity.GetRejectionsRequest) (int64, error) {
+	filter := buildRejectionsFilter(req)
+	return r.CountByFilter(ctx, filter)
+}
+
+func (r *RejectionCollection) CountRejectionsByReason(ctx context.Context, source string) (map[string]int64, error) {
+	filter := buildRejectionsFilter(&entity.GetRejectionsRequest{Source: source})
+	reasons, err := r.Distinct(ctx, "reason", filter)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(reasons))
+	for _, value := range reasons {
+		reason := cast.ToString(value)
+		count, err := r.CountByFilter(ctx, buildRejectionsFilter(&entity.GetRejectionsRequest{
+			Reason: reason,
+			Source: source,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		counts[reason] = count
+	}
+	return counts, nil
+}
+
+func (r *RejectionCollection) IterateRejections(ctx context.Context, fn func(rejection *entity.Rejection) error) error {
+	filter := container.Map{}
+	sort := bson.D{{Key: "_id", Value: 1}}
+	return r.Iterate(ctx, filter, sort, fn)
+}
+
+func (r *RejectionCollection) DeleteRejection(ctx context.Context, id primitive.ObjectID) error {
+	return r.DeleteById(ctx, id)
+}
+
+func buildRejectionsFilter(req *entity.GetRejectionsRequest) container.Map {
+	filter := container.Map{}
+	if req.Reason != "" {
+		filter["reason"] = req.Reason
+	}
+	if req.Source != "" {
+		filter["source"] = req.Source
+	}
+	return filter
+}
@@ -2,11 +2,12 @@ package mongodb
 
 import (
 	"context"
-	"errors"
 
 	"github.com/carousell/ct-go/pkg/container"
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,7 +16,16 @@ import (
 type IRequestStructureCollection interface {
 	CreateRequestStructure(ctx context.Context, requestStructure *entity.RequestStructure) error
 	GetRequestStructureByApiId(ctx context.Context, apiId primitive.ObjectID) (*entity.RequestStructure, error)
-	UpdateRequestStructure(ctx context.Context, id primitive.ObjectID, parameters []*entity.Parameter, bodySchema map[string]any) error
+	UpdateRequestStructure(
+		ctx context.Context,
+		id primitive.ObjectID,
+		version int64,
+		parameters []*entity.Parameter,
+		bodySchema map[string]any,
+		effectiveBodySchema map[string]any,
+		security *entity.SecurityUsage,
+		examples []*entity.Example,
+	) error
 }
 
 type RequestStructureCollection struct {
@@ -48,25 +58,35 @@ func (r *RequestStructureCollection) GetRequestStructureByApiId(
 	return r.Get(ctx, filter)
 }
 
+// UpdateRequestStructure fails with ErrStaleStructure when the structure is no
+// longer at version, i.e. another builder updated it since it was read.
 func (r *RequestStructureCollection) UpdateRequestStructure(
 	ctx context.Context,
 	id primitive.ObjectID,
+	version int64,
 	parameters []*entity.Parameter,
 	bodySchema map[string]any,
+	effectiveBodySchema map[string]any,
+	security *entity.SecurityUsage,
+	examples []*entity.Example,
 ) error {
 	filter := container.Map{
 		"_id": id,
 	}
 	update := container.Map{
-		"parameters":  parameters,
-		"body_schema": bodySchema,
+		"parameters":            parameters,
+		"body_schema":           bodySchema,
+		"effective_body_schema": effectiveBodySchema,
+		"security":              security,
+		"examples":              examples,
 	}
-	updatedResult, err := r.UpdatePartial(ctx, filter, update)
+	updatedResult, err := r.UpdatePartialWithVersion(ctx, filter, version, update)
 	if err != nil {
 		return err
 	}
 	if updatedResult.MatchedCount == 0 {
-		return errors.New("no documents found")
+		logctx.Errorw(ctx, "structure version changed", "id", id, "version", version)
+		return errors.ErrStaleStructure
 	}
 	return nil
 }
@@ -2,11 +2,12 @@ package mongodb
 
 import (
 	"context"
-	"errors"
 
+	"github.com/carousell/ct-go/pkg/container"
 	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,7 +16,15 @@ import (
 type IResponseStructureCollection interface {
 	CreateResponseStructure(ctx context.Context, responseStructure *entity.ResponseStructure) error
 	GetResponseStructureByApiId(ctx context.Context, apiId primitive.ObjectID) (*entity.ResponseStructure, error)
-	UpdateResponseStructure(ctx context.Context, id primitive.ObjectID, bodySchema map[string]any) error
+	UpdateResponseStructure(
+		ctx context.Context,
+		id primitive.ObjectID,
+		version int64,
+		bodySchema map[string]any,
+		effectiveBodySchema map[string]any,
+		examples map[string][]*entity.Example,
+		fieldsSeen []*entity.FieldSeen,
+	) error
 }
 
 type ResponseStructureCollection struct {
@@ -48,20 +57,33 @@ func (r *ResponseStructureCollection) GetResponseStructureByApiId(
 	return r.Get(ctx, filter)
 }
 
-func (r *ResponseStructureCollection) UpdateResponseStructure(ctx context.Context, id primitive.ObjectID, bodySchema map[string]any) error {
-	filter := map[string]any{
+// UpdateResponseStructure fails with ErrStaleStructure when the structure is no
+// longer at version, i.e. another builder updated it since it was read.
+func (r *ResponseStructureCollection) UpdateResponseStructure(
+	ctx context.Context,
+	id primitive.ObjectID,
+	version int64,
+	bodySchema map[string]any,
+	effectiveBodySchema map[string]any,
+	examples map[string][]*entity.Example,
+	fieldsSeen []*entity.FieldSeen,
+) error {
+	filter := container.Map{
 		"_id": id,
 	}
-	update := map[string]any{
-		"body_schema": bodySchema,
+	update := container.Map{
+		"body_schema":           bodySchema,
+		"effective_body_schema": effectiveBodySchema,
+		"examples":              examples,
+		"fields_seen":           fieldsSeen,
 	}
-	updatedResult, err := r.UpdatePartial(ctx, filter, update)
+	updatedResult, err := r.UpdatePartialWithVersion(ctx, filter, version, update)
 	if err != nil {
 		return err
 	}
 	if updatedResult.MatchedCount == 0 {
-		logctx.Errorw(ctx, "no documents found", "id", id)
-		return errors.New("no documents found")
+		logctx.Errorw(ctx, "structure version changed", "id", id, "version", version)
+		return errors.ErrStaleStructure
 	}
 	return nil
 }
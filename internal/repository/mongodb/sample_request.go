@@ -2,18 +2,37 @@ package mongodb
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/carousell/ct-go/pkg/container"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ISampleRequestCollection interface {
 	CreateSampleRequest(ctx context.Context, sampleRequest *entity.SampleRequest) error
+	CountSampleRequestsByApiId(ctx context.Context, apiId primitive.ObjectID) (int64, error)
 	GetSampleRequestByApiId(ctx context.Context, req *entity.GetSampleRequestByApiIdRequest) ([]*entity.SampleRequest, error)
+	IterateSampleRequestByApiId(
+		ctx context.Context,
+		req *entity.GetSampleRequestByApiIdRequest,
+		fn func(sampleRequest *entity.SampleRequest) error,
+	) error
+	IterateLatestSampleRequestByApiId(
+		ctx context.Context,
+		req *entity.GetSampleRequestByApiIdRequest,
+		fn func(sampleRequest *entity.SampleRequest) error,
+	) error
+	WatchSampleRequests(
+		ctx context.Context,
+		resumeToken bson.Raw,
+		fn func(event *mongodbutils.ChangeEvent[*entity.SampleRequest]) error,
+	) error
 }
 
 type SampleRequestCollection struct {
@@ -37,15 +56,90 @@ func (s *SampleRequestCollection) GetSampleRequestByApiId(
 	ctx context.Context,
 	req *entity.GetSampleRequestByApiIdRequest,
 ) ([]*entity.SampleRequest, error) {
+	filter := buildSampleRequestFilter(req)
+	sort := bson.D{{Key: "created_at", Value: -1}}
+	return s.GetByBatch(ctx, filter, sort, req.Limit, req.Offset)
+}
+
+// IterateSampleRequestByApiId streams the samples of req.ApiId in insertion order,
+// req.Limit and req.Offset are ignored.
+func (s *SampleRequestCollection) IterateSampleRequestByApiId(
+	ctx context.Context,
+	req *entity.GetSampleRequestByApiIdRequest,
+	fn func(sampleRequest *entity.SampleRequest) error,
+) error {
+	filter := buildSampleRequestFilter(req)
+	sort := bson.D{{Key: "_id", Value: 1}}
+	return s.Iterate(ctx, filter, sort, fn)
+}
+
+// IterateLatestSampleRequestByApiId streams the samples of req.ApiId from the most
+// recent, req.Limit and req.Offset are ignored.
+func (s *SampleRequestCollection) IterateLatestSampleRequestByApiId(
+	ctx context.Context,
+	req *entity.GetSampleRequestByApiIdRequest,
+	fn func(sampleRequest *entity.SampleRequest) error,
+) error {
+	filter := buildSampleRequestFilter(req)
+	sort := bson.D{{Key: "_id", Value: -1}}
+	return s.Iterate(ctx, filter, sort, fn)
+}
+
+func (s *SampleRequestCollection) WatchSampleRequests(
+	ctx context.Context,
+	resumeToken bson.Raw,
+	fn func(event *mongodbutils.ChangeEvent[*entity.SampleRequest]) error,
+) error {
+	return s.WatchInserts(ctx, resumeToken, fn)
+}
+
+func (s *SampleRequestCollection) CountSampleRequestsByApiId(ctx context.Context, apiId primitive.ObjectID) (int64, error) {
+	filter := container.Map{
+		"api_id":     apiId,
+		"deleted_at": bson.M{"$exists": false},
+	}
+	return s.CountByFilter(ctx, filter)
+}
+
+func buildSampleRequestFilter(req *entity.GetSampleRequestByApiIdRequest) container.Map {
 	filter := container.Map{
 		"api_id": req.ApiId,
 	}
-	if req.From != nil {
-		filter["created_at"] = bson.M{"$gte": req.From}
+	if createdAt := buildCreatedAtFilter(req.From, req.To); createdAt != nil {
+		filter["created_at"] = createdAt
 	}
-	if req.To != nil {
-		filter["created_at"] = bson.M{"$lte": req.To}
+	if req.BeforeId != nil {
+		filter["_id"] = bson.M{"$lt": req.BeforeId}
 	}
-	sort := bson.D{{Key: "created_at", Value: -1}}
-	return s.GetByBatch(ctx, filter, sort, req.Limit, req.Offset)
+	if len(req.Parameters) > 0 {
+		names := make([]string, 0, len(req.Parameters))
+		for name := range req.Parameters {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		parameters := make(bson.A, 0, len(names))
+		for _, name := range names {
+			parameters = append(parameters, bson.M{
+				"$elemMatch": bson.M{"name": name, "value": req.Parameters[name]},
+			})
+		}
+		filter["parameters"] = bson.M{"$all": parameters}
+	}
+	return filter
+}
+
+// buildCreatedAtFilter bounds the creation time of the samples, nil when
+// neither bound is set.
+func buildCreatedAtFilter(from, to *time.Time) bson.M {
+	if from == nil && to == nil {
+		return nil
+	}
+	createdAt := bson.M{}
+	if from != nil {
+		createdAt["$gte"] = from
+	}
+	if to != nil {
+		createdAt["$lte"] = to
+	}
+	return createdAt
 }
@@ -2,18 +2,38 @@ package mongodb
 
 import (
 	"context"
+	"slices"
 
 	"github.com/carousell/ct-go/pkg/container"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"github.com/spf13/cast"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ISampleResponseCollection interface {
 	CreateSampleResponse(ctx context.Context, sampleResponse *entity.SampleResponse) error
+	CountSampleResponsesByApiId(ctx context.Context, apiId primitive.ObjectID) (int64, error)
+	GetStatusCodesByApiId(ctx context.Context, apiId primitive.ObjectID) ([]int, error)
 	GetSampleResponseByApiId(ctx context.Context, req *entity.GetSampleResponseByApiIdRequest) ([]*entity.SampleResponse, error)
+	GetSampleResponsesByCorrelationIds(
+		ctx context.Context,
+		apiId primitive.ObjectID,
+		correlationIds []string,
+	) ([]*entity.SampleResponse, error)
+	IterateSampleResponseByApiId(
+		ctx context.Context,
+		req *entity.GetSampleResponseByApiIdRequest,
+		fn func(sampleResponse *entity.SampleResponse) error,
+	) error
+	WatchSampleResponses(
+		ctx context.Context,
+		resumeToken bson.Raw,
+		fn func(event *mongodbutils.ChangeEvent[*entity.SampleResponse]) error,
+	) error
 }
 
 type SampleResponseCollection struct {
@@ -34,15 +54,77 @@ func (s *SampleResponseCollection) CreateSampleResponse(ctx context.Context, sam
 }
 
 func (s *SampleResponseCollection) GetSampleResponseByApiId(ctx context.Context, req *entity.GetSampleResponseByApiIdRequest) ([]*entity.SampleResponse, error) {
+	filter := buildSampleResponseFilter(req)
+	sort := bson.D{{Key: "created_at", Value: -1}}
+	return s.GetByBatch(ctx, filter, sort, req.Limit, req.Offset)
+}
+
+func (s *SampleResponseCollection) GetSampleResponsesByCorrelationIds(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	correlationIds []string,
+) ([]*entity.SampleResponse, error) {
+	filter := container.Map{
+		"api_id":         apiId,
+		"correlation_id": bson.M{"$in": correlationIds},
+	}
+	return s.GetByBatch(ctx, filter, nil, 0, 0)
+}
+
+// IterateSampleResponseByApiId streams the samples of req.ApiId in insertion order,
+// req.Limit and req.Offset are ignored.
+func (s *SampleResponseCollection) IterateSampleResponseByApiId(
+	ctx context.Context,
+	req *entity.GetSampleResponseByApiIdRequest,
+	fn func(sampleResponse *entity.SampleResponse) error,
+) error {
+	filter := buildSampleResponseFilter(req)
+	sort := bson.D{{Key: "_id", Value: 1}}
+	return s.Iterate(ctx, filter, sort, fn)
+}
+
+func (s *SampleResponseCollection) WatchSampleResponses(
+	ctx context.Context,
+	resumeToken bson.Raw,
+	fn func(event *mongodbutils.ChangeEvent[*entity.SampleResponse]) error,
+) error {
+	return s.WatchInserts(ctx, resumeToken, fn)
+}
+
+func (s *SampleResponseCollection) CountSampleResponsesByApiId(ctx context.Context, apiId primitive.ObjectID) (int64, error) {
+	filter := container.Map{
+		"api_id":     apiId,
+		"deleted_at": bson.M{"$exists": false},
+	}
+	return s.CountByFilter(ctx, filter)
+}
+
+// GetStatusCodesByApiId returns the distinct http status codes seen for the api, in ascending order.
+func (s *SampleResponseCollection) GetStatusCodesByApiId(ctx context.Context, apiId primitive.ObjectID) ([]int, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	values, err := s.Distinct(ctx, "http_status_code", filter)
+	if err != nil {
+		return nil, err
+	}
+	statusCodes := make([]int, 0, len(values))
+	for _, value := range values {
+		statusCodes = append(statusCodes, cast.ToInt(value))
+	}
+	slices.Sort(statusCodes)
+	return statusCodes, nil
+}
+
+func buildSampleResponseFilter(req *entity.GetSampleResponseByApiIdRequest) container.Map {
 	filter := container.Map{
 		"api_id": req.ApiId,
 	}
-	if req.From != nil {
-		filter["created_at"] = bson.M{"$gte": req.From}
+	if createdAt := buildCreatedAtFilter(req.From, req.To); createdAt != nil {
+		filter["created_at"] = createdAt
 	}
-	if req.To != nil {
-		filter["created_at"] = bson.M{"$lte": req.To}
+	if req.HttpStatusCode != 0 {
+		filter["http_status_code"] = req.HttpStatusCode
 	}
-	sort := bson.D{{Key: "created_at", Value: -1}}
-	return s.GetByBatch(ctx, filter, sort, req.Limit, req.Offset)
+	return filter
 }
@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ISchemaOverrideCollection interface {
+	GetSchemaOverrideByApiId(ctx context.Context, apiId primitive.ObjectID) (*entity.SchemaOverride, error)
+	SaveSchemaOverride(ctx context.Context, schemaOverride *entity.SchemaOverride) error
+}
+
+type SchemaOverrideCollection struct {
+	mongodbutils.BaseCollection[entity.SchemaOverride, *entity.SchemaOverride]
+}
+
+var _ ISchemaOverrideCollection = (*SchemaOverrideCollection)(nil)
+
+func NewSchemaOverrideCollection(db *mongo.Database) *SchemaOverrideCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.SchemaOverride](db, constants.SchemaOverridesCollection)
+	return &SchemaOverrideCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+func (s *SchemaOverrideCollection) GetSchemaOverrideByApiId(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+) (*entity.SchemaOverride, error) {
+	filter := container.Map{
+		"api_id": apiId,
+	}
+	return s.Get(ctx, filter)
+}
+
+// SaveSchemaOverride replaces the override of the api.
+func (s *SchemaOverrideCollection) SaveSchemaOverride(ctx context.Context, schemaOverride *entity.SchemaOverride) error {
+	filter := container.Map{
+		"api_id": schemaOverride.ApiId,
+	}
+	_, _, err := s.Upsert(ctx, filter, schemaOverride)
+	return err
+}
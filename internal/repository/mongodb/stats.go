@@ -0,0 +1,100 @@
+package mongodb
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IStatsCollection interface {
+	// RecordTraffic counts a call of the api into the stats of the hour it was
+	// seen in.
+	RecordTraffic(ctx context.Context, apiId primitive.ObjectID, record *entity.TrafficRecord) error
+	// GetApiStatsByApiId returns the hourly stats of the api from from to to,
+	// the oldest first.
+	GetApiStatsByApiId(ctx context.Context, apiId primitive.ObjectID, from, to time.Time) ([]*entity.ApiStats, error)
+}
+
+type StatsCollection struct {
+	mongodbutils.BaseCollection[entity.ApiStats, *entity.ApiStats]
+}
+
+var _ IStatsCollection = (*StatsCollection)(nil)
+
+func NewStatsCollection(db *mongo.Database) *StatsCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.ApiStats](db, constants.StatsCollection)
+	return &StatsCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+// EnsureIndexes keeps a single stats document per api and hour, concurrent
+// upserts of a new hour would otherwise both insert.
+func (s *StatsCollection) EnsureIndexes(ctx context.Context) error {
+	return s.EnsureUniqueIndex(ctx, bson.D{
+		{Key: "api_id", Value: 1},
+		{Key: "hour", Value: 1},
+	})
+}
+
+func (s *StatsCollection) RecordTraffic(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	record *entity.TrafficRecord,
+) error {
+	filter := container.Map{
+		"api_id": apiId,
+		"hour":   record.SeenAt.UTC().Truncate(time.Hour),
+	}
+	update := primitive.M{
+		"$inc": primitive.M{
+			"count": 1,
+			"status_codes." + strconv.Itoa(record.StatusCode):           1,
+			"latency_buckets." + entity.LatencyBucket(record.LatencyMs): 1,
+			"latency_ms_sum":          record.LatencyMs,
+			"upstream_latency_ms_sum": record.UpstreamLatencyMs,
+			"proxy_latency_ms_sum":    record.ProxyLatencyMs,
+			"request_size_sum":        record.RequestSize,
+			"response_size_sum":       record.ResponseSize,
+		},
+		"$set": primitive.M{
+			"updated_at": time.Now(),
+		},
+		"$max": primitive.M{
+			"latency_ms_max":    record.LatencyMs,
+			"request_size_max":  record.RequestSize,
+			"response_size_max": record.ResponseSize,
+			"last_seen_at":      record.SeenAt,
+		},
+		"$min": primitive.M{"first_seen_at": record.SeenAt},
+		"$setOnInsert": primitive.M{
+			"created_at": time.Now(),
+		},
+	}
+	_, err := s.UpsertRaw(ctx, filter, update)
+	return err
+}
+
+func (s *StatsCollection) GetApiStatsByApiId(
+	ctx context.Context,
+	apiId primitive.ObjectID,
+	from, to time.Time,
+) ([]*entity.ApiStats, error) {
+	filter := container.Map{
+		"api_id": apiId,
+		"hour": bson.M{
+			"$gte": from.UTC().Truncate(time.Hour),
+			"$lte": to,
+		},
+	}
+	sort := bson.D{{Key: "hour", Value: 1}}
+	return s.GetByBatch(ctx, filter, sort, 0, 0)
+}
@@ -3,13 +3,16 @@ package mongodb
 import (
 	"context"
 
+	"github.com/carousell/ct-go/pkg/container"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
 	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type ITypeCollection interface{}
+type ITypeCollection interface {
+	GetTypeByName(ctx context.Context, name string) (*entity.Type, error)
+}
 
 type TypeCollection struct {
 	mongodbutils.BaseCollection[entity.Type, *entity.Type]
@@ -27,3 +30,10 @@ func NewTypeCollection(db *mongo.Database) *TypeCollection {
 func (c *TypeCollection) CreateType(ctx context.Context, t *entity.Type) error {
 	return c.Insert(ctx, t)
 }
+
+func (c *TypeCollection) GetTypeByName(ctx context.Context, name string) (*entity.Type, error) {
+	filter := container.Map{
+		"name": name,
+	}
+	return c.Get(ctx, filter)
+}
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IWatchCheckpointCollection interface {
+	GetWatchCheckpoint(ctx context.Context, name string) (*entity.WatchCheckpoint, error)
+	SaveWatchCheckpoint(ctx context.Context, name string, resumeToken bson.Raw, watchedSince *time.Time) error
+}
+
+type WatchCheckpointCollection struct {
+	mongodbutils.BaseCollection[entity.WatchCheckpoint, *entity.WatchCheckpoint]
+}
+
+var _ IWatchCheckpointCollection = (*WatchCheckpointCollection)(nil)
+
+func NewWatchCheckpointCollection(db *mongo.Database) *WatchCheckpointCollection {
+	baseCollection := mongodbutils.NewBaseCollection[entity.WatchCheckpoint](db, constants.WatchCheckpointsCollection)
+	return &WatchCheckpointCollection{
+		BaseCollection: *baseCollection,
+	}
+}
+
+func (w *WatchCheckpointCollection) GetWatchCheckpoint(ctx context.Context, name string) (*entity.WatchCheckpoint, error) {
+	filter := container.Map{
+		"name": name,
+	}
+	return w.Get(ctx, filter)
+}
+
+func (w *WatchCheckpointCollection) SaveWatchCheckpoint(
+	ctx context.Context,
+	name string,
+	resumeToken bson.Raw,
+	watchedSince *time.Time,
+) error {
+	filter := container.Map{
+		"name": name,
+	}
+	checkpoint := &entity.WatchCheckpoint{
+		Name:         name,
+		ResumeToken:  resumeToken,
+		WatchedSince: watchedSince,
+	}
+	_, _, err := w.Upsert(ctx, filter, checkpoint)
+	return err
+}
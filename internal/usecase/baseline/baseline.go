@@ -0,0 +1,207 @@
+package baseline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+)
+
+type IBaselineUC interface {
+	// ImportOpenAPI stores the operations of the OpenAPI document at path as
+	// the baselines of their apis, creating the apis never observed, and
+	// compares them with the apis observed on the servers of the document.
+	ImportOpenAPI(ctx context.Context, path string) (*entity.ImportOpenAPIResponse, error)
+}
+
+type baselineUC struct {
+	conf    *config.Config
+	storage mongodb.MongoStorage
+}
+
+func NewBaselineUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+) IBaselineUC {
+	return &baselineUC{
+		conf:    conf,
+		storage: storage,
+	}
+}
+
+func (uc *baselineUC) ImportOpenAPI(ctx context.Context, path string) (*entity.ImportOpenAPIResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	document, err := openapiutils.ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	document, err = openapiutils.ResolveRefs(document)
+	if err != nil {
+		return nil, err
+	}
+	servers := parseServers(document)
+	operations := parseOperations(document, servers)
+	apis, observedApis, err := uc.indexApis(ctx, servers)
+	if err != nil {
+		return nil, err
+	}
+	resp := &entity.ImportOpenAPIResponse{
+		Source:     path,
+		Operations: len(operations),
+	}
+	for _, operation := range operations {
+		key := endpointKey(operation.method, operation.path)
+		api, ok := apis[key]
+		if ok {
+			resp.LinkedApis++
+		} else {
+			api = operation.newApi(servers)
+			if err := uc.storage.CreateApi(ctx, api); err != nil {
+				return nil, err
+			}
+			apis[key] = api
+			resp.CreatedApis++
+		}
+		operation.apiId = api.Id
+		if err := uc.storage.SaveBaseline(ctx, operation.newBaseline(path)); err != nil {
+			return nil, err
+		}
+	}
+	resp.Comparison, err = uc.compare(ctx, operations, observedApis)
+	if err != nil {
+		return nil, err
+	}
+	logctx.Infow(ctx, "imported openapi document",
+		"source", path,
+		"operations", resp.Operations,
+		"created_apis", resp.CreatedApis,
+		"linked_apis", resp.LinkedApis,
+		"undocumented_endpoints", len(resp.Comparison.UndocumentedEndpoints),
+		"never_seen_operations", len(resp.Comparison.NeverSeenOperations),
+		"undocumented_fields", len(resp.Comparison.UndocumentedFields),
+	)
+	return resp, nil
+}
+
+// indexApis returns every api by endpoint key, an observed api winning over a
+// declared only one, and the apis observed on the hosts of servers, or on any
+// host when the document has no absolute server.
+func (uc *baselineUC) indexApis(
+	ctx context.Context,
+	servers []*server,
+) (map[string]*entity.Api, []*entity.Api, error) {
+	hosts := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		if server.host != "" {
+			hosts[server.host] = true
+		}
+	}
+	apis := make(map[string]*entity.Api)
+	observedApis := make([]*entity.Api, 0)
+	err := uc.storage.IterateApis(ctx, func(api *entity.Api) error {
+		key := endpointKey(api.Method, api.Path)
+		observed := isObserved(api)
+		if indexed, ok := apis[key]; !ok || (observed && !isObserved(indexed)) {
+			apis[key] = api
+		}
+		if observed && (len(hosts) == 0 || hosts[api.Host]) {
+			observedApis = append(observedApis, api)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return apis, observedApis, nil
+}
+
+func (uc *baselineUC) compare(
+	ctx context.Context,
+	operations []*operation,
+	observedApis []*entity.Api,
+) (*entity.BaselineComparison, error) {
+	comparison := &entity.BaselineComparison{
+		UndocumentedEndpoints: make([]*entity.Endpoint, 0),
+		NeverSeenOperations:   make([]*entity.Endpoint, 0),
+		UndocumentedFields:    make([]*entity.UndocumentedField, 0),
+	}
+	observedByKey := make(map[string]*entity.Api, len(observedApis))
+	for _, api := range observedApis {
+		observedByKey[endpointKey(api.Method, api.Path)] = api
+	}
+	declared := make(map[string]bool, len(operations))
+	for _, operation := range operations {
+		key := endpointKey(operation.method, operation.path)
+		declared[key] = true
+		api, ok := observedByKey[key]
+		if !ok {
+			comparison.NeverSeenOperations = append(comparison.NeverSeenOperations, &entity.Endpoint{
+				ApiId:  operation.apiId,
+				Method: operation.method,
+				Path:   operation.path,
+			})
+			continue
+		}
+		fields, err := uc.compareFields(ctx, operation, api)
+		if err != nil {
+			return nil, err
+		}
+		comparison.UndocumentedFields = append(comparison.UndocumentedFields, fields...)
+	}
+	for _, api := range observedApis {
+		if !declared[endpointKey(api.Method, api.Path)] {
+			comparison.UndocumentedEndpoints = append(comparison.UndocumentedEndpoints, newEndpoint(api))
+		}
+	}
+	sort.SliceStable(comparison.UndocumentedEndpoints, func(i, j int) bool {
+		return comparison.UndocumentedEndpoints[i].Path < comparison.UndocumentedEndpoints[j].Path
+	})
+	return comparison, nil
+}
+
+// compareFields returns the fields of the structures of the observed api the
+// operation does not declare.
+func (uc *baselineUC) compareFields(
+	ctx context.Context,
+	operation *operation,
+	api *entity.Api,
+) ([]*entity.UndocumentedField, error) {
+	requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := newEndpoint(api)
+	fields := make([]*entity.UndocumentedField, 0)
+	add := func(target string, names []string) {
+		for _, name := range names {
+			fields = append(fields, &entity.UndocumentedField{
+				Endpoint: *endpoint,
+				Target:   target,
+				Field:    name,
+			})
+		}
+	}
+	if requestStructure != nil {
+		add(constants.DriftTargetParameter, undocumentedParameters(operation.parameters, requestStructure.Parameters))
+		add(constants.TypeRequest, undocumentedFields(
+			[]map[string]any{operation.requestBodySchema}, requestStructure.Schema()))
+	}
+	if responseStructure != nil {
+		add(constants.TypeResponse, undocumentedFields(operation.responseSchemas(), responseStructure.Schema()))
+	}
+	return fields, nil
+}
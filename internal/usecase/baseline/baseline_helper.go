@@ -0,0 +1,406 @@
+package baseline
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/spf13/cast"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// httpMethods are the operations of a path item, in the order they are imported.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var placeholderRegexp = regexp.MustCompile(`\{[^{}/]*\}`)
+
+// server is a server of a document, its variables replaced by their default.
+type server struct {
+	host     string
+	basePath string
+}
+
+// operation is an operation of a document, its references resolved.
+type operation struct {
+	apiId  primitive.ObjectID
+	method string
+	// path is the declared path, after the base path of the first server
+	path string
+	// apiPath is path with the placeholders ingestion recognizes, so the
+	// calls of an operation never observed are recorded under its api
+	apiPath             string
+	operationId         string
+	summary             string
+	description         string
+	tags                []string
+	parameters          []*entity.Parameter
+	requestBodySchema   map[string]any
+	responseBodySchemas map[string]map[string]any
+}
+
+func parseServers(document map[string]any) []*server {
+	servers := make([]*server, 0)
+	items, _ := toSlice(document["servers"])
+	for _, item := range items {
+		m, ok := toMap(item)
+		if !ok {
+			continue
+		}
+		rawUrl := cast.ToString(m["url"])
+		variables, _ := toMap(m["variables"])
+		for name, variable := range variables {
+			variableMap, _ := toMap(variable)
+			rawUrl = strings.ReplaceAll(rawUrl, "{"+name+"}", cast.ToString(variableMap["default"]))
+		}
+		parsedUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			continue
+		}
+		servers = append(servers, &server{
+			host:     parsedUrl.Hostname(),
+			basePath: strings.TrimSuffix(parsedUrl.Path, "/"),
+		})
+	}
+	return servers
+}
+
+// parseOperations returns the operations of a document resolved with
+// openapiutils.ResolveRefs, ordered by path and method.
+func parseOperations(document map[string]any, servers []*server) []*operation {
+	basePath := ""
+	if len(servers) > 0 {
+		basePath = servers[0].basePath
+	}
+	operations := make([]*operation, 0)
+	paths, _ := toMap(document["paths"])
+	for _, path := range mapsutils.SortedKeys(paths) {
+		pathItem, ok := toMap(paths[path])
+		if !ok {
+			continue
+		}
+		for _, method := range httpMethods {
+			operationObject, ok := toMap(pathItem[method])
+			if !ok {
+				continue
+			}
+			parameterObjects := mergeParameters(pathItem["parameters"], operationObject["parameters"])
+			parameters := make([]*entity.Parameter, 0, len(parameterObjects))
+			for _, parameterObject := range parameterObjects {
+				parameters = append(parameters, newParameter(parameterObject))
+			}
+			requestBody, _ := toMap(operationObject["requestBody"])
+			operations = append(operations, &operation{
+				method:              strings.ToUpper(method),
+				path:                basePath + path,
+				apiPath:             basePath + apiPath(path, parameterObjects),
+				operationId:         cast.ToString(operationObject["operationId"]),
+				summary:             cast.ToString(operationObject["summary"]),
+				description:         cast.ToString(operationObject["description"]),
+				tags:                toStrings(operationObject["tags"]),
+				parameters:          parameters,
+				requestBodySchema:   jsonSchema(requestBody["content"]),
+				responseBodySchemas: responseBodySchemas(operationObject["responses"]),
+			})
+		}
+	}
+	return operations
+}
+
+// mergeParameters returns the parameters of a path item and of one of its
+// operations, the operation overriding a path item parameter of the same name
+// and location.
+func mergeParameters(pathItemParameters, operationParameters any) []map[string]any {
+	merged := make([]map[string]any, 0)
+	indexes := make(map[string]int)
+	for _, parameters := range []any{pathItemParameters, operationParameters} {
+		items, _ := toSlice(parameters)
+		for _, item := range items {
+			parameter, ok := toMap(item)
+			if !ok {
+				continue
+			}
+			key := cast.ToString(parameter["in"]) + ":" + cast.ToString(parameter["name"])
+			if index, ok := indexes[key]; ok {
+				merged[index] = parameter
+				continue
+			}
+			indexes[key] = len(merged)
+			merged = append(merged, parameter)
+		}
+	}
+	return merged
+}
+
+func newParameter(parameterObject map[string]any) *entity.Parameter {
+	schema, _ := toMap(parameterObject["schema"])
+	in := cast.ToString(parameterObject["in"])
+	return &entity.Parameter{
+		Name:     cast.ToString(parameterObject["name"]),
+		Type:     cast.ToString(schema["type"]),
+		In:       in,
+		Required: in == "path" || cast.ToBool(parameterObject["required"]),
+	}
+}
+
+// apiPath names the placeholders of path the way ingestion does, {uuid} for a
+// uuid parameter and {id} for an integer one, the others are kept.
+func apiPath(path string, parameterObjects []map[string]any) string {
+	schemas := make(map[string]map[string]any)
+	for _, parameterObject := range parameterObjects {
+		if cast.ToString(parameterObject["in"]) != "path" {
+			continue
+		}
+		schema, _ := toMap(parameterObject["schema"])
+		schemas[cast.ToString(parameterObject["name"])] = schema
+	}
+	return placeholderRegexp.ReplaceAllStringFunc(path, func(placeholder string) string {
+		schema := schemas[strings.Trim(placeholder, "{}")]
+		switch {
+		case cast.ToString(schema["format"]) == "uuid":
+			return "{uuid}"
+		case cast.ToString(schema["type"]) == "integer":
+			return "{id}"
+		}
+		return placeholder
+	})
+}
+
+// jsonSchema returns the schema of the JSON media type of a content object.
+func jsonSchema(content any) map[string]any {
+	mediaTypes, ok := toMap(content)
+	if !ok {
+		return nil
+	}
+	mediaType, ok := toMap(mediaTypes["application/json"])
+	if !ok {
+		for _, name := range mapsutils.SortedKeys(mediaTypes) {
+			if strings.HasSuffix(name, "json") {
+				mediaType, _ = toMap(mediaTypes[name])
+				break
+			}
+		}
+	}
+	schema, _ := toMap(mediaType["schema"])
+	return schema
+}
+
+func responseBodySchemas(responses any) map[string]map[string]any {
+	responseObjects, _ := toMap(responses)
+	schemas := make(map[string]map[string]any)
+	for statusCode, response := range responseObjects {
+		responseObject, _ := toMap(response)
+		if schema := jsonSchema(responseObject["content"]); len(schema) > 0 {
+			schemas[statusCode] = schema
+		}
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+	return schemas
+}
+
+func (o *operation) newApi(servers []*server) *entity.Api {
+	api := &entity.Api{
+		Title:       o.summary,
+		Path:        o.apiPath,
+		Method:      o.method,
+		Description: o.description,
+		Tags:        o.tags,
+	}
+	if api.Title == "" {
+		api.Title = o.operationId
+	}
+	if len(servers) > 0 {
+		api.Host = servers[0].host
+	}
+	return api
+}
+
+func (o *operation) newBaseline(source string) *entity.Baseline {
+	return &entity.Baseline{
+		ApiId:               o.apiId,
+		Source:              source,
+		OperationId:         o.operationId,
+		Parameters:          o.parameters,
+		RequestBodySchema:   o.requestBodySchema,
+		ResponseBodySchemas: o.responseBodySchemas,
+	}
+}
+
+// responseSchemas returns the declared response body schemas, ordered by
+// status code.
+func (o *operation) responseSchemas() []map[string]any {
+	schemas := make([]map[string]any, 0, len(o.responseBodySchemas))
+	for _, statusCode := range mapsutils.SortedKeys(o.responseBodySchemas) {
+		schemas = append(schemas, o.responseBodySchemas[statusCode])
+	}
+	return schemas
+}
+
+// endpointKey identifies the endpoint of a method and a path whatever the names
+// of its placeholders, so /v1/ads/{ad_id} and /v1/ads/{id} are the same.
+func endpointKey(method, path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToUpper(method) + " " + placeholderRegexp.ReplaceAllString(path, "{}")
+}
+
+// isObserved reports whether calls of the api were ingested, the apis created
+// by an import are not until their first call.
+func isObserved(api *entity.Api) bool {
+	return api.LastSeenAt != nil || api.StructuredAt != nil || api.LatestBuildStructure != nil
+}
+
+func newEndpoint(api *entity.Api) *entity.Endpoint {
+	return &entity.Endpoint{
+		ApiId:  api.Id,
+		Method: api.Method,
+		Path:   api.Path,
+	}
+}
+
+// undocumentedParameters returns the names of the observed query parameters
+// which are not declared.
+func undocumentedParameters(declared []*entity.Parameter, observed []*entity.Parameter) []string {
+	declaredNames := make(map[string]bool, len(declared))
+	for _, parameter := range declared {
+		if parameter.In == constants.ParameterInQuery {
+			declaredNames[parameter.Name] = true
+		}
+	}
+	names := make(map[string]bool)
+	for _, parameter := range observed {
+		if !declaredNames[parameter.Name] {
+			names[parameter.Name] = true
+		}
+	}
+	return mapsutils.SortedKeys(names)
+}
+
+// undocumentedFields returns the fields of the observed schema found in none
+// of the declared schemas, a field whose parent is undocumented is left out.
+func undocumentedFields(declared []map[string]any, observed map[string]any) []string {
+	if len(observed) == 0 {
+		return nil
+	}
+	declaredFields := make(map[string]bool)
+	openFields := make(map[string]bool)
+	for _, schema := range declared {
+		if len(schema) > 0 {
+			collectFields(schema, "$", declaredFields, openFields)
+		}
+	}
+	observedFields := make(map[string]bool)
+	collectFields(observed, "$", observedFields, make(map[string]bool))
+	isDocumented := func(field string) bool {
+		if declaredFields[field] {
+			return true
+		}
+		for parent := parentField(field); parent != ""; parent = parentField(parent) {
+			if openFields[parent] {
+				return true
+			}
+		}
+		return false
+	}
+	fields := make([]string, 0)
+	for _, field := range mapsutils.SortedKeys(observedFields) {
+		if isDocumented(field) {
+			continue
+		}
+		if parent := parentField(field); parent != "" && !isDocumented(parent) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// collectFields adds the paths of the fields schema describes, rooted at field,
+// to fields, and the ones accepting undeclared members to openFields.
+func collectFields(v any, field string, fields, openFields map[string]bool) {
+	schema, ok := toMap(v)
+	if !ok {
+		return
+	}
+	fields[field] = true
+	if _, ok := schema[openapiutils.RecursiveRefKey]; ok {
+		openFields[field] = true
+		return
+	}
+	isComposed := false
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		items, _ := toSlice(schema[keyword])
+		for _, item := range items {
+			isComposed = true
+			collectFields(item, field, fields, openFields)
+		}
+	}
+	properties, hasProperties := toMap(schema["properties"])
+	for key, property := range properties {
+		collectFields(property, field+"."+key, fields, openFields)
+	}
+	items, hasItems := toMap(schema["items"])
+	if hasItems {
+		collectFields(items, field+"[*]", fields, openFields)
+	}
+	switch additionalProperties := schema["additionalProperties"].(type) {
+	case bool:
+		if additionalProperties {
+			openFields[field] = true
+		}
+	case nil:
+	default:
+		openFields[field] = true
+	}
+	schemaType := cast.ToString(schema["type"])
+	if !hasProperties && !hasItems && !isComposed && (schemaType == "" || schemaType == "object") {
+		openFields[field] = true
+	}
+}
+
+// parentField returns the path of the object or array holding field, "" for
+// the root.
+func parentField(field string) string {
+	if strings.HasSuffix(field, "[*]") {
+		return strings.TrimSuffix(field, "[*]")
+	}
+	index := strings.LastIndex(field, ".")
+	if index < 0 {
+		return ""
+	}
+	return field[:index]
+}
+
+// toMap accepts the schemas of the documents and the ones decoded from mongo.
+func toMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case primitive.M:
+		return m, true
+	}
+	return nil, false
+}
+
+func toSlice(v any) ([]any, bool) {
+	switch s := v.(type) {
+	case []any:
+		return s, true
+	case primitive.A:
+		return s, true
+	}
+	return nil, false
+}
+
+func toStrings(v any) []string {
+	if items, ok := v.(primitive.A); ok {
+		return cast.ToStringSlice([]any(items))
+	}
+	return cast.ToStringSlice(v)
+}
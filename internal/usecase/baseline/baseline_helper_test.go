@@ -0,0 +1,214 @@
+package baseline
+
+import (
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseOperations(t *testing.T) {
+	t.Parallel()
+	document, err := openapiutils.ParseDocument([]byte(`
+openapi: 3.0.3
+servers:
+  - url: https://{env}.chotot.org/v1/
+    variables:
+      env:
+        default: gateway
+paths:
+  /ads/{ad_id}/images/{image_id}:
+    parameters:
+      - name: ad_id
+        in: path
+        schema:
+          type: integer
+      - name: lang
+        in: query
+        schema:
+          type: string
+    get:
+      operationId: getAdImage
+      tags: [ads]
+      parameters:
+        - name: image_id
+          in: path
+          schema:
+            type: string
+            format: uuid
+        - name: lang
+          in: query
+          required: true
+          schema:
+            type: string
+      responses:
+        200:
+          description: ok
+          content:
+            application/vnd.ct+json:
+              schema:
+                type: object
+        404:
+          description: not found
+  /ads:
+    post:
+      summary: Create an ad
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        201:
+          description: created
+`))
+	require.NoError(t, err)
+	servers := parseServers(document)
+	require.Equal(t, []*server{{host: "gateway.chotot.org", basePath: "/v1"}}, servers)
+	require.Equal(t, []*operation{
+		{
+			method:            "POST",
+			path:              "/v1/ads",
+			apiPath:           "/v1/ads",
+			summary:           "Create an ad",
+			parameters:        []*entity.Parameter{},
+			requestBodySchema: map[string]any{"type": "object"},
+		},
+		{
+			method:      "GET",
+			path:        "/v1/ads/{ad_id}/images/{image_id}",
+			apiPath:     "/v1/ads/{id}/images/{uuid}",
+			operationId: "getAdImage",
+			tags:        []string{"ads"},
+			parameters: []*entity.Parameter{
+				{Name: "ad_id", Type: "integer", In: "path", Required: true},
+				{Name: "lang", Type: "string", In: "query", Required: true},
+				{Name: "image_id", Type: "string", In: "path", Required: true},
+			},
+			responseBodySchemas: map[string]map[string]any{
+				"200": {"type": "object"},
+			},
+		},
+	}, parseOperations(document, servers))
+}
+
+func TestEndpointKey(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, endpointKey("get", "/v1/ads/{ad_id}/"), endpointKey("GET", "/v1/ads/{id}"))
+	require.NotEqual(t, endpointKey("GET", "/v1/ads/{id}"), endpointKey("POST", "/v1/ads/{id}"))
+	require.NotEqual(t, endpointKey("GET", "/v1/ads/{id}"), endpointKey("GET", "/v1/ads/{id}/images"))
+	require.Equal(t, "GET /", endpointKey("GET", "/"))
+}
+
+func TestUndocumentedFields(t *testing.T) {
+	t.Parallel()
+	observed := primitive.M{
+		"type": "object",
+		"properties": primitive.M{
+			"ad_id": primitive.M{"type": "integer"},
+			"params": primitive.M{
+				"type":       "object",
+				"properties": primitive.M{"size": primitive.M{"type": "string"}},
+			},
+			"images": primitive.M{
+				"type": "array",
+				"items": primitive.M{
+					"type": "object",
+					"properties": primitive.M{
+						"url":   primitive.M{"type": "string"},
+						"width": primitive.M{"type": "integer"},
+					},
+				},
+			},
+			"seller": primitive.M{
+				"type":       "object",
+				"properties": primitive.M{"name": primitive.M{"type": "string"}},
+			},
+			"category": primitive.M{
+				"type":       "object",
+				"properties": primitive.M{"parent": primitive.M{"type": "object"}},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		declared   []map[string]any
+		observed   map[string]any
+		wantFields []string
+	}{
+		{
+			name: "Test UndocumentedFields - partly declared",
+			declared: []map[string]any{
+				{
+					"type": "object",
+					"properties": map[string]any{
+						"ad_id":  map[string]any{"type": "integer"},
+						"params": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+						"images": map[string]any{
+							"type": "array",
+							"items": map[string]any{
+								"allOf": []any{
+									map[string]any{"properties": map[string]any{"url": map[string]any{"type": "string"}}},
+								},
+							},
+						},
+						"category": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"parent": map[string]any{openapiutils.RecursiveRefKey: "#/components/schemas/Category"},
+							},
+						},
+					},
+				},
+				{
+					"type":       "object",
+					"properties": map[string]any{"error": map[string]any{"type": "string"}},
+				},
+			},
+			observed:   observed,
+			wantFields: []string{"$.images[*].width", "$.seller"},
+		},
+		{
+			name:       "Test UndocumentedFields - no declared body",
+			declared:   []map[string]any{nil},
+			observed:   observed,
+			wantFields: []string{"$"},
+		},
+		{
+			name:       "Test UndocumentedFields - free-form declared body",
+			declared:   []map[string]any{{"type": "object"}},
+			observed:   observed,
+			wantFields: []string{},
+		},
+		{
+			name:       "Test UndocumentedFields - no observed body",
+			declared:   []map[string]any{{"type": "object"}},
+			observed:   nil,
+			wantFields: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantFields, undocumentedFields(tt.declared, tt.observed))
+		})
+	}
+}
+
+func TestUndocumentedParameters(t *testing.T) {
+	t.Parallel()
+	declared := []*entity.Parameter{
+		{Name: "id", In: "path"},
+		{Name: "limit", In: "query"},
+	}
+	observed := []*entity.Parameter{
+		{Name: "limit", In: "query"},
+		{Name: "o", In: "query"},
+		{Name: "id", In: "query"},
+		{Name: "o", In: "query"},
+	}
+	require.Equal(t, []string{"id", "o"}, undocumentedParameters(declared, observed))
+}
@@ -3,30 +3,45 @@ package buildstructure
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/carousell/ct-go/pkg/workerpool"
 	"github.com/ct-logic-api-document/config"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
+	apperrors "github.com/ct-logic-api-document/internal/errors"
 	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 
 	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
 )
 
 const (
 	maxApisProccessing = 100
-	defaultLimit       = 20
 )
 
 type IBuildStructureUC interface {
 	BuildStructure(ctx context.Context) error
 	DoBuildStructure(ctx context.Context, api *entity.Api) error
+	MergeSamples(
+		ctx context.Context,
+		api *entity.Api,
+		sampleRequests []*entity.SampleRequest,
+		sampleResponses []*entity.SampleResponse,
+		advanceWatermark bool,
+	) error
+	// ApplySchemaOverride rewrites the overridden body schemas of api from its
+	// inferred ones, after its schema override changed.
+	ApplySchemaOverride(ctx context.Context, api *entity.Api) error
 }
 
 type buildStructureIC struct {
-	conf    *config.Config
-	storage mongodb.MongoStorage
+	conf     *config.Config
+	storage  mongodb.MongoStorage
+	redactor *redact.Redactor
 }
 
 func NewBuildStructureUC(
@@ -34,8 +49,9 @@ func NewBuildStructureUC(
 	storage mongodb.MongoStorage,
 ) IBuildStructureUC {
 	return &buildStructureIC{
-		conf:    conf,
-		storage: storage,
+		conf:     conf,
+		storage:  storage,
+		redactor: redact.New(conf.Samples.RedactKeys),
 	}
 }
 
@@ -44,22 +60,14 @@ func (f *buildStructureIC) BuildStructure(ctx context.Context) error {
 	defer func() {
 		pool.Close()
 	}()
-	offset := int64(0)
-	for {
-		apis, err := f.storage.GetApis(ctx, defaultLimit, offset)
-		if err != nil {
-			return err
-		}
-		if len(apis) == 0 {
-			break
-		}
-		for _, api := range apis {
-			api := api
-			pool.Run(func() error {
-				return f.DoBuildStructure(ctx, api)
-			})
-		}
-		offset += defaultLimit
+	err := f.storage.IterateApis(ctx, func(api *entity.Api) error {
+		pool.Run(func() error {
+			return f.DoBuildStructure(ctx, api)
+		})
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	if err := pool.Wait(); err != nil {
 		return err
@@ -71,6 +79,18 @@ func (f *buildStructureIC) DoBuildStructure(ctx context.Context, api *entity.Api
 	if api == nil || api.Id.IsZero() {
 		return nil
 	}
+	leaseName := constants.LeasePrefixBuildStructure + api.GetIdStr()
+	err := f.storage.WithLease(ctx, leaseName, f.conf.Lease.BuildStructureTTL, func(ctx context.Context) error {
+		return f.doBuildStructure(ctx, api)
+	})
+	if errors.Is(err, mongodbutils.ErrLeaseNotAcquired) {
+		logctx.Infof(ctx, "structure of api %s is being built by another worker, skipped", api.Path)
+		return nil
+	}
+	return err
+}
+
+func (f *buildStructureIC) doBuildStructure(ctx context.Context, api *entity.Api) error {
 	logctx.Infof(ctx, "build structure for api: %s", api.Path)
 	// handle build request structure
 	if err := f.buildRequestStructure(ctx, api); err != nil {
@@ -140,147 +160,350 @@ func (f *buildStructureIC) buildResponseStructure(ctx context.Context, api *enti
 func (f *buildStructureIC) buildRequestStructureBySampleRequest(ctx context.Context, api *entity.Api) error {
 	// handle build request structure
 	logctx.Info(ctx, "build request structure by sample request")
-	requestStructure, err := f.storage.GetRequestStructureByApiId(ctx, api.Id)
+	builder, err := f.newRequestStructureBuilder(ctx, api)
 	if err != nil {
 		return err
 	}
+	// load sample requests
+	getSampleRequestByApiIdRequest := &entity.GetSampleRequestByApiIdRequest{
+		ApiId: api.Id,
+	}
+	if api.LatestBuildStructure != nil {
+		getSampleRequestByApiIdRequest.From = api.LatestBuildStructure
+	}
+	err = f.storage.IterateSampleRequestByApiId(ctx, getSampleRequestByApiIdRequest, builder.merge)
+	if err != nil {
+		return err
+	}
+	// update request structure
+	return f.saveRequestStructure(ctx, builder)
+}
+
+func (f *buildStructureIC) buildResponseStructureBySampleResponse(ctx context.Context, api *entity.Api) error {
+	// handle build response structure
+	logctx.Info(ctx, "build response structure by response request")
+	builder, err := f.newResponseStructureBuilder(ctx, api)
+	if err != nil {
+		return err
+	}
+	// load sample responses
+	getSampleResponseByApiIdRequest := &entity.GetSampleResponseByApiIdRequest{
+		ApiId: api.Id,
+	}
+	if api.LatestBuildStructure != nil {
+		getSampleResponseByApiIdRequest.From = api.LatestBuildStructure
+	}
+	err = f.storage.IterateSampleResponseByApiId(ctx, getSampleResponseByApiIdRequest, builder.merge)
+	if err != nil {
+		return err
+	}
+	// update response structure
+	return f.saveResponseStructure(ctx, builder)
+}
+
+// MergeSamples merges the given samples into the stored structures of api, it
+// is the incremental counterpart of DoBuildStructure for samples already loaded.
+// With advanceWatermark the build watermark of api is advanced past the
+// samples so the cronjob does not merge them again, it is only safe when every
+// sample created since the watermark has been merged.
+func (f *buildStructureIC) MergeSamples(
+	ctx context.Context,
+	api *entity.Api,
+	sampleRequests []*entity.SampleRequest,
+	sampleResponses []*entity.SampleResponse,
+	advanceWatermark bool,
+) error {
+	if api == nil || api.Id.IsZero() {
+		return nil
+	}
+	leaseName := constants.LeasePrefixBuildStructure + api.GetIdStr()
+	return f.storage.WithLease(ctx, leaseName, f.conf.Lease.BuildStructureTTL, func(ctx context.Context) error {
+		if len(sampleRequests) > 0 {
+			builder, err := f.newRequestStructureBuilder(ctx, api)
+			if err != nil {
+				return err
+			}
+			for _, sampleRequest := range sampleRequests {
+				if err := builder.merge(sampleRequest); err != nil {
+					return err
+				}
+			}
+			if err := f.saveRequestStructure(ctx, builder); err != nil {
+				return err
+			}
+		}
+		if len(sampleResponses) > 0 {
+			builder, err := f.newResponseStructureBuilder(ctx, api)
+			if err != nil {
+				return err
+			}
+			for _, sampleResponse := range sampleResponses {
+				if err := builder.merge(sampleResponse); err != nil {
+					return err
+				}
+			}
+			if err := f.saveResponseStructure(ctx, builder); err != nil {
+				return err
+			}
+		}
+		if !advanceWatermark {
+			return nil
+		}
+		if mergedUntil := samplesMergedUntil(sampleRequests, sampleResponses); mergedUntil != nil {
+			return f.storage.AdvanceLatestBuildStructure(ctx, api.Id, *mergedUntil)
+		}
+		return nil
+	})
+}
+
+func (f *buildStructureIC) ApplySchemaOverride(ctx context.Context, api *entity.Api) error {
+	if api == nil || api.Id.IsZero() {
+		return nil
+	}
+	leaseName := constants.LeasePrefixBuildStructure + api.GetIdStr()
+	err := f.storage.WithLease(ctx, leaseName, f.conf.Lease.BuildStructureTTL, func(ctx context.Context) error {
+		requestBuilder, err := f.newRequestStructureBuilder(ctx, api)
+		if err != nil {
+			return err
+		}
+		if requestBuilder.structure != nil {
+			if err := f.saveRequestStructure(ctx, requestBuilder); err != nil {
+				return err
+			}
+		}
+		responseBuilder, err := f.newResponseStructureBuilder(ctx, api)
+		if err != nil {
+			return err
+		}
+		if responseBuilder.structure != nil {
+			if err := f.saveResponseStructure(ctx, responseBuilder); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, mongodbutils.ErrLeaseNotAcquired) {
+		return apperrors.Wrap(apperrors.KindConflict, err, "structures of the api are being built, retry later")
+	}
+	return err
+}
+
+// requestStructureBuilder accumulates sample requests into the request structure of an api.
+type requestStructureBuilder struct {
+	api            *entity.Api
+	structure      *entity.RequestStructure
+	schemaOverride *entity.SchemaOverride
+	redactor       *redact.Redactor
+	parameters     map[string]*entity.Parameter
+	bodySchema     map[string]any
+	security       *entity.SecurityUsage
+	examples       []*entity.Example
+}
+
+func (f *buildStructureIC) newRequestStructureBuilder(ctx context.Context, api *entity.Api) (*requestStructureBuilder, error) {
+	requestStructure, err := f.storage.GetRequestStructureByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	schemaOverride, err := f.storage.GetSchemaOverrideByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	builder := &requestStructureBuilder{
+		api:            api,
+		structure:      requestStructure,
+		schemaOverride: schemaOverride,
+		redactor:       f.redactor,
+		parameters:     make(map[string]*entity.Parameter),
+		bodySchema:     map[string]any{},
+		security:       &entity.SecurityUsage{},
+	}
 	// build parameter structure
-	currentParameters := make(map[string]*entity.Parameter)
 	if requestStructure != nil && len(requestStructure.Parameters) > 0 {
 		for _, parameter := range requestStructure.Parameters {
-			currentParameters[parameter.Name] = &entity.Parameter{
+			builder.parameters[parameter.Name] = &entity.Parameter{
 				Name:     parameter.Name,
 				Type:     parameter.Type,
 				In:       parameter.In,
 				Required: parameter.Required,
+				Examples: parameter.Examples,
 			}
 		}
 	}
 	// build body structure
-	currentBodySchema := map[string]any{}
 	if requestStructure != nil && requestStructure.BodySchema != nil {
-		currentBodySchema = requestStructure.BodySchema
+		builder.bodySchema = requestStructure.BodySchema
 	}
-	// load sample requests
-	offset := int64(0)
-	for {
-		getSampleRequestByApiIdRequest := &entity.GetSampleRequestByApiIdRequest{
-			ApiId:  api.Id,
-			Limit:  20,
-			Offset: offset,
-		}
-		if api.LatestBuildStructure != nil {
-			getSampleRequestByApiIdRequest.From = api.LatestBuildStructure
-		}
-		sampleRequests, err := f.storage.GetSampleRequestByApiId(ctx, getSampleRequestByApiIdRequest)
-		if err != nil {
-			return err
-		}
-		if len(sampleRequests) == 0 {
-			break
-		}
-		for _, sampleRequest := range sampleRequests {
-			// update parameter structure
-			if len(sampleRequest.Parameters) > 0 {
-				for _, parameter := range sampleRequest.Parameters {
-					if currentParameter, ok := currentParameters[parameter.Name]; !ok {
-						currentParameters[parameter.Name] = &entity.Parameter{
-							Name:     parameter.Name,
-							Type:     parameter.Type,
-							In:       parameter.In,
-							Required: parameter.Required,
-						}
-					} else {
-						if currentParameter.Type != parameter.Type {
-							currentParameter.Type = constants.ParameterTypeAny
-						}
-					}
+	if requestStructure != nil {
+		builder.examples = requestStructure.Examples
+	}
+	// build security usage
+	if requestStructure != nil && requestStructure.Security != nil {
+		builder.security = requestStructure.Security
+	}
+	return builder, nil
+}
+
+func (b *requestStructureBuilder) merge(sampleRequest *entity.SampleRequest) error {
+	// update parameter structure
+	if len(sampleRequest.Parameters) > 0 {
+		for _, parameter := range sampleRequest.Parameters {
+			currentParameter, ok := b.parameters[parameter.Name]
+			if !ok {
+				currentParameter = &entity.Parameter{
+					Name:     parameter.Name,
+					Type:     parameter.Type,
+					In:       parameter.In,
+					Required: parameter.Required,
 				}
-			}
-			// update body structure
-			if sampleRequest.Body != "" {
-				body := map[string]any{}
-				if err := json.Unmarshal([]byte(sampleRequest.Body), &body); err != nil {
-					body := []any{}
-					if err = json.Unmarshal([]byte(sampleRequest.Body), &body); err != nil {
-						return err
-					}
+				b.parameters[parameter.Name] = currentParameter
+			} else {
+				if currentParameter.Type != parameter.Type {
+					currentParameter.Type = constants.ParameterTypeAny
 				}
-				sampleBodySchema := generateSchema(body)
-				currentBodySchema = mergeObject(currentBodySchema, sampleBodySchema)
 			}
+			value := b.redactor.Parameter(parameter.Name, parameter.Value)
+			currentParameter.Examples = addParameterExample(currentParameter.Examples, value, constants.MaxParameterExamples)
 		}
-		offset += 20
 	}
-	// update request structure
-	currentParametersSlice := make([]*entity.Parameter, 0, len(currentParameters))
-	for _, parameter := range currentParameters {
-		currentParametersSlice = append(currentParametersSlice, parameter)
+	// update security usage, the credentials of the older samples are unknown
+	if sampleRequest.Credentials != nil {
+		b.security.Add(sampleRequest.Credentials)
 	}
-	if requestStructure == nil {
+	// update body structure
+	if sampleRequest.Body != "" {
+		body := map[string]any{}
+		if err := json.Unmarshal([]byte(sampleRequest.Body), &body); err != nil {
+			body := []any{}
+			if err = json.Unmarshal([]byte(sampleRequest.Body), &body); err != nil {
+				return err
+			}
+		}
+		sampleBodySchema := generateSchema(body)
+		b.bodySchema = mergeObject(b.bodySchema, sampleBodySchema)
+		example := newExample(b.redactor, sampleRequest.Id, sampleRequest.CreatedAt, sampleRequest.Body)
+		b.examples = selectExamples(b.examples, example, constants.MaxExamples)
+	}
+	return nil
+}
+
+func (f *buildStructureIC) saveRequestStructure(ctx context.Context, b *requestStructureBuilder) error {
+	parameters := make([]*entity.Parameter, 0, len(b.parameters))
+	for _, parameter := range b.parameters {
+		parameters = append(parameters, parameter)
+	}
+	effectiveBodySchema := applySchemaOverride(b.bodySchema, b.schemaOverride, constants.AnnotationTargetRequest)
+	if b.structure == nil {
 		requestStructure := &entity.RequestStructure{
-			ApiId:      api.Id,
-			Parameters: currentParametersSlice,
-			BodySchema: currentBodySchema,
+			ApiId:               b.api.Id,
+			Parameters:          parameters,
+			BodySchema:          b.bodySchema,
+			EffectiveBodySchema: effectiveBodySchema,
+			Security:            b.security,
+			Examples:            b.examples,
+		}
+		if err := f.storage.CreateRequestStructure(ctx, requestStructure); err != nil {
+			return err
+		}
+	} else {
+		err := f.storage.UpdateRequestStructure(ctx, b.structure.Id, b.structure.Version, parameters,
+			b.bodySchema, effectiveBodySchema, b.security, b.examples)
+		if err != nil {
+			return err
 		}
-		return f.storage.CreateRequestStructure(ctx, requestStructure)
 	}
-	return f.storage.UpdateRequestStructure(ctx, requestStructure.Id, currentParametersSlice, currentBodySchema)
+	return f.storage.UpdateApiStructuredAt(ctx, b.api.Id, time.Now().UTC())
 }
 
-func (f *buildStructureIC) buildResponseStructureBySampleResponse(ctx context.Context, api *entity.Api) error {
-	// handle build response structure
-	logctx.Info(ctx, "build response structure by response request")
+// responseStructureBuilder accumulates sample responses into the response structure of an api.
+type responseStructureBuilder struct {
+	api            *entity.Api
+	structure      *entity.ResponseStructure
+	schemaOverride *entity.SchemaOverride
+	redactor       *redact.Redactor
+	bodySchema     map[string]any
+	examples       map[string][]*entity.Example
+	fieldsSeen     map[string]time.Time
+}
+
+func (f *buildStructureIC) newResponseStructureBuilder(ctx context.Context, api *entity.Api) (*responseStructureBuilder, error) {
 	responseStructure, err := f.storage.GetResponseStructureByApiId(ctx, api.Id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	schemaOverride, err := f.storage.GetSchemaOverrideByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	builder := &responseStructureBuilder{
+		api:            api,
+		structure:      responseStructure,
+		schemaOverride: schemaOverride,
+		redactor:       f.redactor,
+		bodySchema:     map[string]any{},
+		examples:       map[string][]*entity.Example{},
+		fieldsSeen:     map[string]time.Time{},
 	}
 	// build body structure
-	currentBodySchema := map[string]any{}
 	if responseStructure != nil && responseStructure.BodySchema != nil {
-		currentBodySchema = responseStructure.BodySchema
+		builder.bodySchema = responseStructure.BodySchema
 	}
-	// load sample responses
-	offset := int64(0)
-	for {
-		getSampleResponseByApiIdRequest := &entity.GetSampleResponseByApiIdRequest{
-			ApiId:  api.Id,
-			Limit:  20,
-			Offset: offset,
-		}
-		if api.LatestBuildStructure != nil {
-			getSampleResponseByApiIdRequest.From = api.LatestBuildStructure
-		}
-		sampleResponses, err := f.storage.GetSampleResponseByApiId(ctx, getSampleResponseByApiIdRequest)
-		if err != nil {
-			return err
+	if responseStructure != nil && responseStructure.Examples != nil {
+		builder.examples = responseStructure.Examples
+	}
+	if responseStructure != nil {
+		builder.fieldsSeen = loadFieldsSeen(responseStructure)
+	}
+	return builder, nil
+}
+
+func (b *responseStructureBuilder) merge(sampleResponse *entity.SampleResponse) error {
+	// update body structure
+	if sampleResponse.Body != "" {
+		body := map[string]any{}
+		if err := json.Unmarshal([]byte(sampleResponse.Body), &body); err != nil {
+			body := []any{}
+			if err = json.Unmarshal([]byte(sampleResponse.Body), &body); err != nil {
+				return err
+			}
 		}
-		if len(sampleResponses) == 0 {
-			break
+		sampleBodySchema := generateSchema(body)
+		b.bodySchema = mergeObject(b.bodySchema, sampleBodySchema)
+		seenAt := time.Now().UTC()
+		if sampleResponse.CreatedAt != nil {
+			seenAt = *sampleResponse.CreatedAt
 		}
-		for _, sampleResponse := range sampleResponses {
-			// update body structure
-			if sampleResponse.Body != "" {
-				body := map[string]any{}
-				if err := json.Unmarshal([]byte(sampleResponse.Body), &body); err != nil {
-					body := []any{}
-					if err = json.Unmarshal([]byte(sampleResponse.Body), &body); err != nil {
-						return err
-					}
-				}
-				sampleBodySchema := generateSchema(body)
-				currentBodySchema = mergeObject(currentBodySchema, sampleBodySchema)
-			}
+		fields := map[string]bool{}
+		collectFields(body, "", fields)
+		markFieldsSeen(b.fieldsSeen, fields, seenAt)
+		if sampleResponse.HttpStatusCode != 0 {
+			statusCode := strconv.Itoa(sampleResponse.HttpStatusCode)
+			example := newExample(b.redactor, sampleResponse.Id, sampleResponse.CreatedAt, sampleResponse.Body)
+			b.examples[statusCode] = selectExamples(b.examples[statusCode], example, constants.MaxExamples)
 		}
-		offset += 20
 	}
-	// update response structure
-	if responseStructure == nil {
+	return nil
+}
+
+func (f *buildStructureIC) saveResponseStructure(ctx context.Context, b *responseStructureBuilder) error {
+	effectiveBodySchema := applySchemaOverride(b.bodySchema, b.schemaOverride, constants.AnnotationTargetResponse)
+	if b.structure == nil {
 		responseStructure := &entity.ResponseStructure{
-			ApiId:      api.Id,
-			BodySchema: currentBodySchema,
+			ApiId:               b.api.Id,
+			BodySchema:          b.bodySchema,
+			EffectiveBodySchema: effectiveBodySchema,
+			Examples:            b.examples,
+			FieldsSeen:          buildFieldsSeen(b.fieldsSeen),
+		}
+		if err := f.storage.CreateResponseStructure(ctx, responseStructure); err != nil {
+			return err
+		}
+	} else {
+		err := f.storage.UpdateResponseStructure(ctx, b.structure.Id, b.structure.Version,
+			b.bodySchema, effectiveBodySchema, b.examples, buildFieldsSeen(b.fieldsSeen))
+		if err != nil {
+			return err
 		}
-		return f.storage.CreateResponseStructure(ctx, responseStructure)
 	}
-	return f.storage.UpdateResponseStructure(ctx, responseStructure.Id, currentBodySchema)
+	return f.storage.UpdateApiStructuredAt(ctx, b.api.Id, time.Now().UTC())
 }
@@ -1,5 +1,20 @@
 package buildstructure
 
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/spf13/cast"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 func mergeObject(map1, map2 map[string]any) map[string]any {
 	merged := make(map[string]interface{})
 	merged["type"] = "object"
@@ -123,3 +138,322 @@ func inferType(value any) string {
 		return "null"
 	}
 }
+
+// applySchemaOverride returns a copy of the inferred bodySchema of target with
+// the rules of schemaOverride applied, or nil when none of them targets it.
+// The root is marked x-source inferred and every schema a rule touched
+// override, the schemas in between inherit the x-source of their parent.
+// Rules are applied by pointer, so a replaced subtree can be refined by the
+// rules of its children.
+func applySchemaOverride(
+	bodySchema map[string]any,
+	schemaOverride *entity.SchemaOverride,
+	target string,
+) map[string]any {
+	if schemaOverride == nil {
+		return nil
+	}
+	rules := make([]*entity.OverrideRule, 0)
+	for _, rule := range schemaOverride.Rules {
+		if rule.Target == target {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Pointer < rules[j].Pointer
+	})
+	effective, _ := openapiutils.CopySchema(bodySchema).(map[string]any)
+	if effective == nil {
+		effective = map[string]any{}
+	}
+	effective[constants.SchemaSourceKey] = constants.SchemaSourceInferred
+	for _, rule := range rules {
+		effective = applyOverrideRule(effective, rule)
+	}
+	return effective
+}
+
+// applyOverrideRule applies rule onto root and returns the new root, a rule
+// whose pointer no longer resolves is skipped.
+func applyOverrideRule(root map[string]any, rule *entity.OverrideRule) map[string]any {
+	parentPointer, name := openapiutils.SplitPointer(rule.Pointer)
+	if rule.Schema != nil {
+		replacement, _ := openapiutils.CopySchema(rule.Schema).(map[string]any)
+		replacement[constants.SchemaSourceKey] = constants.SchemaSourceOverride
+		if rule.Pointer == "" {
+			root = replacement
+		} else if parent, ok := openapiutils.ResolveSchema(root, parentPointer); ok {
+			parent[name] = replacement
+		}
+	}
+	if rule.Hidden {
+		if parent, ok := openapiutils.ResolveSchema(root, parentPointer); ok {
+			delete(parent, name)
+		}
+		setRequired(root, rule.Pointer, false)
+		return root
+	}
+	schema, ok := openapiutils.ResolveSchema(root, rule.Pointer)
+	if !ok {
+		return root
+	}
+	if rule.Type != "" {
+		schema["type"] = rule.Type
+		if rule.Type != "object" {
+			delete(schema, "properties")
+			delete(schema, "required")
+		}
+		if rule.Type != "array" {
+			delete(schema, "items")
+		}
+		schema[constants.SchemaSourceKey] = constants.SchemaSourceOverride
+	}
+	if rule.Deprecated != nil {
+		schema["deprecated"] = *rule.Deprecated
+		schema[constants.SchemaSourceKey] = constants.SchemaSourceOverride
+	}
+	if rule.Required != nil {
+		setRequired(root, rule.Pointer, *rule.Required)
+		schema[constants.SchemaSourceKey] = constants.SchemaSourceOverride
+	}
+	return root
+}
+
+// setRequired adds the property pointer refers to, e.g. /properties/price, to
+// the required ones of its object, or removes it.
+func setRequired(root map[string]any, pointer string, required bool) {
+	propertiesPointer, name := openapiutils.SplitPointer(pointer)
+	if !strings.HasSuffix(propertiesPointer, "/properties") {
+		return
+	}
+	object, ok := openapiutils.ResolveSchema(root, strings.TrimSuffix(propertiesPointer, "/properties"))
+	if !ok {
+		return
+	}
+	names := make([]string, 0)
+	for _, itemName := range cast.ToStringSlice(object["required"]) {
+		if itemName != name {
+			names = append(names, itemName)
+		}
+	}
+	if required {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		delete(object, "required")
+		return
+	}
+	sort.Strings(names)
+	object["required"] = names
+}
+
+// newExample returns the redacted body of a sample as an example, nil when the
+// body is empty, too large or not JSON.
+func newExample(
+	redactor *redact.Redactor,
+	sampleId primitive.ObjectID,
+	createdAt *time.Time,
+	body string,
+) *entity.Example {
+	if body == "" || len(body) > constants.MaxExampleBodySize {
+		return nil
+	}
+	value := redactor.Body(body)
+	var doc any
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return nil
+	}
+	fields := map[string]bool{}
+	collectFields(doc, "", fields)
+	return &entity.Example{
+		SampleId:  sampleId,
+		CreatedAt: createdAt,
+		Value:     value,
+		Fields:    sortedFields(fields),
+	}
+}
+
+// collectFields adds the paths of the fields of doc to fields, the items of an
+// array share the path of the array suffixed with [].
+func collectFields(doc any, path string, fields map[string]bool) {
+	switch value := doc.(type) {
+	case map[string]any:
+		for key, item := range value {
+			itemPath := key
+			if path != "" {
+				itemPath = path + "." + key
+			}
+			fields[itemPath] = true
+			collectFields(item, itemPath, fields)
+		}
+	case []any:
+		for _, item := range value {
+			collectFields(item, path+"[]", fields)
+		}
+	}
+}
+
+func sortedFields(fields map[string]bool) []string {
+	sorted := make([]string, 0, len(fields))
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// selectExamples adds candidate to examples and keeps at most limit of them.
+// Examples with the same fields are the same structure, the most recent one is
+// kept. The rest are picked greedily by the fields they add to the ones picked
+// before, the most recent one on ties, so the first example is the richest.
+func selectExamples(examples []*entity.Example, candidate *entity.Example, limit int) []*entity.Example {
+	if candidate == nil {
+		return examples
+	}
+	pool := make([]*entity.Example, 0, len(examples)+1)
+	for _, example := range examples {
+		if !slices.Equal(example.Fields, candidate.Fields) {
+			pool = append(pool, example)
+		}
+	}
+	pool = append(pool, candidate)
+
+	selected := make([]*entity.Example, 0, limit)
+	covered := map[string]bool{}
+	for len(selected) < limit && len(pool) > 0 {
+		best, bestGain := 0, -1
+		for i, example := range pool {
+			gain := 0
+			for _, field := range example.Fields {
+				if !covered[field] {
+					gain++
+				}
+			}
+			if gain > bestGain || (gain == bestGain && isMoreRecent(example, pool[best])) {
+				best, bestGain = i, gain
+			}
+		}
+		for _, field := range pool[best].Fields {
+			covered[field] = true
+		}
+		selected = append(selected, pool[best])
+		pool = slices.Delete(pool, best, best+1)
+	}
+	return selected
+}
+
+func isMoreRecent(example, other *entity.Example) bool {
+	if example.CreatedAt == nil || other.CreatedAt == nil {
+		return example.SampleId.Timestamp().After(other.SampleId.Timestamp())
+	}
+	return example.CreatedAt.After(*other.CreatedAt)
+}
+
+// addParameterExample puts value first in the examples of a parameter, values
+// which are empty or entirely redacted are not examples.
+func addParameterExample(examples []string, value string, limit int) []string {
+	if value == "" || value == redact.Mask {
+		return examples
+	}
+	added := make([]string, 0, limit)
+	added = append(added, value)
+	for _, example := range examples {
+		if len(added) == limit {
+			break
+		}
+		if example != value {
+			added = append(added, example)
+		}
+	}
+	return added
+}
+
+// loadFieldsSeen returns the last time each field of the response bodies of
+// the structure was seen. The fields of a structure merged before they were
+// tracked are taken as seen when it was last updated.
+func loadFieldsSeen(structure *entity.ResponseStructure) map[string]time.Time {
+	fieldsSeen := make(map[string]time.Time, len(structure.FieldsSeen))
+	if structure.FieldsSeen == nil {
+		seenAt := time.Now().UTC()
+		if structure.UpdatedAt != nil {
+			seenAt = *structure.UpdatedAt
+		}
+		fields := map[string]bool{}
+		collectSchemaFields(structure.BodySchema, "", fields)
+		markFieldsSeen(fieldsSeen, fields, seenAt)
+		return fieldsSeen
+	}
+	for _, fieldSeen := range structure.FieldsSeen {
+		fieldsSeen[fieldSeen.Field] = fieldSeen.LastSeenAt
+	}
+	return fieldsSeen
+}
+
+// markFieldsSeen moves the last seen time of fields forward, samples may be
+// merged out of order.
+func markFieldsSeen(fieldsSeen map[string]time.Time, fields map[string]bool, seenAt time.Time) {
+	for field := range fields {
+		if lastSeenAt, ok := fieldsSeen[field]; !ok || seenAt.After(lastSeenAt) {
+			fieldsSeen[field] = seenAt
+		}
+	}
+}
+
+func buildFieldsSeen(fieldsSeen map[string]time.Time) []*entity.FieldSeen {
+	fields := make([]*entity.FieldSeen, 0, len(fieldsSeen))
+	for field, lastSeenAt := range fieldsSeen {
+		fields = append(fields, &entity.FieldSeen{
+			Field:      field,
+			LastSeenAt: lastSeenAt,
+		})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Field < fields[j].Field
+	})
+	return fields
+}
+
+// collectSchemaFields adds the paths of the fields of schema to fields, as
+// collectFields does for the bodies it was generated from.
+func collectSchemaFields(schema map[string]any, path string, fields map[string]bool) {
+	if items, ok := openapiutils.ResolveSchema(schema, "/items"); ok {
+		collectSchemaFields(items, path+"[]", fields)
+		return
+	}
+	properties, _ := openapiutils.ResolveSchema(schema, "/properties")
+	for key, value := range properties {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fields[fieldPath] = true
+		property, _ := openapiutils.ResolveSchema(value, "")
+		collectSchemaFields(property, fieldPath, fields)
+	}
+}
+
+// samplesMergedUntil returns the build watermark past the merged samples, the
+// builds load the samples created since the watermark included and the dates
+// are stored to the millisecond. It is nil when no sample has a creation date.
+func samplesMergedUntil(sampleRequests []*entity.SampleRequest, sampleResponses []*entity.SampleResponse) *time.Time {
+	var latest *time.Time
+	observe := func(createdAt *time.Time) {
+		if createdAt != nil && (latest == nil || createdAt.After(*latest)) {
+			latest = createdAt
+		}
+	}
+	for _, sampleRequest := range sampleRequests {
+		observe(sampleRequest.CreatedAt)
+	}
+	for _, sampleResponse := range sampleResponses {
+		observe(sampleResponse.CreatedAt)
+	}
+	if latest == nil {
+		return nil
+	}
+	mergedUntil := latest.Truncate(time.Millisecond).Add(time.Millisecond)
+	return &mergedUntil
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestGenerateSchema(t *testing.T) {
@@ -367,3 +373,323 @@ func TestMergeObject(t *testing.T) {
 		})
 	}
 }
+
+func TestApplySchemaOverride(t *testing.T) {
+	t.Parallel()
+	inferred := func() map[string]any {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"ad_id":    map[string]any{"type": "number"},
+				"password": map[string]any{"type": "string"},
+				"price":    map[string]any{"type": "string"},
+				"params":   map[string]any{"type": "object", "properties": map[string]any{}},
+			},
+		}
+	}
+	deprecated := true
+	required := true
+	tests := []struct {
+		name  string
+		rules []*entity.OverrideRule
+		want  map[string]any
+	}{
+		{
+			name: "Test ApplySchemaOverride - no rule of the target",
+			rules: []*entity.OverrideRule{
+				{Target: constants.AnnotationTargetRequest, Pointer: "/properties/ad_id", Hidden: true},
+			},
+			want: nil,
+		},
+		{
+			name: "Test ApplySchemaOverride - pin, require, deprecate and hide",
+			rules: []*entity.OverrideRule{
+				{Target: constants.AnnotationTargetResponse, Pointer: "/properties/price", Type: "number", Deprecated: &deprecated},
+				{Target: constants.AnnotationTargetResponse, Pointer: "/properties/password", Hidden: true},
+				{Target: constants.AnnotationTargetResponse, Pointer: "/properties/ad_id", Required: &required},
+				{Target: constants.AnnotationTargetResponse, Pointer: "/properties/removed", Type: "string"},
+			},
+			want: map[string]any{
+				"type":     "object",
+				"x-source": "inferred",
+				"required": []string{"ad_id"},
+				"properties": map[string]any{
+					"ad_id":  map[string]any{"type": "number", "x-source": "override"},
+					"price":  map[string]any{"type": "number", "deprecated": true, "x-source": "override"},
+					"params": map[string]any{"type": "object", "properties": map[string]any{}},
+				},
+			},
+		},
+		{
+			name: "Test ApplySchemaOverride - replace a subtree then refine it",
+			rules: []*entity.OverrideRule{
+				{Target: constants.AnnotationTargetResponse, Pointer: "/properties/params/properties/size", Required: &required},
+				{
+					Target:  constants.AnnotationTargetResponse,
+					Pointer: "/properties/params",
+					Schema: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"size":  map[string]any{"type": "integer"},
+							"color": map[string]any{"type": "string"},
+						},
+					},
+				},
+			},
+			want: map[string]any{
+				"type":     "object",
+				"x-source": "inferred",
+				"properties": map[string]any{
+					"ad_id":    map[string]any{"type": "number"},
+					"password": map[string]any{"type": "string"},
+					"price":    map[string]any{"type": "string"},
+					"params": map[string]any{
+						"type":     "object",
+						"x-source": "override",
+						"required": []string{"size"},
+						"properties": map[string]any{
+							"size":  map[string]any{"type": "integer", "x-source": "override"},
+							"color": map[string]any{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			bodySchema := inferred()
+			schemaOverride := &entity.SchemaOverride{Rules: tt.rules}
+			got := applySchemaOverride(bodySchema, schemaOverride, constants.AnnotationTargetResponse)
+			require.Equal(t, tt.want, got)
+			// the inferred schema the next samples are merged into is left as is
+			require.Equal(t, inferred(), bodySchema)
+		})
+	}
+}
+
+func TestNewExample(t *testing.T) {
+	t.Parallel()
+	redactor := redact.New(nil)
+	createdAt := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	sampleId := primitive.NewObjectIDFromTimestamp(createdAt)
+	tests := []struct {
+		name        string
+		body        string
+		wantExample *entity.Example
+	}{
+		{
+			name: "Test NewExample - redacted with array items sharing their path",
+			body: `{"ads":[{"ad_id":1,"phone":"0373601207"},{"ad_id":2,"price":100}],"total":2}`,
+			wantExample: &entity.Example{
+				SampleId:  sampleId,
+				CreatedAt: &createdAt,
+				Value:     `{"ads":[{"ad_id":1,"phone":"REDACTED"},{"ad_id":2,"price":100}],"total":2}`,
+				Fields:    []string{"ads", "ads[].ad_id", "ads[].phone", "ads[].price", "total"},
+			},
+		},
+		{
+			name: "Test NewExample - root array",
+			body: `[{"id":1}]`,
+			wantExample: &entity.Example{
+				SampleId:  sampleId,
+				CreatedAt: &createdAt,
+				Value:     `[{"id":1}]`,
+				Fields:    []string{"[].id"},
+			},
+		},
+		{
+			name:        "Test NewExample - not JSON",
+			body:        `ad_id=1`,
+			wantExample: nil,
+		},
+		{
+			name:        "Test NewExample - empty",
+			body:        ``,
+			wantExample: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantExample, newExample(redactor, sampleId, &createdAt, tt.body))
+		})
+	}
+}
+
+func TestSelectExamples(t *testing.T) {
+	t.Parallel()
+	newExample := func(minute int, fields ...string) *entity.Example {
+		createdAt := time.Date(2024, 10, 1, 8, minute, 0, 0, time.UTC)
+		return &entity.Example{
+			SampleId:  primitive.NewObjectIDFromTimestamp(createdAt),
+			CreatedAt: &createdAt,
+			Value:     "{}",
+			Fields:    fields,
+		}
+	}
+	small := newExample(1, "ad_id")
+	full := newExample(2, "ad_id", "price", "subject")
+	images := newExample(3, "ad_id", "images", "images[].url")
+	empty := newExample(4)
+	recentSmall := newExample(5, "ad_id")
+	tests := []struct {
+		name         string
+		examples     []*entity.Example
+		candidate    *entity.Example
+		wantExamples []*entity.Example
+	}{
+		{
+			name:         "Test SelectExamples - no candidate",
+			examples:     []*entity.Example{small},
+			candidate:    nil,
+			wantExamples: []*entity.Example{small},
+		},
+		{
+			name:         "Test SelectExamples - richest first",
+			examples:     []*entity.Example{small},
+			candidate:    full,
+			wantExamples: []*entity.Example{full, small},
+		},
+		{
+			name:         "Test SelectExamples - same fields keep the most recent",
+			examples:     []*entity.Example{full, small},
+			candidate:    recentSmall,
+			wantExamples: []*entity.Example{full, recentSmall},
+		},
+		{
+			name:         "Test SelectExamples - keep the ones adding fields, the most recent on ties",
+			examples:     []*entity.Example{full, small, empty},
+			candidate:    images,
+			wantExamples: []*entity.Example{images, full, empty},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := selectExamples(tt.examples, tt.candidate, 3)
+			require.Equal(t, tt.wantExamples, got)
+		})
+	}
+}
+
+func TestAddParameterExample(t *testing.T) {
+	t.Parallel()
+	examples := addParameterExample(nil, "20", 2)
+	examples = addParameterExample(examples, "", 2)
+	examples = addParameterExample(examples, redact.Mask, 2)
+	examples = addParameterExample(examples, "50", 2)
+	require.Equal(t, []string{"50", "20"}, examples)
+	examples = addParameterExample(examples, "20", 2)
+	require.Equal(t, []string{"20", "50"}, examples)
+	examples = addParameterExample(examples, "10", 2)
+	require.Equal(t, []string{"10", "20"}, examples)
+}
+
+func TestLoadFieldsSeen(t *testing.T) {
+	t.Parallel()
+	updatedAt := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	seenAt := time.Date(2024, 11, 1, 8, 0, 0, 0, time.UTC)
+	bodySchema := map[string]any{
+		"type": "object",
+		"properties": primitive.M{
+			"ad_id": primitive.M{"type": "integer"},
+			"images": primitive.M{
+				"type": "array",
+				"items": primitive.M{
+					"type":       "object",
+					"properties": primitive.M{"url": primitive.M{"type": "string"}},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name      string
+		structure *entity.ResponseStructure
+		want      map[string]time.Time
+	}{
+		{
+			name: "Test LoadFieldsSeen - tracked fields",
+			structure: &entity.ResponseStructure{
+				BodySchema: bodySchema,
+				FieldsSeen: []*entity.FieldSeen{{Field: "ad_id", LastSeenAt: seenAt}},
+			},
+			want: map[string]time.Time{"ad_id": seenAt},
+		},
+		{
+			name: "Test LoadFieldsSeen - fields merged before they were tracked",
+			structure: &entity.ResponseStructure{
+				BaseEntity: mongodbutils.BaseEntity{UpdatedAt: &updatedAt},
+				BodySchema: bodySchema,
+			},
+			want: map[string]time.Time{"ad_id": updatedAt, "images": updatedAt, "images[].url": updatedAt},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, loadFieldsSeen(tt.structure))
+		})
+	}
+}
+
+func TestMarkFieldsSeen(t *testing.T) {
+	t.Parallel()
+	earlier := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	later := time.Date(2024, 11, 1, 8, 0, 0, 0, time.UTC)
+	fieldsSeen := map[string]time.Time{}
+	fields := map[string]bool{}
+	collectFields(map[string]any{"ad_id": 1, "images": []any{map[string]any{"url": "a.jpg"}}}, "", fields)
+	markFieldsSeen(fieldsSeen, fields, later)
+	// a sample merged out of order does not move the fields back
+	markFieldsSeen(fieldsSeen, map[string]bool{"ad_id": true, "price": true}, earlier)
+	require.Equal(t, []*entity.FieldSeen{
+		{Field: "ad_id", LastSeenAt: later},
+		{Field: "images", LastSeenAt: later},
+		{Field: "images[].url", LastSeenAt: later},
+		{Field: "price", LastSeenAt: earlier},
+	}, buildFieldsSeen(fieldsSeen))
+}
+
+func TestSamplesMergedUntil(t *testing.T) {
+	t.Parallel()
+	createdAt := func(value string) *time.Time {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		require.NoError(t, err)
+		return &parsed
+	}
+	tests := []struct {
+		name            string
+		sampleRequests  []*entity.SampleRequest
+		sampleResponses []*entity.SampleResponse
+		want            *time.Time
+	}{
+		{
+			name: "Test SamplesMergedUntil - past the latest sample",
+			sampleRequests: []*entity.SampleRequest{
+				{BaseEntity: mongodbutils.BaseEntity{CreatedAt: createdAt("2024-12-02T03:00:00.100Z")}},
+			},
+			sampleResponses: []*entity.SampleResponse{
+				{BaseEntity: mongodbutils.BaseEntity{CreatedAt: createdAt("2024-12-02T03:00:00.250400Z")}},
+				{BaseEntity: mongodbutils.BaseEntity{CreatedAt: createdAt("2024-12-02T03:00:00.200Z")}},
+			},
+			want: createdAt("2024-12-02T03:00:00.251Z"),
+		},
+		{
+			name:           "Test SamplesMergedUntil - no creation date",
+			sampleRequests: []*entity.SampleRequest{{}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, samplesMergedUntil(tt.sampleRequests, tt.sampleResponses))
+		})
+	}
+}
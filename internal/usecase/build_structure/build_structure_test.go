@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ct-logic-api-document/internal/usecase/drift"
 	fetchdata "github.com/ct-logic-api-document/internal/usecase/fetch_data"
 	"github.com/stretchr/testify/require"
 )
@@ -14,6 +15,7 @@ func TestBuildStructure(t *testing.T) {
 	fetchdataUC := fetchdata.NewFetchDataUC(
 		conf,
 		mongoStorage,
+		drift.NewDriftUC(conf, mongoStorage),
 	)
 	samplesForFetchData := []string{
 		`{"request":{"method":"POST","body":"{\"services\":[{\"target\":1138330,\"type\":\"bump\",\"params\":{\"duration\":1}},{\"target\":1138330,\"type\":\"sticky_ad\",\"params\":{\"duration\":3}},{\"target\":1138330,\"type\":\"special_display\",\"params\":{\"duration\":14}},{\"target\":1138330,\"type\":\"bundle\",\"params\":{\"ad_id\":1138330,\"bundle_id\":\"151\",\"category_id\":1030,\"region_id\":12000,\"segment_id\":\"all\"}}]}","querystring":{"cart_id":"oneclick"},"uri":"/v2/private/cart/services?cart_id=oneclick","headers":{"x-chotot-id-key":"L6bwKf6aPC6ejBDhcT0jCxRRVIrMTFhP","cdn-loop":"cloudflare; loops=1","cf-ipcountry":"SG","authorization":"REDACTED","x-forwarded-for":"35.187.226.205","host":"gateway.chotot.org","content-type":"application/json","cf-visitor":"{\"scheme\":\"https\"}","cache-control":"no-cache","accept-encoding":"gzip, br","postman-token":"c1c1f04f-415d-435a-9b56-b459ac10d68d","cf-connecting-ip":"35.187.226.205","accept":"*/*","content-length":"338","x-forwarded-proto":"https","cf-ray":"8ea0b8314f38604d-SIN","user-agent":"PostmanRuntime/7.39.1"},"size":1373,"url":"https://gateway.chotot.org:443/v2/private/cart/services?cart_id=oneclick","tls":{"cipher":"TLS_AES_128_GCM_SHA256","client_verify":"NONE","version":"TLSv1.3"}},"response":{"status":200,"size":479,"body":"{\"success\":[{\"target\":1138330,\"type\":\"bump\",\"params\":{\"duration\":1,\"user_type\":\"private\"},\"price\":0,\"priceUnit\":null,\"error\":\"\"},{\"target\":1138330,\"type\":\"sticky_ad\",\"params\":{\"duration\":3,\"user_type\":\"private\"},\"price\":0,\"priceUnit\":null,\"error\":\"\"},{\"target\":1138330,\"type\":\"special_display\",\"params\":{\"duration\":14,\"user_type\":\"private\"},\"price\":0,\"priceUnit\":null,\"error\":\"\"},{\"target\":1138330,\"type\":\"bundle\",\"params\":{\"ad_id\":1138330,\"ad_type\":\"let\",\"bundle_id\":\"151\",\"category_id\":1030,\"region_id\":12000,\"segment_id\":\"all\",\"user_type\":\"private\"},\"price\":0,\"priceUnit\":null,\"error\":\"\"}],\"fail\":[]}\n","headers":{"vary":"Origin","connection":"close","x-correlation-id":"3e17e6c035544d929535e4e6e6041766","content-encoding":"gzip","via":"kong/2.8.0-d648489b6","x-kong-proxy-latency":"2","content-type":"application/json; charset=UTF-8","x-kong-upstream-latency":"103","date":"Fri, 29 Nov 2024 07:00:13 GMT","access-control-expose-headers":"X-Total-Count"}},"client_ip":"35.187.226.205","started_at":1732863613661,"upstream_uri":"/private/cart/services?cart_id=oneclick"}`,
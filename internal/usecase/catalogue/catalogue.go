@@ -0,0 +1,476 @@
+package catalogue
+
+import (
+	"context"
+	"time"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	buildstructure "github.com/ct-logic-api-document/internal/usecase/build_structure"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ICatalogueUC interface {
+	GetApis(ctx context.Context, req *entity.GetApisRequest) (*entity.GetApisResponse, error)
+	GetApiDetail(ctx context.Context, apiId string) (*entity.ApiDetail, error)
+	UpdateApi(ctx context.Context, req *entity.UpdateApiRequest) (*entity.Api, error)
+	// GetSamples returns the recorded calls of the api from the most recent,
+	// redacted.
+	GetSamples(ctx context.Context, req *entity.GetSamplesRequest) (*entity.GetSamplesResponse, error)
+	// BuildApi builds the structures of the api from its new samples right
+	// away, instead of waiting for the build cronjob.
+	BuildApi(ctx context.Context, apiId string) (*entity.Api, error)
+	// GetAnnotations returns the annotations of the api, flagging the ones whose
+	// pointer no longer resolves in the current structures.
+	GetAnnotations(ctx context.Context, apiId string) ([]*entity.Annotation, error)
+	SaveAnnotation(ctx context.Context, req *entity.SaveAnnotationRequest) (*entity.Annotation, error)
+	DeleteAnnotation(ctx context.Context, req *entity.DeleteAnnotationRequest) error
+	GetSchemaOverride(ctx context.Context, apiId string) (*entity.SchemaOverride, error)
+	// SaveSchemaOverride replaces the schema override of the api and applies it
+	// onto its structures right away.
+	SaveSchemaOverride(ctx context.Context, req *entity.SaveSchemaOverrideRequest) (*entity.SchemaOverride, error)
+	// ValidateSchemaOverride validates the recent samples of the api against its
+	// structures, overridden ones included.
+	ValidateSchemaOverride(ctx context.Context, apiId string) (*entity.SchemaOverrideValidation, error)
+	// GetDrifts returns the drifts of the live traffic of the api from its
+	// structures, the most frequent first.
+	GetDrifts(ctx context.Context, req *entity.GetDriftsRequest) (*entity.GetDriftsResponse, error)
+	// GetApiStats returns the hourly traffic stats of the api and their
+	// summary.
+	GetApiStats(ctx context.Context, req *entity.GetApiStatsRequest) (*entity.GetApiStatsResponse, error)
+	// GetConsumers returns the app versions, kong consumers, id keys and
+	// subnets calling the api with the fields they send, the most frequent
+	// first.
+	GetConsumers(ctx context.Context, req *entity.GetConsumersRequest) (*entity.GetConsumersResponse, error)
+}
+
+type catalogueUC struct {
+	conf             *config.Config
+	storage          mongodb.MongoStorage
+	buildStructureUC buildstructure.IBuildStructureUC
+	redactor         *redact.Redactor
+}
+
+func NewCatalogueUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	buildStructureUC buildstructure.IBuildStructureUC,
+) ICatalogueUC {
+	return &catalogueUC{
+		conf:             conf,
+		storage:          storage,
+		buildStructureUC: buildStructureUC,
+		redactor:         redact.New(conf.Samples.RedactKeys),
+	}
+}
+
+func (uc *catalogueUC) GetApis(ctx context.Context, req *entity.GetApisRequest) (*entity.GetApisResponse, error) {
+	apis, err := uc.storage.GetApisByFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	total, err := uc.storage.CountApisByFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if apis == nil {
+		apis = []*entity.Api{}
+	}
+	return &entity.GetApisResponse{
+		Apis:   apis,
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+func (uc *catalogueUC) GetApiDetail(ctx context.Context, apiId string) (*entity.ApiDetail, error) {
+	apiObject, err := uc.getApi(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	sampleRequestCount, err := uc.storage.CountSampleRequestsByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	sampleResponseCount, err := uc.storage.CountSampleResponsesByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	statusCodes, err := uc.storage.GetStatusCodesByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.ApiDetail{
+		Api:                 apiObject,
+		SampleRequestCount:  sampleRequestCount,
+		SampleResponseCount: sampleResponseCount,
+		StatusCodes:         statusCodes,
+	}, nil
+}
+
+func (uc *catalogueUC) UpdateApi(ctx context.Context, req *entity.UpdateApiRequest) (*entity.Api, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.storage.UpdateApiInfo(ctx, apiObject.Id, req); err != nil {
+		return nil, err
+	}
+	return uc.getApi(ctx, req.ApiId)
+}
+
+func (uc *catalogueUC) GetSamples(ctx context.Context,
+	req *entity.GetSamplesRequest,
+) (*entity.GetSamplesResponse, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParameterFilters(uc.redactor, req.Parameters); err != nil {
+		return nil, err
+	}
+	filter := &entity.GetSampleRequestByApiIdRequest{
+		ApiId:      apiObject.Id,
+		From:       req.From,
+		To:         req.To,
+		Parameters: req.Parameters,
+	}
+	if req.Cursor != "" {
+		beforeId, err := primitive.ObjectIDFromHex(req.Cursor)
+		if err != nil {
+			return nil, errors.InvalidArgument("cursor is invalid")
+		}
+		filter.BeforeId = &beforeId
+	}
+
+	resp := &entity.GetSamplesResponse{
+		Samples: []*entity.Sample{},
+	}
+	batch := make([]*entity.SampleRequest, 0, constants.SamplesPairingBatchSize)
+	// collect keeps the samples of the batch matching req, until the page is
+	// full
+	collect := func() (bool, error) {
+		samples, err := uc.pairSamples(ctx, apiObject.Id, batch)
+		if err != nil {
+			return false, err
+		}
+		batch = batch[:0]
+		for _, sample := range samples {
+			redactSample(uc.redactor, sample)
+			if !matchSample(sample, req) {
+				continue
+			}
+			resp.Samples = append(resp.Samples, sample)
+			if int64(len(resp.Samples)) == req.Limit {
+				resp.NextCursor = sample.Request.GetIdStr()
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	scanned := 0
+	err = uc.storage.IterateLatestSampleRequestByApiId(ctx, filter, func(sampleRequest *entity.SampleRequest) error {
+		batch = append(batch, sampleRequest)
+		scanned++
+		if scanned < constants.MaxSamplesScanned && len(batch) < constants.SamplesPairingBatchSize {
+			return nil
+		}
+		full, err := collect()
+		if err != nil {
+			return err
+		}
+		if full {
+			return mongodbutils.ErrStopIteration
+		}
+		if scanned >= constants.MaxSamplesScanned {
+			resp.NextCursor = sampleRequest.GetIdStr()
+			return mongodbutils.ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batch) > 0 {
+		if _, err := collect(); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// pairSamples looks up the responses of sampleRequests, the samples are in
+// the order of sampleRequests.
+func (uc *catalogueUC) pairSamples(ctx context.Context,
+	apiId primitive.ObjectID,
+	sampleRequests []*entity.SampleRequest,
+) ([]*entity.Sample, error) {
+	correlationIds := make([]string, 0, len(sampleRequests))
+	for _, sampleRequest := range sampleRequests {
+		if sampleRequest.CorrelationId != "" {
+			correlationIds = append(correlationIds, sampleRequest.CorrelationId)
+		}
+	}
+	var sampleResponses []*entity.SampleResponse
+	if len(correlationIds) > 0 {
+		var err error
+		sampleResponses, err = uc.storage.GetSampleResponsesByCorrelationIds(ctx, apiId, correlationIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buildSamples(sampleRequests, sampleResponses), nil
+}
+
+func (uc *catalogueUC) BuildApi(ctx context.Context, apiId string) (*entity.Api, error) {
+	apiObject, err := uc.getApi(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.buildStructureUC.DoBuildStructure(ctx, apiObject); err != nil {
+		return nil, err
+	}
+	return uc.getApi(ctx, apiId)
+}
+
+func (uc *catalogueUC) GetAnnotations(ctx context.Context, apiId string) ([]*entity.Annotation, error) {
+	apiObject, err := uc.getApi(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := uc.storage.GetAnnotationsByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.flagOrphanedAnnotations(ctx, apiObject, annotations); err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = []*entity.Annotation{}
+	}
+	return annotations, nil
+}
+
+// SaveAnnotation replaces the annotation of the same target and pointer, it is
+// accepted even when the pointer does not resolve yet and comes back orphaned.
+func (uc *catalogueUC) SaveAnnotation(ctx context.Context, req *entity.SaveAnnotationRequest) (*entity.Annotation, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	annotation := &entity.Annotation{
+		ApiId:   apiObject.Id,
+		Target:  req.Target,
+		Pointer: req.Pointer,
+		Schema:  req.Schema,
+	}
+	if err := uc.storage.SaveAnnotation(ctx, annotation); err != nil {
+		return nil, err
+	}
+	if err := uc.flagOrphanedAnnotations(ctx, apiObject, []*entity.Annotation{annotation}); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func (uc *catalogueUC) DeleteAnnotation(ctx context.Context, req *entity.DeleteAnnotationRequest) error {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return err
+	}
+	return uc.storage.DeleteAnnotation(ctx, apiObject.Id, req.Target, req.Pointer)
+}
+
+func (uc *catalogueUC) GetSchemaOverride(ctx context.Context, apiId string) (*entity.SchemaOverride, error) {
+	apiObject, err := uc.getApi(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	schemaOverride, err := uc.storage.GetSchemaOverrideByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	if schemaOverride == nil {
+		schemaOverride = &entity.SchemaOverride{
+			ApiId: apiObject.Id,
+			Rules: []*entity.OverrideRule{},
+		}
+	}
+	return schemaOverride, nil
+}
+
+func (uc *catalogueUC) SaveSchemaOverride(ctx context.Context,
+	req *entity.SaveSchemaOverrideRequest,
+) (*entity.SchemaOverride, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	schemaOverride := &entity.SchemaOverride{
+		ApiId: apiObject.Id,
+		Rules: req.Rules,
+	}
+	if schemaOverride.Rules == nil {
+		schemaOverride.Rules = []*entity.OverrideRule{}
+	}
+	if err := uc.storage.SaveSchemaOverride(ctx, schemaOverride); err != nil {
+		return nil, err
+	}
+	if err := uc.buildStructureUC.ApplySchemaOverride(ctx, apiObject); err != nil {
+		return nil, err
+	}
+	return schemaOverride, nil
+}
+
+func (uc *catalogueUC) ValidateSchemaOverride(ctx context.Context,
+	apiId string,
+) (*entity.SchemaOverrideValidation, error) {
+	apiObject, err := uc.getApi(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	validation := &entity.SchemaOverrideValidation{
+		Valid:   true,
+		Samples: []*entity.SampleValidation{},
+	}
+	requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	if requestStructure != nil {
+		sampleRequests, err := uc.storage.GetSampleRequestByApiId(ctx, &entity.GetSampleRequestByApiIdRequest{
+			ApiId: apiObject.Id,
+			Limit: constants.OverrideValidationSamples,
+		})
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]*sampleBody, 0, len(sampleRequests))
+		for _, sampleRequest := range sampleRequests {
+			samples = append(samples, &sampleBody{id: sampleRequest.Id, body: sampleRequest.Body})
+		}
+		err = validateSamples(validation, constants.AnnotationTargetRequest, requestStructure.Schema(), samples)
+		if err != nil {
+			return nil, err
+		}
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	if responseStructure != nil {
+		sampleResponses, err := uc.storage.GetSampleResponseByApiId(ctx, &entity.GetSampleResponseByApiIdRequest{
+			ApiId: apiObject.Id,
+			Limit: constants.OverrideValidationSamples,
+		})
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]*sampleBody, 0, len(sampleResponses))
+		for _, sampleResponse := range sampleResponses {
+			samples = append(samples, &sampleBody{id: sampleResponse.Id, body: sampleResponse.Body})
+		}
+		err = validateSamples(validation, constants.AnnotationTargetResponse, responseStructure.Schema(), samples)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return validation, nil
+}
+
+func (uc *catalogueUC) flagOrphanedAnnotations(ctx context.Context,
+	apiObject *entity.Api, annotations []*entity.Annotation,
+) error {
+	requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return err
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return err
+	}
+	for _, annotation := range annotations {
+		_, ok := annotation.Resolve(requestStructure, responseStructure)
+		annotation.Orphaned = !ok
+	}
+	return nil
+}
+
+func (uc *catalogueUC) getApi(ctx context.Context, apiId string) (*entity.Api, error) {
+	apiObject, err := uc.storage.GetApiByIdInStr(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	if apiObject == nil {
+		return nil, errors.ErrApiNotFound
+	}
+	return apiObject, nil
+}
+
+func (uc *catalogueUC) GetDrifts(ctx context.Context, req *entity.GetDriftsRequest) (*entity.GetDriftsResponse, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	drifts, err := uc.storage.GetDriftsByApiId(ctx, apiObject.Id, req.From)
+	if err != nil {
+		return nil, err
+	}
+	if drifts == nil {
+		drifts = []*entity.Drift{}
+	}
+	return &entity.GetDriftsResponse{
+		Drifts: drifts,
+	}, nil
+}
+
+func (uc *catalogueUC) GetApiStats(ctx context.Context, req *entity.GetApiStatsRequest) (*entity.GetApiStatsResponse, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	to := time.Now().UTC()
+	if req.To != nil {
+		to = req.To.UTC()
+	}
+	from := to.Add(-constants.StatsDefaultWindow)
+	if req.From != nil {
+		from = req.From.UTC()
+	}
+	if !from.Before(to) {
+		return nil, errors.InvalidArgument("from must be before to")
+	}
+	hours, err := uc.storage.GetApiStatsByApiId(ctx, apiObject.Id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if hours == nil {
+		hours = []*entity.ApiStats{}
+	}
+	return &entity.GetApiStatsResponse{
+		From:    from,
+		To:      to,
+		Summary: entity.SummarizeApiStats(hours),
+		Hours:   hours,
+	}, nil
+}
+
+func (uc *catalogueUC) GetConsumers(ctx context.Context, req *entity.GetConsumersRequest) (*entity.GetConsumersResponse, error) {
+	apiObject, err := uc.getApi(ctx, req.ApiId)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := uc.storage.GetConsumerUsagesByApiId(ctx, apiObject.Id, req.Kind, req.From)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.GetConsumersResponse{
+		Consumers: groupConsumers(usages, req.Field),
+	}, nil
+}
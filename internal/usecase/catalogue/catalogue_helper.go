@@ -0,0 +1,244 @@
+package catalogue
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/pkg/redact"
+	jsonpathutils "github.com/ct-logic-api-document/utils/jsonpath"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const bodySchemaURL = "body_schema.json"
+
+// sampleBody is the body of a sample request or response.
+type sampleBody struct {
+	id   primitive.ObjectID
+	body string
+}
+
+// validateSamples adds to validation the result of each sample with a body
+// against bodySchema, nothing is validated when the structure has no body.
+func validateSamples(
+	validation *entity.SchemaOverrideValidation,
+	target string,
+	bodySchema map[string]any,
+	samples []*sampleBody,
+) error {
+	if len(bodySchema) == 0 {
+		return nil
+	}
+	schema, err := compileBodySchema(bodySchema)
+	if err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		if sample.body == "" {
+			continue
+		}
+		violations := validateBody(schema, sample.body)
+		validation.Samples = append(validation.Samples, &entity.SampleValidation{
+			Target:   target,
+			SampleId: sample.id,
+			Valid:    len(violations) == 0,
+			Errors:   violations,
+		})
+		if len(violations) > 0 {
+			validation.Valid = false
+		}
+	}
+	return nil
+}
+
+// compileBodySchema compiles a body schema, in the OpenAPI 3.0 dialect the
+// structures are stored in, to validate sample bodies against.
+func compileBodySchema(bodySchema map[string]any) (*jsonschema.Schema, error) {
+	schema := openapiutils.CopySchema(bodySchema)
+	openapiutils.ConvertSchemaTo31(schema)
+	// the compiler expects the values of a decoded JSON document
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	if err := compiler.AddResource(bodySchemaURL, document); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(bodySchemaURL)
+}
+
+// validateBody returns the violations of schema by body, one per instance
+// location, a body which is not JSON is a violation itself.
+func validateBody(schema *jsonschema.Schema, body string) []string {
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(body))
+	if err != nil {
+		return []string{fmt.Sprintf("body is not JSON: %v", err)}
+	}
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{err.Error()}
+	}
+	violations := make([]string, 0)
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s", location, unit.Error))
+	}
+	return violations
+}
+
+// buildSamples pairs each sample request with the first sample response of
+// its correlation id.
+func buildSamples(sampleRequests []*entity.SampleRequest, sampleResponses []*entity.SampleResponse) []*entity.Sample {
+	responses := make(map[string]*entity.SampleResponse, len(sampleResponses))
+	for _, sampleResponse := range sampleResponses {
+		if _, ok := responses[sampleResponse.CorrelationId]; !ok {
+			responses[sampleResponse.CorrelationId] = sampleResponse
+		}
+	}
+	samples := make([]*entity.Sample, 0, len(sampleRequests))
+	for _, sampleRequest := range sampleRequests {
+		sample := &entity.Sample{
+			CorrelationId: sampleRequest.CorrelationId,
+			Request:       sampleRequest,
+		}
+		if sampleRequest.CorrelationId != "" {
+			sample.Response = responses[sampleRequest.CorrelationId]
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func redactSample(redactor *redact.Redactor, sample *entity.Sample) {
+	sample.Request.Body = redactor.Body(sample.Request.Body)
+	for _, parameter := range sample.Request.Parameters {
+		parameter.Value = redactor.Parameter(parameter.Name, parameter.Value)
+	}
+	if sample.Response != nil {
+		sample.Response.Body = redactor.Body(sample.Response.Body)
+	}
+}
+
+// checkParameterFilters rejects the parameter filters on values which are
+// masked in the samples served. Mongo matches the stored values, a filter on a
+// masked one would let a caller confirm a guess of it.
+func checkParameterFilters(redactor *redact.Redactor, parameters map[string]string) error {
+	for name, value := range parameters {
+		if redactor.IsSensitive(name) {
+			return errors.InvalidArgument("parameter %s is redacted and can not be filtered on", name)
+		}
+		if redactor.Parameter(name, value) != value {
+			return errors.InvalidArgument("the value of parameter %s is redacted and can not be filtered on", name)
+		}
+	}
+	return nil
+}
+
+// matchSample applies the filters of req mongo can not, on the redacted
+// sample so that a predicate can not reveal a masked value.
+func matchSample(sample *entity.Sample, req *entity.GetSamplesRequest) bool {
+	if req.StatusCode != 0 && (sample.Response == nil || sample.Response.HttpStatusCode != req.StatusCode) {
+		return false
+	}
+	if req.RequestBody != nil && !matchBody(req.RequestBody, sample.Request.Body) {
+		return false
+	}
+	if req.ResponseBody != nil && (sample.Response == nil || !matchBody(req.ResponseBody, sample.Response.Body)) {
+		return false
+	}
+	return true
+}
+
+func matchBody(predicate *jsonpathutils.Predicate, body string) bool {
+	var doc any
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return false
+	}
+	return predicate.Match(doc)
+}
+
+// groupConsumers groups usages into the consumers they count the calls or the
+// fields of, the most frequent first. When field is set only the consumers
+// sending it are kept, with that field alone.
+func groupConsumers(usages []*entity.ConsumerUsage, field string) []*entity.ApiConsumer {
+	type consumerKey struct {
+		kind, name, version string
+	}
+	consumers := make(map[consumerKey]*entity.ApiConsumer)
+	for _, usage := range usages {
+		key := consumerKey{kind: usage.Kind, name: usage.Name, version: usage.Version}
+		consumer, ok := consumers[key]
+		if !ok {
+			consumer = &entity.ApiConsumer{
+				Kind:    usage.Kind,
+				Name:    usage.Name,
+				Version: usage.Version,
+			}
+			consumers[key] = consumer
+		}
+		if usage.Field == "" {
+			consumer.Count = usage.Count
+			consumer.FirstSeenAt = usage.FirstSeenAt
+			consumer.LastSeenAt = usage.LastSeenAt
+			continue
+		}
+		if field != "" && usage.Field != field {
+			continue
+		}
+		consumer.Fields = append(consumer.Fields, &entity.ConsumerField{
+			Target:     usage.Target,
+			Field:      usage.Field,
+			Count:      usage.Count,
+			LastSeenAt: usage.LastSeenAt,
+		})
+	}
+
+	result := make([]*entity.ApiConsumer, 0, len(consumers))
+	for _, consumer := range consumers {
+		if field != "" && len(consumer.Fields) == 0 {
+			continue
+		}
+		slices.SortFunc(consumer.Fields, func(a, b *entity.ConsumerField) int {
+			return cmp.Or(
+				cmp.Compare(b.Count, a.Count),
+				cmp.Compare(a.Target, b.Target),
+				cmp.Compare(a.Field, b.Field),
+			)
+		})
+		result = append(result, consumer)
+	}
+	slices.SortFunc(result, func(a, b *entity.ApiConsumer) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Version, b.Version),
+		)
+	})
+	return result
+}
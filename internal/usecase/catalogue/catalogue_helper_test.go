@@ -0,0 +1,236 @@
+package catalogue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	jsonpathutils "github.com/ct-logic-api-document/utils/jsonpath"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBody(t *testing.T) {
+	t.Parallel()
+	bodySchema := map[string]any{
+		"type":     "object",
+		"required": []string{"ad_id"},
+		"properties": map[string]any{
+			"ad_id":   map[string]any{"type": "number"},
+			"subject": map[string]any{"type": "string", "nullable": true, "example": "iPhone 15"},
+			"images": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+		"x-source": "inferred",
+	}
+	tests := []struct {
+		name           string
+		body           string
+		wantViolations []string
+	}{
+		{
+			name: "Test ValidateBody - valid",
+			body: `{"ad_id": 1, "subject": null, "images": ["a.jpg"]}`,
+		},
+		{
+			name:           "Test ValidateBody - missing required",
+			body:           `{"subject": "iPhone 15"}`,
+			wantViolations: []string{"/: missing property 'ad_id'"},
+		},
+		{
+			name:           "Test ValidateBody - wrong type",
+			body:           `{"ad_id": 1, "images": [1]}`,
+			wantViolations: []string{"/images/0: got number, want string"},
+		},
+	}
+	schema, err := compileBodySchema(bodySchema)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantViolations, validateBody(schema, tt.body))
+		})
+	}
+}
+
+func TestValidateBody_NotJSON(t *testing.T) {
+	t.Parallel()
+	schema, err := compileBodySchema(map[string]any{"type": "object"})
+	require.NoError(t, err)
+	violations := validateBody(schema, "<html>")
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0], "body is not JSON")
+}
+
+func TestBuildSamples(t *testing.T) {
+	t.Parallel()
+	sampleRequests := []*entity.SampleRequest{
+		{CorrelationId: "a"},
+		{CorrelationId: "b"},
+		// ingested before the samples were paired
+		{},
+	}
+	sampleResponses := []*entity.SampleResponse{
+		{CorrelationId: "b", HttpStatusCode: 500},
+		{CorrelationId: "a", HttpStatusCode: 200},
+		{CorrelationId: "a", HttpStatusCode: 201},
+		{HttpStatusCode: 404},
+	}
+	samples := buildSamples(sampleRequests, sampleResponses)
+	require.Len(t, samples, 3)
+	require.Equal(t, "a", samples[0].CorrelationId)
+	require.Equal(t, 200, samples[0].Response.HttpStatusCode)
+	require.Equal(t, 500, samples[1].Response.HttpStatusCode)
+	require.Same(t, sampleRequests[2], samples[2].Request)
+	require.Nil(t, samples[2].Response)
+}
+
+func TestMatchSample(t *testing.T) {
+	t.Parallel()
+	predicate := func(s string) *jsonpathutils.Predicate {
+		p, err := jsonpathutils.ParsePredicate(s)
+		require.NoError(t, err)
+		return p
+	}
+	sample := &entity.Sample{
+		Request: &entity.SampleRequest{Body: `{"ad_id":1138330,"phone":"0373601207"}`},
+		Response: &entity.SampleResponse{
+			HttpStatusCode: 200,
+			Body:           `{"services":[{"type":"bump","price":0},{"type":"sticky_ad","price":31000}]}`,
+		},
+	}
+	redactSample(redact.New(nil), sample)
+	tests := []struct {
+		name      string
+		sample    *entity.Sample
+		req       *entity.GetSamplesRequest
+		wantMatch bool
+	}{
+		{
+			name:      "Test MatchSample - no filter",
+			sample:    sample,
+			req:       &entity.GetSamplesRequest{},
+			wantMatch: true,
+		},
+		{
+			name:      "Test MatchSample - status code",
+			sample:    sample,
+			req:       &entity.GetSamplesRequest{StatusCode: 404},
+			wantMatch: false,
+		},
+		{
+			name:   "Test MatchSample - body predicates",
+			sample: sample,
+			req: &entity.GetSamplesRequest{
+				StatusCode:   200,
+				RequestBody:  predicate("$.ad_id==1138330"),
+				ResponseBody: predicate("$.services[*].price>30000"),
+			},
+			wantMatch: true,
+		},
+		{
+			name:      "Test MatchSample - redacted values can not be probed",
+			sample:    sample,
+			req:       &entity.GetSamplesRequest{RequestBody: predicate("$.phone==0373601207")},
+			wantMatch: false,
+		},
+		{
+			name:      "Test MatchSample - response predicate without response",
+			sample:    &entity.Sample{Request: &entity.SampleRequest{}},
+			req:       &entity.GetSamplesRequest{ResponseBody: predicate("$.services")},
+			wantMatch: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantMatch, matchSample(tt.sample, tt.req))
+		})
+	}
+}
+
+func TestGroupConsumers(t *testing.T) {
+	t.Parallel()
+	usages := []*entity.ConsumerUsage{
+		{Kind: "app", Name: "android", Version: "5.2.0", Count: 10},
+		{Kind: "subnet", Name: "10.0.1.0/24", Count: 7},
+		{Kind: "app", Name: "android", Version: "5.2.0", Target: "parameter", Field: "limit", Count: 6},
+		{Kind: "app", Name: "ios", Version: "5.1.0", Count: 4},
+		{Kind: "app", Name: "ios", Version: "5.1.0", Target: "parameter", Field: "limit", Count: 4},
+		{Kind: "app", Name: "android", Version: "5.2.0", Target: "request", Field: "$.ads[*].price", Count: 6},
+	}
+	tests := []struct {
+		name   string
+		field  string
+		expect []string
+	}{
+		{
+			name:   "Test GroupConsumers - all consumers",
+			expect: []string{"app|android|5.2.0|10|2", "subnet|10.0.1.0/24||7|0", "app|ios|5.1.0|4|1"},
+		},
+		{
+			name:   "Test GroupConsumers - consumers sending the field",
+			field:  "limit",
+			expect: []string{"app|android|5.2.0|10|1", "app|ios|5.1.0|4|1"},
+		},
+		{
+			name:   "Test GroupConsumers - field sent by nobody",
+			field:  "$.title",
+			expect: []string{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			consumers := groupConsumers(usages, tt.field)
+			got := make([]string, 0, len(consumers))
+			for _, consumer := range consumers {
+				got = append(got, fmt.Sprintf("%s|%s|%s|%d|%d",
+					consumer.Kind, consumer.Name, consumer.Version, consumer.Count, len(consumer.Fields)))
+			}
+			require.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func TestCheckParameterFilters(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "Test CheckParameterFilters - plain value",
+			parameters: map[string]string{"region": "13000"},
+		},
+		{
+			name:       "Test CheckParameterFilters - sensitive name",
+			parameters: map[string]string{"phone": "0373601207"},
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckParameterFilters - email guessed under another name",
+			parameters: map[string]string{"contact": "a@b.vn"},
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckParameterFilters - phone number guessed under another name",
+			parameters: map[string]string{"q": "0373601207"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkParameterFilters(redact.New(nil), tt.parameters)
+			require.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package deprecation
+
+import (
+	"context"
+	"time"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+)
+
+type IDeprecationUC interface {
+	// DetectDeprecated flags the apis not called anymore as deprecated
+	// candidates, reports the response fields which stopped appearing in the
+	// calls of the others, and soft deletes the candidates past their grace
+	// period when enabled.
+	DetectDeprecated(ctx context.Context) error
+}
+
+type deprecationUC struct {
+	conf    *config.Config
+	storage mongodb.MongoStorage
+}
+
+func NewDeprecationUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+) IDeprecationUC {
+	return &deprecationUC{
+		conf:    conf,
+		storage: storage,
+	}
+}
+
+func (uc *deprecationUC) DetectDeprecated(ctx context.Context) error {
+	now := time.Now().UTC()
+	report := &deprecationReport{}
+	err := uc.storage.IterateApis(ctx, func(api *entity.Api) error {
+		if err := uc.detectDeprecatedApi(ctx, api, now, report); err != nil {
+			return err
+		}
+		if isUnseen(api, now, uc.conf.Deprecation.UnseenPeriod) {
+			return nil
+		}
+		return uc.detectDeprecatedFields(ctx, api, report)
+	})
+	if err != nil {
+		return err
+	}
+	logctx.Infow(ctx, "detected deprecated apis and fields",
+		"candidates", report.candidates, "flagged", report.flagged, "unflagged", report.unflagged,
+		"soft_deleted", report.softDeleted, "apis_with_stale_fields", report.apisWithStaleFields,
+		"stale_fields", report.staleFields)
+	return nil
+}
+
+func (uc *deprecationUC) detectDeprecatedApi(
+	ctx context.Context,
+	api *entity.Api,
+	now time.Time,
+	report *deprecationReport,
+) error {
+	action := decideApiAction(api, now, uc.conf)
+	switch action {
+	case actionFlag:
+		if err := uc.storage.UpdateApiDeprecatedCandidate(ctx, api.Id, &now); err != nil {
+			return err
+		}
+		report.flagged++
+		logctx.Warnw(ctx, "api is not called anymore, flagged as deprecated candidate",
+			"api", api.Method+" "+api.Path, "last_seen_at", apiLastSeenAt(api))
+	case actionUnflag:
+		if err := uc.storage.UpdateApiDeprecatedCandidate(ctx, api.Id, nil); err != nil {
+			return err
+		}
+		report.unflagged++
+		logctx.Infow(ctx, "api is called again, no longer a deprecated candidate",
+			"api", api.Method+" "+api.Path, "last_seen_at", apiLastSeenAt(api))
+	case actionSoftDelete:
+		// an api declared by a document of its team is kept until the
+		// document drops it
+		baseline, err := uc.storage.GetBaselineByApiId(ctx, api.Id)
+		if err != nil {
+			return err
+		}
+		if baseline != nil {
+			report.candidates++
+			logctx.Warnw(ctx, "deprecated candidate is declared by a baseline, not deleted",
+				"api", api.Method+" "+api.Path, "source", baseline.Source)
+			return nil
+		}
+		if err := uc.storage.SoftDeleteApi(ctx, api.Id); err != nil {
+			return err
+		}
+		report.softDeleted++
+		logctx.Warnw(ctx, "deprecated candidate soft deleted after its grace period",
+			"api", api.Method+" "+api.Path, "flagged_at", api.DeprecatedCandidateAt)
+	case actionNone:
+		if api.DeprecatedCandidateAt != nil {
+			report.candidates++
+		}
+	}
+	return nil
+}
+
+func (uc *deprecationUC) detectDeprecatedFields(ctx context.Context, api *entity.Api, report *deprecationReport) error {
+	lastSeenAt := apiLastSeenAt(api)
+	if lastSeenAt == nil {
+		return nil
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, api.Id)
+	if err != nil {
+		return err
+	}
+	if responseStructure == nil {
+		return nil
+	}
+	fields := staleFields(responseStructure.FieldsSeen, *lastSeenAt, uc.conf.Deprecation.FieldUnseenPeriod)
+	if len(fields) == 0 {
+		return nil
+	}
+	report.apisWithStaleFields++
+	report.staleFields += len(fields)
+	logctx.Warnw(ctx, "fields stopped appearing in the responses of api",
+		"api", api.Method+" "+api.Path, "fields", fields)
+	return nil
+}
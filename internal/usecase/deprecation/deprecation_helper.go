@@ -0,0 +1,96 @@
+package deprecation
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/entity"
+)
+
+type apiAction int
+
+const (
+	actionNone apiAction = iota
+	// actionFlag flags an api not called for the unseen period
+	actionFlag
+	// actionUnflag clears the flag of an api called again
+	actionUnflag
+	// actionSoftDelete soft deletes a candidate past its grace period
+	actionSoftDelete
+)
+
+type deprecationReport struct {
+	// candidates are the apis which stay flagged
+	candidates          int
+	flagged             int
+	unflagged           int
+	softDeleted         int
+	apisWithStaleFields int
+	staleFields         int
+}
+
+// apiLastSeenAt returns the time of the latest call of the api, its creation
+// for the apis created before the calls were tracked.
+func apiLastSeenAt(api *entity.Api) *time.Time {
+	if api.LastSeenAt != nil {
+		return api.LastSeenAt
+	}
+	return api.CreatedAt
+}
+
+// isUnseen reports whether the api was not called during period.
+func isUnseen(api *entity.Api, now time.Time, period time.Duration) bool {
+	lastSeenAt := apiLastSeenAt(api)
+	return lastSeenAt != nil && now.Sub(*lastSeenAt) >= period
+}
+
+func decideApiAction(api *entity.Api, now time.Time, conf *config.Config) apiAction {
+	unseen := isUnseen(api, now, conf.Deprecation.UnseenPeriod)
+	switch {
+	case api.DeprecatedCandidateAt == nil && unseen:
+		return actionFlag
+	case api.DeprecatedCandidateAt == nil:
+		return actionNone
+	case !unseen:
+		return actionUnflag
+	case conf.Deprecation.SoftDelete && now.Sub(*api.DeprecatedCandidateAt) >= conf.Deprecation.GracePeriod:
+		return actionSoftDelete
+	}
+	return actionNone
+}
+
+// staleFields returns the fields not seen during period before the latest call
+// of their api, the fields of a stale field are left out.
+func staleFields(fieldsSeen []*entity.FieldSeen, apiLastSeenAt time.Time, period time.Duration) []string {
+	cutoff := apiLastSeenAt.Add(-period)
+	stale := make(map[string]bool)
+	for _, fieldSeen := range fieldsSeen {
+		if fieldSeen.LastSeenAt.Before(cutoff) {
+			stale[fieldSeen.Field] = true
+		}
+	}
+	fields := make([]string, 0, len(stale))
+	for field := range stale {
+		if !hasStaleParent(field, stale) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// hasStaleParent reports whether a field containing field is stale, e.g.
+// images for images[].url.
+func hasStaleParent(field string, stale map[string]bool) bool {
+	for i := len(field) - 1; i > 0; i-- {
+		if field[i] != '.' && !strings.HasPrefix(field[i:], "[]") {
+			continue
+		}
+		if stale[field[:i]] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package deprecation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/entity"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideApiAction(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 12, 31, 8, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+	conf := &config.Config{}
+	conf.Deprecation.UnseenPeriod = 30 * 24 * time.Hour
+	conf.Deprecation.GracePeriod = 30 * 24 * time.Hour
+	conf.Deprecation.SoftDelete = true
+	noSoftDelete := &config.Config{}
+	noSoftDelete.Deprecation = conf.Deprecation
+	noSoftDelete.Deprecation.SoftDelete = false
+	tests := []struct {
+		name string
+		api  *entity.Api
+		conf *config.Config
+		want apiAction
+	}{
+		{
+			name: "Test DecideApiAction - called recently",
+			api:  &entity.Api{LastSeenAt: daysAgo(1)},
+			conf: conf,
+			want: actionNone,
+		},
+		{
+			name: "Test DecideApiAction - not called for the unseen period",
+			api:  &entity.Api{LastSeenAt: daysAgo(31)},
+			conf: conf,
+			want: actionFlag,
+		},
+		{
+			name: "Test DecideApiAction - created before the calls were tracked",
+			api:  &entity.Api{BaseEntity: mongodbutils.BaseEntity{CreatedAt: daysAgo(40)}},
+			conf: conf,
+			want: actionFlag,
+		},
+		{
+			name: "Test DecideApiAction - candidate called again",
+			api:  &entity.Api{LastSeenAt: daysAgo(1), DeprecatedCandidateAt: daysAgo(10)},
+			conf: conf,
+			want: actionUnflag,
+		},
+		{
+			name: "Test DecideApiAction - candidate within its grace period",
+			api:  &entity.Api{LastSeenAt: daysAgo(50), DeprecatedCandidateAt: daysAgo(20)},
+			conf: conf,
+			want: actionNone,
+		},
+		{
+			name: "Test DecideApiAction - candidate past its grace period",
+			api:  &entity.Api{LastSeenAt: daysAgo(70), DeprecatedCandidateAt: daysAgo(40)},
+			conf: conf,
+			want: actionSoftDelete,
+		},
+		{
+			name: "Test DecideApiAction - candidate past its grace period without soft delete",
+			api:  &entity.Api{LastSeenAt: daysAgo(70), DeprecatedCandidateAt: daysAgo(40)},
+			conf: noSoftDelete,
+			want: actionNone,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, decideApiAction(tt.api, now, tt.conf))
+		})
+	}
+}
+
+func TestStaleFields(t *testing.T) {
+	t.Parallel()
+	lastSeenAt := time.Date(2024, 12, 31, 8, 0, 0, 0, time.UTC)
+	fieldsSeen := []*entity.FieldSeen{
+		{Field: "ad_id", LastSeenAt: lastSeenAt},
+		{Field: "images", LastSeenAt: lastSeenAt.AddDate(0, -2, 0)},
+		{Field: "images[].url", LastSeenAt: lastSeenAt.AddDate(0, -2, 0)},
+		{Field: "params", LastSeenAt: lastSeenAt},
+		{Field: "params.legacy_id", LastSeenAt: lastSeenAt.AddDate(0, -3, 0)},
+		{Field: "price", LastSeenAt: lastSeenAt.AddDate(0, 0, -10)},
+	}
+	require.Equal(t, []string{"images", "params.legacy_id"},
+		staleFields(fieldsSeen, lastSeenAt, 30*24*time.Hour))
+	require.Empty(t, staleFields(nil, lastSeenAt, 30*24*time.Hour))
+}
@@ -0,0 +1,163 @@
+package drift
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IDriftUC interface {
+	// Validate records the drifts of a call of api seen at seenAt from its
+	// structures, the response is nil when the call was not answered with a body.
+	Validate(
+		ctx context.Context,
+		api *entity.Api,
+		sampleRequest *entity.SampleRequest,
+		sampleResponse *entity.SampleResponse,
+		seenAt time.Time,
+	) error
+	// ReportDrift logs a summary of the drifts of each api seen during the
+	// report window.
+	ReportDrift(ctx context.Context) error
+}
+
+type driftUC struct {
+	conf    *config.Config
+	storage mongodb.MongoStorage
+
+	mu         sync.Mutex
+	structures map[primitive.ObjectID]*apiStructures
+}
+
+// apiStructures are the structures of an api calls are validated against.
+type apiStructures struct {
+	request     *entity.RequestStructure
+	response    *entity.ResponseStructure
+	statusCodes []int
+	loadedAt    time.Time
+}
+
+func NewDriftUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+) IDriftUC {
+	return &driftUC{
+		conf:       conf,
+		storage:    storage,
+		structures: make(map[primitive.ObjectID]*apiStructures),
+	}
+}
+
+func (uc *driftUC) Validate(
+	ctx context.Context,
+	api *entity.Api,
+	sampleRequest *entity.SampleRequest,
+	sampleResponse *entity.SampleResponse,
+	seenAt time.Time,
+) error {
+	structures, err := uc.getStructures(ctx, api.Id)
+	if err != nil {
+		return err
+	}
+	violations := make([]*entity.Violation, 0)
+	if structures.request != nil && sampleRequest != nil {
+		violations = append(violations, validateParameters(structures.request.Parameters, sampleRequest.Parameters)...)
+		violations = append(violations,
+			validateBody(constants.TypeRequest, structures.request.Schema(), sampleRequest.Body)...)
+	}
+	if structures.response != nil && sampleResponse != nil {
+		if !slices.Contains(structures.statusCodes, sampleResponse.HttpStatusCode) {
+			violations = append(violations, newStatusCodeViolation(sampleResponse.HttpStatusCode))
+		} else {
+			violations = append(violations,
+				validateBody(constants.TypeResponse, structures.response.Schema(), sampleResponse.Body)...)
+		}
+	}
+	violations = dedupeViolations(violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return uc.storage.RecordDrifts(ctx, api.Id, violations, seenAt)
+}
+
+// getStructures returns the structures of the api, cached for the configured
+// TTL as every call of the api is validated against them.
+func (uc *driftUC) getStructures(ctx context.Context, apiId primitive.ObjectID) (*apiStructures, error) {
+	uc.mu.Lock()
+	structures, ok := uc.structures[apiId]
+	uc.mu.Unlock()
+	if ok && time.Since(structures.loadedAt) < uc.conf.Drift.StructureTTL {
+		return structures, nil
+	}
+	requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	statusCodes, err := uc.storage.GetStatusCodesByApiId(ctx, apiId)
+	if err != nil {
+		return nil, err
+	}
+	structures = &apiStructures{
+		request:     requestStructure,
+		response:    responseStructure,
+		statusCodes: statusCodes,
+		loadedAt:    time.Now(),
+	}
+	uc.mu.Lock()
+	uc.structures[apiId] = structures
+	uc.mu.Unlock()
+	return structures, nil
+}
+
+func (uc *driftUC) ReportDrift(ctx context.Context) error {
+	from := time.Now().UTC().Add(-uc.conf.Drift.ReportWindow)
+	var summary *driftSummary
+	logSummary := func() error {
+		if summary == nil {
+			return nil
+		}
+		api, err := uc.storage.GetApiById(ctx, summary.apiId)
+		if err != nil {
+			return err
+		}
+		path := summary.apiId.Hex()
+		if api != nil {
+			path = api.Method + " " + api.Path
+		}
+		logctx.Warnw(ctx, "api drifted from its documentation",
+			"api", path, "drifts", summary.drifts, "violations", summary.violations, "kinds", summary.kinds)
+		return nil
+	}
+	apis := 0
+	err := uc.storage.IterateDrifts(ctx, from, func(drift *entity.Drift) error {
+		if summary == nil || summary.apiId != drift.ApiId {
+			if err := logSummary(); err != nil {
+				return err
+			}
+			summary = newDriftSummary(drift.ApiId)
+			apis++
+		}
+		summary.add(drift)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := logSummary(); err != nil {
+		return err
+	}
+	logctx.Infof(ctx, "%d apis drifted since %s", apis, from.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,249 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/spf13/cast"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// validateParameters returns the violations of the structure parameters by
+// the parameters of a call.
+func validateParameters(parameters []*entity.Parameter, sampleParameters []*entity.Parameter) []*entity.Violation {
+	violations := make([]*entity.Violation, 0)
+	declared := make(map[string]*entity.Parameter, len(parameters))
+	for _, parameter := range parameters {
+		declared[parameter.Name] = parameter
+	}
+	seen := make(map[string]bool, len(sampleParameters))
+	for _, sampleParameter := range sampleParameters {
+		seen[sampleParameter.Name] = true
+		parameter, ok := declared[sampleParameter.Name]
+		if !ok {
+			violations = append(violations, &entity.Violation{
+				Target:  constants.DriftTargetParameter,
+				Kind:    constants.DriftKindUndeclaredField,
+				Field:   sampleParameter.Name,
+				Message: "parameter is not documented",
+			})
+			continue
+		}
+		if !isParameterValue(parameter.Type, sampleParameter.Value) {
+			// the value is left out, the drifts are served as documentation
+			violations = append(violations, &entity.Violation{
+				Target:  constants.DriftTargetParameter,
+				Kind:    constants.DriftKindTypeMismatch,
+				Field:   sampleParameter.Name,
+				Message: fmt.Sprintf("expected %s, got string", parameter.Type),
+			})
+		}
+	}
+	for _, parameter := range parameters {
+		if parameter.Required && !seen[parameter.Name] {
+			violations = append(violations, &entity.Violation{
+				Target:  constants.DriftTargetParameter,
+				Kind:    constants.DriftKindMissingRequired,
+				Field:   parameter.Name,
+				Message: "required parameter is missing",
+			})
+		}
+	}
+	return violations
+}
+
+func isParameterValue(parameterType, value string) bool {
+	switch parameterType {
+	case constants.ParameterTypeInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case constants.ParameterTypeBoolean:
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	}
+	return true
+}
+
+// validateBody returns the violations of bodySchema by a JSON body, nothing is
+// validated when the structure or the call has no body.
+func validateBody(target string, bodySchema map[string]any, body string) []*entity.Violation {
+	if len(bodySchema) == 0 || body == "" {
+		return nil
+	}
+	var value any
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []*entity.Violation{{
+			Target:  target,
+			Kind:    constants.DriftKindTypeMismatch,
+			Field:   "$",
+			Message: "body is not JSON",
+		}}
+	}
+	violations := make([]*entity.Violation, 0)
+	validateValue(target, bodySchema, value, "$", &violations)
+	return violations
+}
+
+// validateValue adds the violations of schema by value, found at field, to
+// violations. A null matches any schema, the structures never infer nulls.
+func validateValue(target string, schema map[string]any, value any, field string, violations *[]*entity.Violation) {
+	if value == nil {
+		return
+	}
+	schemaType := cast.ToString(schema["type"])
+	valueType := jsonType(value)
+	if schemaType != "" && schemaType != valueType && !(schemaType == "number" && valueType == "integer") {
+		*violations = append(*violations, &entity.Violation{
+			Target:  target,
+			Kind:    constants.DriftKindTypeMismatch,
+			Field:   field,
+			Message: fmt.Sprintf("expected %s, got %s", schemaType, valueType),
+		})
+		return
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		properties, hasProperties := toMap(schema["properties"])
+		if hasProperties && !allowsAdditionalProperties(schema) {
+			for key := range value {
+				if _, ok := properties[key]; !ok {
+					*violations = append(*violations, &entity.Violation{
+						Target:  target,
+						Kind:    constants.DriftKindUndeclaredField,
+						Field:   field + "." + key,
+						Message: "field is not documented",
+					})
+				}
+			}
+		}
+		for _, key := range toStrings(schema["required"]) {
+			if _, ok := value[key]; !ok {
+				*violations = append(*violations, &entity.Violation{
+					Target:  target,
+					Kind:    constants.DriftKindMissingRequired,
+					Field:   field + "." + key,
+					Message: "required field is missing",
+				})
+			}
+		}
+		for key, item := range value {
+			if propertySchema, ok := toMap(properties[key]); ok {
+				validateValue(target, propertySchema, item, field+"."+key, violations)
+			}
+		}
+	case []any:
+		itemSchema, ok := toMap(schema["items"])
+		if !ok {
+			return
+		}
+		for _, item := range value {
+			validateValue(target, itemSchema, item, field+"[*]", violations)
+		}
+	}
+}
+
+// jsonType returns the schema type of a value decoded with UseNumber.
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
+
+func allowsAdditionalProperties(schema map[string]any) bool {
+	switch additionalProperties := schema["additionalProperties"].(type) {
+	case bool:
+		return additionalProperties
+	case nil:
+		return false
+	}
+	return true
+}
+
+// toMap accepts the schemas built in memory and the ones decoded from mongo.
+func toMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case primitive.M:
+		return m, true
+	}
+	return nil, false
+}
+
+// toStrings accepts the required lists built in memory and the ones decoded
+// from mongo.
+func toStrings(v any) []string {
+	if items, ok := v.(primitive.A); ok {
+		return cast.ToStringSlice([]any(items))
+	}
+	return cast.ToStringSlice(v)
+}
+
+func newStatusCodeViolation(statusCode int) *entity.Violation {
+	return &entity.Violation{
+		Target:  constants.TypeResponse,
+		Kind:    constants.DriftKindUnknownStatusCode,
+		Field:   strconv.Itoa(statusCode),
+		Message: "status code is not documented",
+	}
+}
+
+// dedupeViolations keeps the first violation of each target, kind and field,
+// the items of an array share their field.
+func dedupeViolations(violations []*entity.Violation) []*entity.Violation {
+	seen := make(map[string]bool, len(violations))
+	deduped := make([]*entity.Violation, 0, len(violations))
+	for _, violation := range violations {
+		key := violation.Target + " " + violation.Kind + " " + violation.Field
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, violation)
+	}
+	sort.SliceStable(deduped, func(i, j int) bool {
+		return deduped[i].Field < deduped[j].Field
+	})
+	return deduped
+}
+
+// driftSummary sums up the drifts of an api for the report.
+type driftSummary struct {
+	apiId      primitive.ObjectID
+	drifts     int
+	violations int64
+	kinds      map[string]int
+}
+
+func newDriftSummary(apiId primitive.ObjectID) *driftSummary {
+	return &driftSummary{
+		apiId: apiId,
+		kinds: make(map[string]int),
+	}
+}
+
+func (s *driftSummary) add(drift *entity.Drift) {
+	s.drifts++
+	s.violations += drift.Count
+	s.kinds[drift.Kind]++
+}
@@ -0,0 +1,86 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateBody(t *testing.T) {
+	t.Parallel()
+	bodySchema := map[string]any{
+		"type":     "object",
+		"required": primitive.A{"ad_id"},
+		"properties": map[string]any{
+			"ad_id":   map[string]any{"type": "integer"},
+			"subject": map[string]any{"type": "string"},
+			"params":  map[string]any{"type": "object", "additionalProperties": true, "properties": map[string]any{}},
+			"images": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"url": map[string]any{"type": "string"}},
+				},
+			},
+			"price": map[string]any{"type": "number"},
+		},
+	}
+	tests := []struct {
+		name           string
+		body           string
+		wantViolations []*entity.Violation
+	}{
+		{
+			name:           "Test ValidateBody - matching",
+			body:           `{"ad_id":1,"subject":null,"price":150000,"params":{"size":"m"},"images":[{"url":"1.jpg"}]}`,
+			wantViolations: []*entity.Violation{},
+		},
+		{
+			name: "Test ValidateBody - drifted",
+			body: `{"subject":1,"price":1.5,"images":[{"url":"1.jpg","width":400},{"url":2}],"is_sticky":true}`,
+			wantViolations: []*entity.Violation{
+				{Target: constants.TypeResponse, Kind: constants.DriftKindMissingRequired, Field: "$.ad_id", Message: "required field is missing"},
+				{Target: constants.TypeResponse, Kind: constants.DriftKindTypeMismatch, Field: "$.images[*].url", Message: "expected string, got integer"},
+				{Target: constants.TypeResponse, Kind: constants.DriftKindUndeclaredField, Field: "$.images[*].width", Message: "field is not documented"},
+				{Target: constants.TypeResponse, Kind: constants.DriftKindUndeclaredField, Field: "$.is_sticky", Message: "field is not documented"},
+				{Target: constants.TypeResponse, Kind: constants.DriftKindTypeMismatch, Field: "$.subject", Message: "expected string, got integer"},
+			},
+		},
+		{
+			name: "Test ValidateBody - not JSON",
+			body: `ad_id=1`,
+			wantViolations: []*entity.Violation{
+				{Target: constants.TypeResponse, Kind: constants.DriftKindTypeMismatch, Field: "$", Message: "body is not JSON"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			violations := dedupeViolations(validateBody(constants.TypeResponse, bodySchema, tt.body))
+			require.Equal(t, tt.wantViolations, violations)
+		})
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	t.Parallel()
+	parameters := []*entity.Parameter{
+		{Name: "limit", Type: constants.ParameterTypeInteger, In: constants.ParameterInQuery},
+		{Name: "cart_id", Type: constants.ParameterTypeString, In: constants.ParameterInQuery, Required: true},
+	}
+	sampleParameters := []*entity.Parameter{
+		{Name: "limit", Value: "all"},
+		{Name: "page", Value: "2"},
+	}
+	violations := validateParameters(parameters, sampleParameters)
+	require.Equal(t, []*entity.Violation{
+		{Target: constants.DriftTargetParameter, Kind: constants.DriftKindTypeMismatch, Field: "limit", Message: "expected integer, got string"},
+		{Target: constants.DriftTargetParameter, Kind: constants.DriftKindUndeclaredField, Field: "page", Message: "parameter is not documented"},
+		{Target: constants.DriftTargetParameter, Kind: constants.DriftKindMissingRequired, Field: "cart_id", Message: "required parameter is missing"},
+	}, violations)
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+)
+
+// openApiDocumentBufferSize is the size of the chunks the documents are
+// written in, as they are streamed by the live endpoint.
+const openApiDocumentBufferSize = 64 * 1024
+
+type IExportUC interface {
+	// Export writes the catalogue to req.Dir: the combined OpenAPI document,
+	// one per host and a page per operation, the documents being the ones
+	// served by the live endpoints.
+	Export(ctx context.Context, req *entity.ExportRequest) (*entity.ExportResponse, error)
+}
+
+type exportUC struct {
+	conf            *config.Config
+	storage         mongodb.MongoStorage
+	loadStructureUC loadstructure.ILoadstructure
+}
+
+func NewExportUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	loadStructureUC loadstructure.ILoadstructure,
+) IExportUC {
+	return &exportUC{
+		conf:            conf,
+		storage:         storage,
+		loadStructureUC: loadStructureUC,
+	}
+}
+
+func (uc *exportUC) Export(ctx context.Context, req *entity.ExportRequest) (*entity.ExportResponse, error) {
+	apis := make([]*entity.Api, 0)
+	err := uc.storage.IterateApisByFilter(ctx, &entity.ApiFilter{}, func(api *entity.Api) error {
+		apis = append(apis, api)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.writeDocument(ctx, filepath.Join(req.Dir, "openapi.json"), &entity.LoadOpenApiDocumentRequest{
+		Version: req.Version,
+	}); err != nil {
+		return nil, err
+	}
+	document := &entity.LoadStructureByApiIdResponse{}
+	document.LoadDefault()
+	index := &indexPage{Title: document.Info.Title}
+	hosts := make(map[string]*indexHost)
+	extension := pageExtension(req.Pages)
+	operations := 0
+	for _, api := range apis {
+		host, ok := hosts[api.Host]
+		if !ok {
+			host = &indexHost{Name: hostDirName(api.Host)}
+			// an empty host filters nothing, its apis are only in the combined document
+			if api.Host != "" {
+				host.Document = filepath.ToSlash(filepath.Join("..", "hosts", api.Host+".json"))
+				if err := uc.writeDocument(ctx, filepath.Join(req.Dir, "hosts", api.Host+".json"),
+					&entity.LoadOpenApiDocumentRequest{
+						ApiFilter: entity.ApiFilter{Host: api.Host},
+						Version:   req.Version,
+					}); err != nil {
+					return nil, err
+				}
+			}
+			hosts[api.Host] = host
+			index.Hosts = append(index.Hosts, host)
+		}
+		pages, err := uc.buildPages(ctx, api, req.Version)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range pages {
+			file := filepath.Join(host.Name, operationFileName(page.Method, page.Path, extension))
+			if err := writeFile(filepath.Join(req.Dir, "operations", file), func(w *bufio.Writer) error {
+				return renderOperationPage(w, page, req.Pages)
+			}); err != nil {
+				return nil, err
+			}
+			host.Operations = append(host.Operations, &indexOperation{
+				Method: page.Method,
+				Path:   page.Path,
+				Title:  page.Title,
+				File:   filepath.ToSlash(file),
+			})
+			operations++
+		}
+	}
+	if err := writeFile(filepath.Join(req.Dir, "operations", "index."+extension), func(w *bufio.Writer) error {
+		return renderIndexPage(w, index, req.Pages)
+	}); err != nil {
+		return nil, err
+	}
+	logctx.Infow(ctx, "exported documentation", "dir", req.Dir, "hosts", len(hosts), "operations", operations)
+	return &entity.ExportResponse{
+		Dir:        req.Dir,
+		Hosts:      len(hosts),
+		Operations: operations,
+	}, nil
+}
+
+// writeDocument writes the OpenAPI document of the apis matching req the way
+// the live endpoint streams it.
+func (uc *exportUC) writeDocument(ctx context.Context, path string, req *entity.LoadOpenApiDocumentRequest) error {
+	return writeFile(path, func(w *bufio.Writer) error {
+		return uc.loadStructureUC.LoadOpenApiDocument(ctx, req, w)
+	})
+}
+
+// buildPages renders the operations of the document of an api.
+func (uc *exportUC) buildPages(ctx context.Context, api *entity.Api, version string) ([]*operationPage, error) {
+	resp, err := uc.loadStructureUC.LoadStructureByApiId(ctx, &entity.LoadStructureByApiIdRequest{
+		ApiId:   api.Id.Hex(),
+		Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]any{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return buildOperationPages(document, api.Host), nil
+}
+
+// writeFile creates the file at path, and its directory, with the content fn
+// writes.
+func writeFile(path string, fn func(w *bufio.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(file, openApiDocumentBufferSize)
+	if err := fn(w); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
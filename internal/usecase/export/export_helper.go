@@ -0,0 +1,334 @@
+package export
+
+import (
+	"embed"
+	"encoding/json"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	"github.com/spf13/cast"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var templateFuncs = map[string]any{
+	"join":   strings.Join,
+	"cell":   markdownCell,
+	"indent": func(depth int) string { return strings.Repeat("  ", depth) },
+}
+
+var (
+	markdownTemplates = texttemplate.Must(texttemplate.New("").Funcs(templateFuncs).
+				ParseFS(templatesFS, "templates/*.md.tmpl"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(templateFuncs).
+			ParseFS(templatesFS, "templates/*.html.tmpl"))
+)
+
+// httpMethods are the operations of a path item, in the order they are rendered.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var nonFileNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// operationPage is the rendering of an operation of an OpenAPI document.
+type operationPage struct {
+	Title       string
+	Method      string
+	Path        string
+	Host        string
+	OperationId string
+	Description string
+	Tags        []string
+	Parameters  []*pageParameter
+	RequestBody *pageBody
+	Responses   []*pageResponse
+}
+
+type pageParameter struct {
+	Name        string
+	In          string
+	Type        string
+	Required    bool
+	Description string
+	Example     string
+}
+
+// pageBody is a media type, its schema flattened into a tree of fields.
+type pageBody struct {
+	Type     string
+	Fields   []*pageField
+	Examples []*pageExample
+}
+
+type pageField struct {
+	// Depth is the nesting of the field, 0 for the members of the body
+	Depth       int
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+type pageExample struct {
+	Name    string
+	Summary string
+	// Value is the indented JSON of the example
+	Value string
+}
+
+type pageResponse struct {
+	StatusCode  string
+	Description string
+	Body        *pageBody
+}
+
+// indexPage lists the operation pages of each host.
+type indexPage struct {
+	Title string
+	Hosts []*indexHost
+}
+
+type indexHost struct {
+	Name string
+	// Document is the path of the OpenAPI document of the host, relative to
+	// the index, "" when the host has none
+	Document   string
+	Operations []*indexOperation
+}
+
+type indexOperation struct {
+	Method string
+	Path   string
+	Title  string
+	// File is the path of the page, relative to the index
+	File string
+}
+
+// buildOperationPages renders the operations of a document, the document
+// being decoded from its JSON.
+func buildOperationPages(document map[string]any, host string) []*operationPage {
+	pages := make([]*operationPage, 0)
+	paths, _ := document["paths"].(map[string]any)
+	for _, path := range mapsutils.SortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]any)
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			pages = append(pages, buildOperationPage(host, strings.ToUpper(method), path, operation))
+		}
+	}
+	return pages
+}
+
+func buildOperationPage(host, method, path string, operation map[string]any) *operationPage {
+	page := &operationPage{
+		Title:       cast.ToString(operation["summary"]),
+		Method:      method,
+		Path:        path,
+		Host:        host,
+		OperationId: cast.ToString(operation["operationId"]),
+		Description: cast.ToString(operation["description"]),
+		Tags:        cast.ToStringSlice(operation["tags"]),
+		Parameters:  make([]*pageParameter, 0),
+		Responses:   make([]*pageResponse, 0),
+	}
+	if page.Title == "" {
+		page.Title = method + " " + path
+	}
+	parameters, _ := operation["parameters"].([]any)
+	for _, item := range parameters {
+		parameter, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		schema, _ := parameter["schema"].(map[string]any)
+		page.Parameters = append(page.Parameters, &pageParameter{
+			Name:        cast.ToString(parameter["name"]),
+			In:          cast.ToString(parameter["in"]),
+			Type:        schemaTypeName(schema),
+			Required:    cast.ToBool(parameter["required"]),
+			Description: cast.ToString(parameter["description"]),
+			Example:     parameterExample(parameter),
+		})
+	}
+	if requestBody, ok := operation["requestBody"].(map[string]any); ok {
+		page.RequestBody = buildPageBody(requestBody["content"])
+	}
+	responses, _ := operation["responses"].(map[string]any)
+	for _, statusCode := range mapsutils.SortedKeys(responses) {
+		response, _ := responses[statusCode].(map[string]any)
+		page.Responses = append(page.Responses, &pageResponse{
+			StatusCode:  statusCode,
+			Description: cast.ToString(response["description"]),
+			Body:        buildPageBody(response["content"]),
+		})
+	}
+	return page
+}
+
+// parameterExample returns the first named example of a parameter, or its
+// example.
+func parameterExample(parameter map[string]any) string {
+	examples, _ := parameter["examples"].(map[string]any)
+	for _, name := range mapsutils.SortedKeys(examples) {
+		example, _ := examples[name].(map[string]any)
+		return cast.ToString(example["value"])
+	}
+	return cast.ToString(parameter["example"])
+}
+
+// buildPageBody renders the JSON media type of a content object, nil when
+// there is none.
+func buildPageBody(content any) *pageBody {
+	mediaTypes, _ := content.(map[string]any)
+	mediaType, ok := mediaTypes[constants.MIMEApplicationJSON].(map[string]any)
+	if !ok {
+		return nil
+	}
+	// a media type without schema takes any content
+	schema, ok := mediaType["schema"].(map[string]any)
+	if !ok {
+		schema = map[string]any{}
+	}
+	body := &pageBody{
+		Type:     schemaTypeName(schema),
+		Fields:   make([]*pageField, 0),
+		Examples: make([]*pageExample, 0),
+	}
+	collectPageFields(schema, 0, &body.Fields)
+	examples, _ := mediaType["examples"].(map[string]any)
+	for _, name := range mapsutils.SortedKeys(examples) {
+		example, _ := examples[name].(map[string]any)
+		value, err := json.MarshalIndent(example["value"], "", "  ")
+		if err != nil {
+			continue
+		}
+		body.Examples = append(body.Examples, &pageExample{
+			Name:    name,
+			Summary: cast.ToString(example["summary"]),
+			Value:   string(value),
+		})
+	}
+	return body
+}
+
+// collectPageFields adds the properties of schema, and of its items when it is
+// an array, to fields, each followed by its own properties.
+func collectPageFields(schema map[string]any, depth int, fields *[]*pageField) {
+	if schema == nil {
+		return
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		collectPageFields(items, depth, fields)
+		return
+	}
+	allOf, _ := schema["allOf"].([]any)
+	for _, item := range allOf {
+		subschema, _ := item.(map[string]any)
+		collectPageFields(subschema, depth, fields)
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	for _, name := range cast.ToStringSlice(schema["required"]) {
+		required[name] = true
+	}
+	for _, name := range mapsutils.SortedKeys(properties) {
+		property, _ := properties[name].(map[string]any)
+		*fields = append(*fields, &pageField{
+			Depth:       depth,
+			Name:        name,
+			Type:        schemaTypeName(property),
+			Required:    required[name],
+			Description: cast.ToString(property["description"]),
+		})
+		collectPageFields(property, depth+1, fields)
+	}
+}
+
+// schemaTypeName describes the type of a schema in both dialects, e.g.
+// "array of string (uuid)" or "integer | null".
+func schemaTypeName(schema map[string]any) string {
+	if schema == nil {
+		return ""
+	}
+	types := make([]string, 0)
+	for _, schemaType := range cast.ToStringSlice(schema["type"]) {
+		if schemaType == "array" {
+			items, _ := schema["items"].(map[string]any)
+			if itemsType := schemaTypeName(items); itemsType != "" {
+				schemaType = "array of " + itemsType
+			}
+		} else if format := cast.ToString(schema["format"]); format != "" && schemaType != "null" {
+			schemaType += " (" + format + ")"
+		}
+		types = append(types, schemaType)
+	}
+	if len(types) == 0 {
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			subschemas, _ := schema[keyword].([]any)
+			for _, item := range subschemas {
+				subschema, _ := item.(map[string]any)
+				types = append(types, schemaTypeName(subschema))
+			}
+		}
+	}
+	if len(types) == 0 {
+		types = append(types, "any")
+	}
+	if cast.ToBool(schema["nullable"]) {
+		types = append(types, "null")
+	}
+	return strings.Join(types, " | ")
+}
+
+// operationFileName names the page of an operation, e.g. get_v1_ads_id.md.
+func operationFileName(method, path, extension string) string {
+	name := strings.Trim(nonFileNameRegexp.ReplaceAllString(path, "_"), "_")
+	if name == "" {
+		name = "root"
+	}
+	return strings.ToLower(method) + "_" + name + "." + extension
+}
+
+// hostDirName names the directory of the pages of a host.
+func hostDirName(host string) string {
+	if host == "" {
+		return "default"
+	}
+	return host
+}
+
+// pageExtension returns the file extension of the pages of a format.
+func pageExtension(pages string) string {
+	if pages == constants.ExportPagesHTML {
+		return "html"
+	}
+	return "md"
+}
+
+func renderOperationPage(w io.Writer, page *operationPage, pages string) error {
+	if pages == constants.ExportPagesHTML {
+		return htmlTemplates.ExecuteTemplate(w, "operation.html.tmpl", page)
+	}
+	return markdownTemplates.ExecuteTemplate(w, "operation.md.tmpl", page)
+}
+
+func renderIndexPage(w io.Writer, page *indexPage, pages string) error {
+	if pages == constants.ExportPagesHTML {
+		return htmlTemplates.ExecuteTemplate(w, "index.html.tmpl", page)
+	}
+	return markdownTemplates.ExecuteTemplate(w, "index.md.tmpl", page)
+}
+
+// markdownCell escapes a value written in a cell of a Markdown table.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/stretchr/testify/require"
+)
+
+// operationDocument is the document of an api as served by the live endpoint.
+const operationDocument = `{
+	"openapi": "3.0.0",
+	"paths": {
+		"/v1/ads/{id}": {
+			"servers": [{"url": "gateway.chotot.org"}],
+			"get": {
+				"operationId": "64b7f0c2a1b2c3d4e5f60718",
+				"summary": "Get an ad",
+				"tags": ["gateway.chotot.org/ads"],
+				"parameters": [
+					{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+					{"name": "fields", "in": "query", "schema": {"type": "string"},
+						"examples": {"sample_1": {"value": "id|subject"}}}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"content": {"application/json": {
+							"schema": {
+								"type": "object",
+								"required": ["ad_id"],
+								"properties": {
+									"ad_id": {"type": "integer", "description": "id of the ad"},
+									"images": {"type": "array", "items": {
+										"type": "object",
+										"properties": {"url": {"type": "string", "format": "uri"}}
+									}},
+									"price": {"type": "number", "nullable": true}
+								}
+							},
+							"examples": {"sample_1": {"summary": "Recorded at 2024-01-02 03:04:05 UTC", "value": {"ad_id": 1}}}
+						}}
+					},
+					"404": {"description": "Not Found", "content": {"application/json": {}}}
+				}
+			}
+		}
+	}
+}`
+
+func TestBuildOperationPages(t *testing.T) {
+	t.Parallel()
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(operationDocument), &document))
+	pages := buildOperationPages(document, "gateway.chotot.org")
+	require.Equal(t, []*operationPage{{
+		Title:       "Get an ad",
+		Method:      "GET",
+		Path:        "/v1/ads/{id}",
+		Host:        "gateway.chotot.org",
+		OperationId: "64b7f0c2a1b2c3d4e5f60718",
+		Tags:        []string{"gateway.chotot.org/ads"},
+		Parameters: []*pageParameter{
+			{Name: "id", In: "path", Type: "integer", Required: true},
+			{Name: "fields", In: "query", Type: "string", Example: "id|subject"},
+		},
+		Responses: []*pageResponse{
+			{
+				StatusCode:  "200",
+				Description: "OK",
+				Body: &pageBody{
+					Type: "object",
+					Fields: []*pageField{
+						{Depth: 0, Name: "ad_id", Type: "integer", Required: true, Description: "id of the ad"},
+						{Depth: 0, Name: "images", Type: "array of object"},
+						{Depth: 1, Name: "url", Type: "string (uri)"},
+						{Depth: 0, Name: "price", Type: "number | null"},
+					},
+					Examples: []*pageExample{
+						{Name: "sample_1", Summary: "Recorded at 2024-01-02 03:04:05 UTC", Value: "{\n  \"ad_id\": 1\n}"},
+					},
+				},
+			},
+			{
+				StatusCode:  "404",
+				Description: "Not Found",
+				Body:        &pageBody{Type: "any", Fields: []*pageField{}, Examples: []*pageExample{}},
+			},
+		},
+	}}, pages)
+}
+
+func TestRenderOperationPage_Markdown(t *testing.T) {
+	t.Parallel()
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(operationDocument), &document))
+	page := buildOperationPages(document, "gateway.chotot.org")[0]
+	buffer := &bytes.Buffer{}
+	require.NoError(t, renderOperationPage(buffer, page, constants.ExportPagesMarkdown))
+	require.Equal(t, "# Get an ad\n"+
+		"\n"+
+		"`GET /v1/ads/{id}` on `gateway.chotot.org`\n"+
+		"\n"+
+		"Tags: gateway.chotot.org/ads\n"+
+		"\n"+
+		"## Parameters\n"+
+		"\n"+
+		"| Name | In | Type | Required | Description | Example |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `id` | path | integer | yes |  |  |\n"+
+		"| `fields` | query | string | no |  | `id\\|subject` |\n"+
+		"\n"+
+		"## Request body\n"+
+		"\n"+
+		"No body.\n"+
+		"\n"+
+		"## Responses\n"+
+		"\n"+
+		"### 200 OK\n"+
+		"\n"+
+		"Type: object\n"+
+		"\n"+
+		"- `ad_id` integer, required: id of the ad\n"+
+		"- `images` array of object\n"+
+		"  - `url` string (uri)\n"+
+		"- `price` number | null\n"+
+		"\n"+
+		"Example `sample_1`, Recorded at 2024-01-02 03:04:05 UTC:\n"+
+		"\n"+
+		"```json\n"+
+		"{\n"+
+		"  \"ad_id\": 1\n"+
+		"}\n"+
+		"```\n"+
+		"\n"+
+		"### 404 Not Found\n"+
+		"\n"+
+		"Type: any\n", buffer.String())
+}
+
+func TestRenderOperationPage_HTML(t *testing.T) {
+	t.Parallel()
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(operationDocument), &document))
+	page := buildOperationPages(document, "gateway.chotot.org")[0]
+	page.Description = "<script>alert(1)</script>"
+	buffer := &bytes.Buffer{}
+	require.NoError(t, renderOperationPage(buffer, page, constants.ExportPagesHTML))
+	html := buffer.String()
+	require.Contains(t, html, `<li style="margin-left: 1em"><code>url</code> string (uri)</li>`)
+	require.Contains(t, html, `<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`)
+	require.Contains(t, html, "<pre><code>{\n  &#34;ad_id&#34;: 1\n}</code></pre>")
+}
+
+func TestOperationFileName(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "get_v1_ads_id_images.md", operationFileName("GET", "/v1/ads/{id}/images", "md"))
+	require.Equal(t, "post_v1_ads_uuid_publish.html", operationFileName("POST", "/v1/ads/{uuid}:publish", "html"))
+	require.Equal(t, "get_root.md", operationFileName("GET", "/", "md"))
+}
@@ -6,6 +6,7 @@ import (
 	"compress/gzip"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"regexp"
@@ -24,10 +25,11 @@ import (
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
 	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	"github.com/ct-logic-api-document/internal/usecase/drift"
+	"github.com/ct-logic-api-document/pkg/redact"
 	gcsutils "github.com/ct-logic-api-document/utils/gcs"
 	utilslocal "github.com/ct-logic-api-document/utils/local"
 	"github.com/goccy/go-json"
-	"github.com/spf13/cast"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 )
@@ -47,6 +49,15 @@ var bufferPool = sync.Pool{
 
 type IFetchDataUC interface {
 	FetchDataFromGcs(ctx context.Context) error
+	// ImportPostmanCollection ingests the saved examples of a Postman
+	// collection as calls logged by kong.
+	ImportPostmanCollection(ctx context.Context, collection *entity.PostmanCollection) (*entity.ImportPostmanResponse, error)
+	// GetRejections returns the log lines the ingestion rejected and their
+	// number by reason.
+	GetRejections(ctx context.Context, req *entity.GetRejectionsRequest) (*entity.GetRejectionsResponse, error)
+	// ReplayRejections ingests the rejected lines again, the ones ingested are
+	// removed and the others recorded with their new reason.
+	ReplayRejections(ctx context.Context) error
 
 	// testing
 	FetchDataFromLocal(ctx context.Context) error
@@ -54,17 +65,22 @@ type IFetchDataUC interface {
 }
 
 type fetchDataUC struct {
-	conf    *config.Config
-	storage mongodb.MongoStorage
+	conf     *config.Config
+	storage  mongodb.MongoStorage
+	driftUC  drift.IDriftUC
+	redactor *redact.Redactor
 }
 
 func NewFetchDataUC(
 	conf *config.Config,
 	storage mongodb.MongoStorage,
+	driftUC drift.IDriftUC,
 ) IFetchDataUC {
 	return &fetchDataUC{
-		conf:    conf,
-		storage: storage,
+		conf:     conf,
+		storage:  storage,
+		driftUC:  driftUC,
+		redactor: redact.New(conf.Samples.RedactKeys),
 	}
 }
 
@@ -166,7 +182,7 @@ func (f *fetchDataUC) processFile(ctx context.Context, bucket *storage.BucketHan
 		// process the job
 		line := line
 		pool.Run(func() error {
-			return f.ProcessLogLine(ctx, line)
+			return f.processLine(ctx, line)
 		})
 	}
 
@@ -178,7 +194,7 @@ func (f *fetchDataUC) processFile(ctx context.Context, bucket *storage.BucketHan
 	return nil
 }
 
-func (f *fetchDataUC) readFile(ctx context.Context, bucket *storage.BucketHandle, file string) ([][]byte, error) {
+func (f *fetchDataUC) readFile(ctx context.Context, bucket *storage.BucketHandle, file string) ([]*sourceLine, error) {
 	// read the file
 	// send the data to the jobs channel
 	logctx.Infow(ctx, "reading file", "file", file)
@@ -210,39 +226,54 @@ func (f *fetchDataUC) readFile(ctx context.Context, bucket *storage.BucketHandle
 	scanner := bufio.NewScanner(decompressed)
 	buffer := bufferPool.Get().([]byte)
 	scanner.Buffer(buffer, 5*1024*1024) // 5MB max line length
-	resp := make([][]byte, 0)
 	defer bufferPool.Put(&buffer)
+	return f.scanLines(ctx, scanner, file), nil
+}
+
+// scanLines pre processes the lines of source, the rejected ones are dead
+// lettered and left out.
+func (f *fetchDataUC) scanLines(ctx context.Context, scanner *bufio.Scanner, source string) []*sourceLine {
+	resp := make([]*sourceLine, 0)
+	number := 0
 	for scanner.Scan() {
+		number++
 		lineBytes := scanner.Bytes()
 		line, err := f.preProcessLine(ctx, lineBytes)
+		if errors.Is(err, errIgnoredLine) {
+			logctx.Infow(ctx, "log line ignored", "source", source, "line", number, "err", err)
+			continue
+		}
 		if err != nil {
-			logctx.Errorw(ctx, "failed to pre process line", "err", err)
+			rejected := &sourceLine{source: source, number: number, data: lineBytes}
+			if err := f.rejectLine(ctx, rejected, constants.RejectionStagePreProcess, err); err != nil {
+				logctx.Errorw(ctx, "failed to store rejected line", "err", err)
+			}
 			continue
 		}
-		resp = append(resp, line)
+		resp = append(resp, &sourceLine{source: source, number: number, data: line})
 	}
-	return resp, nil
+	return resp
 }
 
 func (f *fetchDataUC) preProcessLine(_ context.Context, line []byte) ([]byte, error) {
 	// pre process the line
 	skip, utf8Log := skipOrFixUtf8(line)
 	if skip {
-		return nil, errors.New("invalid utf8")
+		return nil, reject(constants.RejectionReasonInvalidUtf8, errors.New("invalid utf8"))
 	}
 	logLine := string(utf8Log)
 	if strings.Contains(logLine, "Content-Type: image") { // temporary fix for image content type
-		return nil, errors.New("image content type")
+		return nil, fmt.Errorf("%w: image content type", errIgnoredLine)
 	}
 	if strings.Contains(logLine, ".lua") { // temporary fix for application/octet-stream content type
-		return nil, errors.New("ignore kong debug")
+		return nil, fmt.Errorf("%w: kong debug", errIgnoredLine)
 	}
 	if strings.Contains(logLine, "config?check_hash") { // temporary fix for kong
-		return nil, errors.New("ignore kong config")
+		return nil, fmt.Errorf("%w: kong config", errIgnoredLine)
 	}
 	ll, err := strconv.Unquote(`"` + logLine + `"`)
 	if err != nil {
-		return nil, err
+		return nil, reject(constants.RejectionReasonInvalidEscape, err)
 	}
 	extractJSON, _, _ := f.extractJSON([]byte(ll))
 	return extractJSON, nil
@@ -263,6 +294,23 @@ func (f *fetchDataUC) ProcessLogLine(ctx context.Context, logLine []byte) error
 	return nil
 }
 
+// processLine ingests a line read from a file, a rejected line is dead
+// lettered instead of failing its file.
+func (f *fetchDataUC) processLine(ctx context.Context, line *sourceLine) error {
+	err := f.ProcessLogLine(ctx, line.data)
+	if err == nil {
+		return nil
+	}
+	return f.rejectLine(ctx, line, constants.RejectionStageProcess, err)
+}
+
+func (f *fetchDataUC) rejectLine(ctx context.Context, line *sourceLine, stage string, err error) error {
+	rejection := newRejection(line, stage, err, f.redactor, f.conf.Rejections.MaxLineSize, time.Now().UTC())
+	logctx.Warnw(ctx, "log line rejected",
+		"source", line.source, "line", line.number, "reason", rejection.Reason, "err", err)
+	return f.storage.RecordRejection(ctx, rejection)
+}
+
 func skipOrFixUtf8(log []byte) (bool, []byte) {
 	if utf8.ValidString(string(log)) {
 		return false, log
@@ -279,11 +327,11 @@ func (f *fetchDataUC) parseLogIntoStruct(ctx context.Context, log string) (conta
 	logObject := container.Map{}
 	if err := json.Unmarshal([]byte(log), &logObject); err != nil {
 		logctx.Errorw(ctx, "failed to unmarshal log", "err", err)
-		return nil, err
+		return nil, reject(constants.RejectionReasonInvalidJson, err)
 	}
 	if err := f.validateLogObject(logObject); err != nil {
 		logctx.Errorw(ctx, "failed to validate log object", "err", err)
-		return nil, err
+		return nil, reject(constants.RejectionReasonInvalidLog, err)
 	}
 	return logObject, nil
 }
@@ -342,57 +390,109 @@ func (f *fetchDataUC) storeLog(ctx context.Context, logObject container.Map) err
 		return err
 	}
 	if api == nil {
+		// an api soft deleted as deprecated is called again
+		api, err = f.storage.RestoreApiByPath(ctx, updatedPath)
+		if err != nil {
+			return err
+		}
+	}
+	seenAt := getStartedAt(logObject)
+	isNewApi := api == nil
+	if isNewApi {
 		// create api
 		api = &entity.Api{
 			Host:                 host,
 			Path:                 updatedPath,
 			Method:               request["method"].(string),
 			LatestBuildStructure: nil,
+			LastSeenAt:           &seenAt,
 		}
 		if err := f.storage.CreateApi(ctx, api); err != nil {
 			return err
 		}
-	}
-	if err := f.storeSampleRequest(ctx, api, request); err != nil {
+	} else if err := f.storage.UpdateApiLastSeenAt(ctx, api.Id, seenAt); err != nil {
 		return err
 	}
+	correlationId := getCorrelationId(logObject)
+	sampleRequest := newSampleRequest(api, request, correlationId)
 	response := logObject["response"].(map[string]any)
-	if err := f.storeSampleResponse(ctx, api, response); err != nil {
+	sampleResponse := newSampleResponse(api, response, correlationId, getLatencyMs(logObject))
+	if sampleResponse == nil {
+		logctx.Infow(ctx, "response body is nil", "response", response)
+	}
+	if err := f.storage.CreateSampleRequest(ctx, sampleRequest); err != nil {
 		return err
 	}
+	if sampleResponse != nil {
+		if err := f.storage.CreateSampleResponse(ctx, sampleResponse); err != nil {
+			return err
+		}
+	}
+	// the counters come last and do not reject the log, a rejected log is
+	// replayed and would be counted twice
+	f.recordCounters(ctx, api, logObject, sampleRequest, sampleResponse, seenAt, isNewApi)
 	return nil
 }
 
-func (f *fetchDataUC) storeSampleRequest(ctx context.Context, api *entity.Api, request container.Map) error {
-	sampleRequest := &entity.SampleRequest{
-		ApiId: api.Id,
+// recordCounters counts a stored log into the drifts, the traffic stats and
+// the consumers of its api.
+func (f *fetchDataUC) recordCounters(
+	ctx context.Context,
+	api *entity.Api,
+	logObject container.Map,
+	sampleRequest *entity.SampleRequest,
+	sampleResponse *entity.SampleResponse,
+	seenAt time.Time,
+	isNewApi bool,
+) {
+	// a new api has no structure to drift from
+	if f.conf.Ingestion.Mode == constants.IngestionModeValidate && !isNewApi {
+		if err := f.driftUC.Validate(ctx, api, sampleRequest, sampleResponse, seenAt); err != nil {
+			logctx.Errorw(ctx, "failed to validate log", "api", api.Path, "err", err)
+		}
 	}
-	if request["body"] != nil {
-		sampleRequest.Body = request["body"].(string)
+	record, isTraffic := newTrafficRecord(logObject)
+	if !isTraffic {
+		return
 	}
-	if request["querystring"] != nil {
-		sampleRequest.Parameters = buildParametersFromQueryString(request["querystring"].(map[string]any))
+	if err := f.storage.RecordTraffic(ctx, api.Id, record); err != nil {
+		logctx.Errorw(ctx, "failed to record traffic", "api", api.Path, "err", err)
 	}
-	if err := f.storage.CreateSampleRequest(ctx, sampleRequest); err != nil {
-		return err
+	usages := newConsumerUsages(newClientIdentity(logObject), sampleRequest)
+	if err := f.storage.RecordConsumerUsages(ctx, api.Id, usages, seenAt); err != nil {
+		logctx.Errorw(ctx, "failed to record consumer usages", "api", api.Path, "err", err)
 	}
-	return nil
 }
 
-func (f *fetchDataUC) storeSampleResponse(ctx context.Context, api *entity.Api, response container.Map) error {
-	if response["body"] == nil {
-		logctx.Infow(ctx, "response body is nil", "response", response)
+func (f *fetchDataUC) ImportPostmanCollection(
+	ctx context.Context,
+	collection *entity.PostmanCollection,
+) (*entity.ImportPostmanResponse, error) {
+	resp := &entity.ImportPostmanResponse{}
+	variables := make(map[string]string, len(collection.Variable))
+	for _, variable := range collection.Variable {
+		variables[variable.Key] = variable.Value
+	}
+	err := walkPostmanItems(collection.Item, func(item *entity.PostmanItem) error {
+		for _, example := range item.Response {
+			resp.Examples++
+			logObject, ok := newPostmanLogObject(item.Request, example, variables)
+			if !ok {
+				logctx.Warnw(ctx, "postman example skipped, its url is not resolved", "item", item.Name, "example", example.Name)
+				resp.Skipped++
+				continue
+			}
+			if err := f.storeLog(ctx, logObject); err != nil {
+				return err
+			}
+			resp.Imported++
+		}
 		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	sampleResponse := &entity.SampleResponse{
-		ApiId:          api.Id,
-		HttpStatusCode: cast.ToInt(response["status"]),
-		Body:           response["body"].(string),
-	}
-	if err := f.storage.CreateSampleResponse(ctx, sampleResponse); err != nil {
-		return err
-	}
-	return nil
+	return resp, nil
 }
 
 func (f *fetchDataUC) FetchDataFromLocal(ctx context.Context) error {
@@ -417,7 +517,7 @@ func (f *fetchDataUC) FetchDataFromLocal(ctx context.Context) error {
 			ctx := context.Background()
 			ctx = httpkit.InjectCorrelationIDToContext(ctx, httpkit.GenerateCorrelationID())
 			pool.Run(func() error {
-				return f.ProcessLogLine(ctx, line)
+				return f.processLine(ctx, line)
 			})
 		}
 		if err := pool.Wait(); err != nil {
@@ -428,7 +528,7 @@ func (f *fetchDataUC) FetchDataFromLocal(ctx context.Context) error {
 	return nil
 }
 
-func (f *fetchDataUC) readFileLocal(ctx context.Context, filePath string) ([][]byte, error) {
+func (f *fetchDataUC) readFileLocal(ctx context.Context, filePath string) ([]*sourceLine, error) {
 	// read the file
 	// send the data to the jobs channel
 	logctx.Infow(ctx, "reading file", "file", filePath)
@@ -451,17 +551,71 @@ func (f *fetchDataUC) readFileLocal(ctx context.Context, filePath string) ([][]b
 			return nil, err
 		}
 		scanner := bufio.NewScanner(decompressed)
-		resp := make([][]byte, 0)
-		for scanner.Scan() {
-			lineBytes := scanner.Bytes()
-			line, err := f.preProcessLine(ctx, lineBytes)
+		return f.scanLines(ctx, scanner, filePath), nil
+	}
+	return nil, errors.New("file is not gzipped")
+}
+
+func (f *fetchDataUC) GetRejections(
+	ctx context.Context,
+	req *entity.GetRejectionsRequest,
+) (*entity.GetRejectionsResponse, error) {
+	rejections, err := f.storage.GetRejections(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if rejections == nil {
+		rejections = []*entity.Rejection{}
+	}
+	total, err := f.storage.CountRejections(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := f.storage.CountRejectionsByReason(ctx, req.Source)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.GetRejectionsResponse{
+		Counts:     counts,
+		Rejections: rejections,
+		Total:      total,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}, nil
+}
+
+func (f *fetchDataUC) ReplayRejections(ctx context.Context) error {
+	var replayed, rejected, ignored, skipped int
+	err := f.storage.IterateRejections(ctx, func(rejection *entity.Rejection) error {
+		if rejection.Truncated || rejection.RawLine == "" {
+			skipped++
+			return nil
+		}
+		line := &sourceLine{
+			source: rejection.Source,
+			number: rejection.LineNumber,
+			data:   []byte(rejection.RawLine),
+		}
+		if rejection.Stage == constants.RejectionStagePreProcess {
+			data, err := f.preProcessLine(ctx, line.data)
+			if errors.Is(err, errIgnoredLine) {
+				ignored++
+				return f.storage.DeleteRejection(ctx, rejection.Id)
+			}
 			if err != nil {
-				logctx.Errorw(ctx, "failed to pre process line", "err", err)
-				continue
+				rejected++
+				return f.rejectLine(ctx, line, constants.RejectionStagePreProcess, err)
 			}
-			resp = append(resp, line)
+			line.data = data
 		}
-		return resp, nil
-	}
-	return nil, errors.New("file is not gzipped")
+		if err := f.ProcessLogLine(ctx, line.data); err != nil {
+			rejected++
+			return f.rejectLine(ctx, line, constants.RejectionStageProcess, err)
+		}
+		replayed++
+		return f.storage.DeleteRejection(ctx, rejection.Id)
+	})
+	logctx.Infow(ctx, "rejections replayed",
+		"replayed", replayed, "rejected", rejected, "ignored", ignored, "skipped", skipped)
+	return err
 }
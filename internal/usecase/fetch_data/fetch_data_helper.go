@@ -1,16 +1,29 @@
 package fetchdata
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/carousell/ct-go/pkg/container"
 	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
 	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
 	"github.com/google/uuid"
+	"github.com/spf13/cast"
 )
 
 func parseRawUrl(ctx context.Context, rawUrl string) (string, string) {
@@ -23,6 +36,267 @@ func parseRawUrl(ctx context.Context, rawUrl string) (string, string) {
 	return host, parsedURL.Path
 }
 
+// getStartedAt returns the time kong received the call, or now for the logs without it.
+func getStartedAt(logObject container.Map) time.Time {
+	startedAt := cast.ToInt64(logObject["started_at"])
+	if startedAt <= 0 {
+		return time.Now().UTC()
+	}
+	return time.UnixMilli(startedAt).UTC()
+}
+
+// getLatencyMs returns the time kong took to answer the call in milliseconds,
+// 0 for the logs without it.
+func getLatencyMs(logObject container.Map) int64 {
+	latencies, _ := logObject["latencies"].(map[string]any)
+	return cast.ToInt64(latencies["request"])
+}
+
+// newTrafficRecord returns the traffic of a call logged by kong, false for
+// the logs without started_at, e.g. the imported examples, which are no
+// traffic. Kong adds the latency headers to the responses it proxies, the
+// latencies of the log are used when they are missing.
+func newTrafficRecord(logObject container.Map) (*entity.TrafficRecord, bool) {
+	if cast.ToInt64(logObject["started_at"]) <= 0 {
+		return nil, false
+	}
+	request, _ := logObject["request"].(map[string]any)
+	response, _ := logObject["response"].(map[string]any)
+	headers, _ := response["headers"].(map[string]any)
+	latencies, _ := logObject["latencies"].(map[string]any)
+	record := &entity.TrafficRecord{
+		SeenAt:            getStartedAt(logObject),
+		StatusCode:        cast.ToInt(response["status"]),
+		LatencyMs:         getLatencyMs(logObject),
+		UpstreamLatencyMs: cast.ToInt64(latencies["proxy"]),
+		ProxyLatencyMs:    cast.ToInt64(latencies["kong"]),
+		RequestSize:       cast.ToInt64(request["size"]),
+		ResponseSize:      cast.ToInt64(response["size"]),
+	}
+	if latency := headerValue(headers, constants.HeaderUpstreamLatency); latency != "" {
+		record.UpstreamLatencyMs = cast.ToInt64(latency)
+	}
+	if latency := headerValue(headers, constants.HeaderProxyLatency); latency != "" {
+		record.ProxyLatencyMs = cast.ToInt64(latency)
+	}
+	return record, true
+}
+
+// browserTokens are the products of a browser user agent naming the browser,
+// in the order they are looked for, e.g. Chrome user agents also name Safari.
+var browserTokens = []struct {
+	token  string
+	family string
+}{
+	{"Edg", "Edge"},
+	{"OPR", "Opera"},
+	{"Firefox", "Firefox"},
+	{"FxiOS", "Firefox"},
+	{"CriOS", "Chrome"},
+	{"Chrome", "Chrome"},
+	{"Version", "Safari"},
+}
+
+// newClientIdentity returns who made a call logged by kong.
+func newClientIdentity(logObject container.Map) *entity.ClientIdentity {
+	request, _ := logObject["request"].(map[string]any)
+	headers, _ := request["headers"].(map[string]any)
+	identity := &entity.ClientIdentity{
+		Subnet: clientSubnet(cast.ToString(logObject["client_ip"])),
+	}
+	identity.App, identity.AppVersion = parseUserAgent(headerValue(headers, constants.HeaderUserAgent))
+	if consumer, ok := logObject["consumer"].(map[string]any); ok {
+		for _, key := range []string{"username", "custom_id", "id"} {
+			if name := cast.ToString(consumer[key]); name != "" {
+				identity.Consumer = name
+				break
+			}
+		}
+	}
+	if idKey := headerValue(headers, constants.HeaderIdKey); idKey != "" {
+		sum := sha256.Sum256([]byte(idKey))
+		identity.IdKeyHash = hex.EncodeToString(sum[:])[:constants.ConsumerIdKeyHashLength]
+	}
+	return identity
+}
+
+// parseUserAgent returns the family and version of a user agent: the browser
+// of the Mozilla ones, the first product of the others, e.g. Chotot/5.12.0
+// (Android 13) is the version 5.12.0 of Chotot.
+func parseUserAgent(userAgent string) (string, string) {
+	products := make(map[string]string)
+	first := ""
+	depth := 0
+	for _, token := range strings.Fields(userAgent) {
+		// the comments in parentheses are not products
+		if depth > 0 || strings.HasPrefix(token, "(") {
+			depth += strings.Count(token, "(") - strings.Count(token, ")")
+			continue
+		}
+		name, version, _ := strings.Cut(token, "/")
+		if _, ok := products[name]; !ok {
+			products[name] = version
+		}
+		if first == "" {
+			first = name
+		}
+	}
+	if first != "Mozilla" {
+		return first, products[first]
+	}
+	for _, browser := range browserTokens {
+		if version, ok := products[browser.token]; ok {
+			return browser.family, version
+		}
+	}
+	return first, ""
+}
+
+// clientSubnet returns the subnet of a client ip, "" when it is not an ip.
+func clientSubnet(clientIp string) string {
+	addr, err := netip.ParseAddr(clientIp)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := constants.ConsumerSubnetBitsIPv6
+	if addr.Is4() {
+		bits = constants.ConsumerSubnetBitsIPv4
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// newConsumerUsages returns the usages of a call by its consumers: a call of
+// each consumer identified, and the fields the app and kong consumer sent.
+func newConsumerUsages(identity *entity.ClientIdentity, sampleRequest *entity.SampleRequest) []*entity.ConsumerUsage {
+	consumers := make([]*entity.ConsumerUsage, 0, 4)
+	if identity.App != "" {
+		consumers = append(consumers, &entity.ConsumerUsage{
+			Kind:    constants.ConsumerKindApp,
+			Name:    identity.App,
+			Version: identity.AppVersion,
+		})
+	}
+	if identity.Consumer != "" {
+		consumers = append(consumers, &entity.ConsumerUsage{Kind: constants.ConsumerKindConsumer, Name: identity.Consumer})
+	}
+	if identity.IdKeyHash != "" {
+		consumers = append(consumers, &entity.ConsumerUsage{Kind: constants.ConsumerKindIdKey, Name: identity.IdKeyHash})
+	}
+	if identity.Subnet != "" {
+		consumers = append(consumers, &entity.ConsumerUsage{Kind: constants.ConsumerKindSubnet, Name: identity.Subnet})
+	}
+	fields := requestFields(sampleRequest)
+	usages := make([]*entity.ConsumerUsage, 0, len(consumers))
+	for _, consumer := range consumers {
+		usages = append(usages, consumer)
+		if !constants.ConsumerFieldKinds.Contains(consumer.Kind) {
+			continue
+		}
+		for _, field := range fields {
+			usages = append(usages, &entity.ConsumerUsage{
+				Kind:    consumer.Kind,
+				Name:    consumer.Name,
+				Version: consumer.Version,
+				Target:  field.Target,
+				Field:   field.Field,
+			})
+		}
+	}
+	return usages
+}
+
+// requestFields returns the query parameters and body fields of a call, with
+// the paths of the drifts, at most constants.ConsumerMaxFields of them.
+func requestFields(sampleRequest *entity.SampleRequest) []*entity.ConsumerField {
+	fields := make([]*entity.ConsumerField, 0)
+	for _, parameter := range sampleRequest.Parameters {
+		fields = append(fields, &entity.ConsumerField{Target: constants.DriftTargetParameter, Field: parameter.Name})
+	}
+	var body any
+	if sampleRequest.Body != "" && json.Unmarshal([]byte(sampleRequest.Body), &body) == nil {
+		bodyFields := make(map[string]bool)
+		collectBodyFields(body, "$", bodyFields)
+		for _, field := range mapsutils.SortedKeys(bodyFields) {
+			fields = append(fields, &entity.ConsumerField{Target: constants.TypeRequest, Field: field})
+		}
+	}
+	if len(fields) > constants.ConsumerMaxFields {
+		fields = fields[:constants.ConsumerMaxFields]
+	}
+	return fields
+}
+
+// collectBodyFields adds the paths of the fields of value to fields, the items
+// of an array share the path of the array suffixed with [*].
+func collectBodyFields(value any, path string, fields map[string]bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			fields[path+"."+key] = true
+			collectBodyFields(item, path+"."+key, fields)
+		}
+	case []any:
+		for _, item := range value {
+			collectBodyFields(item, path+"[*]", fields)
+		}
+	}
+}
+
+func newSampleRequest(api *entity.Api, request container.Map, correlationId string) *entity.SampleRequest {
+	sampleRequest := &entity.SampleRequest{
+		ApiId:         api.Id,
+		Credentials:   detectCredentials(request),
+		CorrelationId: correlationId,
+	}
+	if request["body"] != nil {
+		sampleRequest.Body = request["body"].(string)
+	}
+	if request["querystring"] != nil {
+		sampleRequest.Parameters = buildParametersFromQueryString(request["querystring"].(map[string]any))
+	}
+	return sampleRequest
+}
+
+// newSampleResponse returns nil for the responses logged without a body.
+func newSampleResponse(
+	api *entity.Api,
+	response container.Map,
+	correlationId string,
+	latencyMs int64,
+) *entity.SampleResponse {
+	if response["body"] == nil {
+		return nil
+	}
+	return &entity.SampleResponse{
+		ApiId:          api.Id,
+		HttpStatusCode: cast.ToInt(response["status"]),
+		Body:           response["body"].(string),
+		CorrelationId:  correlationId,
+		LatencyMs:      latencyMs,
+	}
+}
+
+// getCorrelationId returns the id kong gave the call, or a new one for the
+// logs without it, so the request and response samples of a call are paired.
+func getCorrelationId(logObject container.Map) string {
+	for _, key := range []string{"response", "request"} {
+		message, ok := logObject[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		headers, _ := message["headers"].(map[string]any)
+		if correlationId := headerValue(headers, constants.HeaderCorrelationId); correlationId != "" {
+			return correlationId
+		}
+	}
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
 func findParameterInPath(_ context.Context, path string) string {
 	pathParts := strings.Split(path, "/")
 	updatedPath := []string{}
@@ -93,3 +367,335 @@ func getPossibleType(s string) string {
 	}
 	return constants.ParameterTypeString
 }
+
+// detectCredentials returns the credentials a kong request log carries, an
+// empty list for an anonymous request.
+func detectCredentials(request container.Map) []*entity.Credential {
+	credentials := make([]*entity.Credential, 0)
+	headers, _ := request["headers"].(map[string]any)
+	if authorization := headerValue(headers, "authorization"); authorization != "" {
+		credentials = append(credentials, detectAuthorization(authorization))
+	}
+	for _, name := range constants.CredentialHeaders {
+		if headerValue(headers, name) != "" {
+			credentials = append(credentials, &entity.Credential{
+				Type: constants.SecuritySchemeTypeAPIKey,
+				In:   constants.CredentialInHeader,
+				Name: name,
+			})
+		}
+	}
+	queryString, _ := request["querystring"].(map[string]any)
+	for _, name := range mapsutils.SortedKeys(queryString) {
+		if constants.CredentialQueryParameters.Contains(strings.ToLower(name)) {
+			credentials = append(credentials, &entity.Credential{
+				Type: constants.SecuritySchemeTypeAPIKey,
+				In:   constants.CredentialInQuery,
+				Name: name,
+			})
+		}
+	}
+	if cookie := headerValue(headers, "cookie"); cookie != "" {
+		cookies, _ := http.ParseCookie(cookie)
+		seen := make(map[string]bool)
+		for _, c := range cookies {
+			if seen[c.Name] || !constants.SessionCookieRegexp.MatchString(c.Name) {
+				continue
+			}
+			seen[c.Name] = true
+			credentials = append(credentials, &entity.Credential{
+				Type: constants.SecuritySchemeTypeAPIKey,
+				In:   constants.CredentialInCookie,
+				Name: c.Name,
+			})
+		}
+	}
+	return credentials
+}
+
+// detectAuthorization reads the scheme of an authorization header, kong may
+// redact its value in which case it is assumed to be a bearer token.
+func detectAuthorization(authorization string) *entity.Credential {
+	credential := &entity.Credential{
+		Type:   constants.SecuritySchemeTypeHTTP,
+		Scheme: constants.HTTPSchemeBearer,
+	}
+	scheme, token, found := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !found {
+		return credential
+	}
+	if strings.EqualFold(scheme, constants.HTTPSchemeBasic) {
+		credential.Scheme = constants.HTTPSchemeBasic
+		return credential
+	}
+	if strings.EqualFold(scheme, constants.HTTPSchemeBearer) && strings.Count(token, ".") == 2 {
+		credential.BearerFormat = "JWT"
+	}
+	return credential
+}
+
+// headerValue returns the value of a header of a kong log, the first one when
+// the header was repeated.
+func headerValue(headers map[string]any, name string) string {
+	value := headers[name]
+	if values, ok := value.([]any); ok {
+		if len(values) == 0 {
+			return ""
+		}
+		value = values[0]
+	}
+	return cast.ToString(value)
+}
+
+var postmanVariableRegexp = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// walkPostmanItems calls fn on every request of a collection, the folders
+// being walked depth first.
+func walkPostmanItems(items []*entity.PostmanItem, fn func(item *entity.PostmanItem) error) error {
+	for _, item := range items {
+		if err := walkPostmanItems(item.Item, fn); err != nil {
+			return err
+		}
+		if item.Request == nil && len(item.Response) == 0 {
+			continue
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPostmanLogObject returns the kong log of the call a saved example of a
+// Postman request records, ok is false when its url keeps a variable or a path
+// variable without value.
+func newPostmanLogObject(
+	request *entity.PostmanRequest,
+	example *entity.PostmanResponse,
+	variables map[string]string,
+) (container.Map, bool) {
+	if example.OriginalRequest != nil {
+		request = example.OriginalRequest
+	}
+	if request == nil || request.Url == nil {
+		return nil, false
+	}
+	rawUrl, ok := resolvePostmanUrl(request.Url, variables)
+	if !ok {
+		return nil, false
+	}
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	logRequest := map[string]any{
+		"method":  method,
+		"url":     rawUrl,
+		"headers": postmanHeaders(request.Header, variables),
+	}
+	parsedUrl, _ := url.Parse(rawUrl)
+	if query := parsedUrl.Query(); len(query) > 0 {
+		querystring := make(map[string]any, len(query))
+		for key, values := range query {
+			querystring[key] = values[0]
+		}
+		logRequest["querystring"] = querystring
+	}
+	if request.Body != nil && request.Body.Mode == constants.PostmanBodyModeRaw && request.Body.Raw != "" {
+		logRequest["body"] = replacePostmanVariables(request.Body.Raw, variables)
+	}
+	logResponse := map[string]any{
+		"status":  example.Code,
+		"headers": postmanHeaders(example.Header, nil),
+	}
+	if example.Body != "" {
+		logResponse["body"] = example.Body
+	}
+	return container.Map{
+		"request":  logRequest,
+		"response": logResponse,
+	}, true
+}
+
+// resolvePostmanUrl replaces the variables and the path variables of a
+// Postman url, an url without scheme being an https one.
+func resolvePostmanUrl(postmanUrl *entity.PostmanUrl, variables map[string]string) (string, bool) {
+	rawUrl := replacePostmanVariables(postmanUrl.Raw, variables)
+	if rawUrl == "" || postmanVariableRegexp.MatchString(rawUrl) {
+		return "", false
+	}
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = "https://" + rawUrl
+	}
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || parsedUrl.Host == "" {
+		return "", false
+	}
+	pathVariables := make(map[string]string, len(postmanUrl.Variable))
+	for _, variable := range postmanUrl.Variable {
+		pathVariables[variable.Key] = replacePostmanVariables(variable.Value, variables)
+	}
+	segments := strings.Split(parsedUrl.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		value := pathVariables[segment[1:]]
+		if value == "" {
+			return "", false
+		}
+		segments[i] = value
+	}
+	parsedUrl.Path = strings.Join(segments, "/")
+	return parsedUrl.String(), true
+}
+
+// replacePostmanVariables replaces the {{name}} variables of s known in
+// variables, the others are kept.
+func replacePostmanVariables(s string, variables map[string]string) string {
+	return postmanVariableRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if value, ok := variables[match[2:len(match)-2]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// postmanHeaders returns the enabled headers the way kong logs them, by lower
+// case name.
+func postmanHeaders(headers []*entity.PostmanHeader, variables map[string]string) map[string]any {
+	logHeaders := make(map[string]any, len(headers))
+	for _, header := range headers {
+		if header.Disabled {
+			continue
+		}
+		logHeaders[strings.ToLower(header.Key)] = replacePostmanVariables(header.Value, variables)
+	}
+	return logHeaders
+}
+
+// errIgnoredLine is a line left out of the ingestion on purpose, it is not
+// dead lettered.
+var errIgnoredLine = errors.New("log line ignored")
+
+// sourceLine is a log line with where it was read from.
+type sourceLine struct {
+	source string
+	number int
+	data   []byte
+}
+
+// rejectError is the error of a line the ingestion rejects, with the
+// constants.RejectionReason* it is dead lettered under.
+type rejectError struct {
+	reason string
+	err    error
+}
+
+func reject(reason string, err error) error {
+	return &rejectError{reason: reason, err: err}
+}
+
+func (e *rejectError) Error() string {
+	return e.reason + ": " + e.err.Error()
+}
+
+func (e *rejectError) Unwrap() error {
+	return e.err
+}
+
+// rejectionReason returns the reason of err, an error which is not a
+// rejectError comes from the storage of a valid log.
+func rejectionReason(err error) string {
+	var rejectErr *rejectError
+	if errors.As(err, &rejectErr) {
+		return rejectErr.reason
+	}
+	return constants.RejectionReasonStoreFailed
+}
+
+// newRejection returns the rejection of line, served redacted and cut to
+// maxLineSize bytes at a rune boundary. The raw line is kept for the replay
+// unless it is truncated, a redacted line would corrupt the samples.
+func newRejection(line *sourceLine, stage string, err error, redactor *redact.Redactor,
+	maxLineSize int, rejectedAt time.Time,
+) *entity.Rejection {
+	rejection := &entity.Rejection{
+		Source:         line.source,
+		LineNumber:     line.number,
+		Stage:          stage,
+		Reason:         rejectionReason(err),
+		Error:          err.Error(),
+		Line:           string(cutLine(redactLine(redactor, line.data), maxLineSize)),
+		Truncated:      maxLineSize > 0 && len(line.data) > maxLineSize,
+		LastRejectedAt: &rejectedAt,
+	}
+	if !rejection.Truncated {
+		rejection.RawLine = string(line.data)
+	}
+	return rejection
+}
+
+// cutLine cuts data to maxLineSize bytes at a rune boundary, 0 keeps it whole.
+func cutLine(data []byte, maxLineSize int) []byte {
+	if maxLineSize <= 0 || len(data) <= maxLineSize {
+		return data
+	}
+	size := maxLineSize
+	for size > 0 && !utf8.RuneStart(data[size]) {
+		size--
+	}
+	return data[:size]
+}
+
+// redactLine masks the sensitive headers, query parameters and body fields of
+// a log line, a line which is not JSON only gets its sensitive values masked.
+func redactLine(redactor *redact.Redactor, data []byte) []byte {
+	var logObject map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&logObject); err != nil || decoder.More() {
+		return []byte(redactor.Body(string(data)))
+	}
+	for _, key := range []string{"request", "response"} {
+		message, _ := logObject[key].(map[string]any)
+		if headers, ok := message["headers"].(map[string]any); ok {
+			for name, value := range headers {
+				headers[name] = redactField(redactor, name, value, constants.CredentialHeaders)
+			}
+		}
+		if querystring, ok := message["querystring"].(map[string]any); ok {
+			for name, value := range querystring {
+				querystring[name] = redactField(redactor, name, value, constants.CredentialQueryParameters)
+			}
+		}
+		if body, ok := message["body"].(string); ok {
+			message["body"] = redactor.Body(body)
+		}
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(logObject); err != nil {
+		return []byte(redactor.Body(string(data)))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactField redacts the value of the header or query parameter name, kong
+// logs the repeated ones as a list.
+func redactField(redactor *redact.Redactor, name string, value any, credentials container.List[string]) any {
+	switch v := value.(type) {
+	case string:
+		if credentials.Contains(strings.ToLower(name)) && v != "" {
+			return redact.Mask
+		}
+		return redactor.Parameter(name, v)
+	case []any:
+		for i, item := range v {
+			v[i] = redactField(redactor, name, item, credentials)
+		}
+		return v
+	}
+	return value
+}
@@ -3,7 +3,11 @@ package fetchdata
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/usecase/drift"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,6 +17,7 @@ func TestFetchDataUC_processLogLine(t *testing.T) {
 	fetchDataUC := NewFetchDataUC(
 		conf,
 		mongoStorage,
+		drift.NewDriftUC(conf, mongoStorage),
 	)
 
 	test := []struct {
@@ -41,3 +46,83 @@ func TestFetchDataUC_processLogLine(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchDataUC_processLogLineAfterSoftDelete(t *testing.T) {
+	t.Parallel()
+
+	fetchDataUC := NewFetchDataUC(
+		conf,
+		mongoStorage,
+		drift.NewDriftUC(conf, mongoStorage),
+	)
+	ctx := context.Background()
+	path := "/v1/private/soft_deleted/contracts"
+	logLine := `{"request":{"method":"GET","url":"https://gateway.chotot.org:443` + path + `?limit=20","querystring":{"limit":"20"},"headers":{"host":"gateway.chotot.org"},"body":""},"response":{"status":200,"headers":{"content-type":"application/json"},"body":"{\"data\":[]}"},"started_at":1732863603503,"client_ip":"10.9.24.207"}`
+
+	require.Nil(t, fetchDataUC.ProcessLogLine(ctx, []byte(logLine)))
+	api, err := mongoStorage.GetApiByPath(ctx, path)
+	require.Nil(t, err)
+	require.NotNil(t, api)
+	flaggedAt := time.Now()
+	require.Nil(t, mongoStorage.UpdateApiDeprecatedCandidate(ctx, api.Id, &flaggedAt))
+	require.Nil(t, mongoStorage.SoftDeleteApi(ctx, api.Id))
+
+	// the calls after the soft delete restore the api instead of creating one
+	for i := 0; i < 2; i++ {
+		require.Nil(t, fetchDataUC.ProcessLogLine(ctx, []byte(logLine)))
+	}
+	restored, err := mongoStorage.GetApiByPath(ctx, path)
+	require.Nil(t, err)
+	require.NotNil(t, restored)
+	require.Equal(t, api.Id, restored.Id)
+	require.Nil(t, restored.DeletedAt)
+	require.Nil(t, restored.DeprecatedCandidateAt)
+	apis, err := mongoStorage.GetApisByFilter(ctx, &entity.GetApisRequest{Path: path, Limit: 10})
+	require.Nil(t, err)
+	require.Len(t, apis, 1)
+}
+
+func TestFetchDataUC_ReplayRejections(t *testing.T) {
+	t.Parallel()
+
+	fetchDataUC := NewFetchDataUC(
+		conf,
+		mongoStorage,
+		drift.NewDriftUC(conf, mongoStorage),
+	)
+	ctx := context.Background()
+	path := "/v1/private/replayed/orders"
+	source := "replay_rejections_test.log.gz"
+	logLine := `{"request":{"method":"GET","url":"https://gateway.chotot.org:443` + path + `?limit=20","querystring":{"limit":"20"},"headers":{"host":"gateway.chotot.org","user-agent":"okhttp/4.9.2"},"body":""},"response":{"status":200,"headers":{"content-type":"application/json","x-kong-upstream-latency":"7","x-kong-proxy-latency":"2"},"body":"{\"data\":[]}"},"started_at":1732863603503,"client_ip":"10.9.24.207"}`
+	rejectedAt := time.Now()
+	require.Nil(t, mongoStorage.RecordRejection(ctx, &entity.Rejection{
+		Source:         source,
+		LineNumber:     1,
+		Stage:          constants.RejectionStageProcess,
+		Reason:         constants.RejectionReasonStoreFailed,
+		Error:          "mongo insert: connection reset",
+		Line:           logLine,
+		RawLine:        logLine,
+		LastRejectedAt: &rejectedAt,
+	}))
+
+	seenAt := time.UnixMilli(1732863603503).UTC()
+	// a second replay finds no rejection left and counts nothing
+	for i := 0; i < 2; i++ {
+		require.Nil(t, fetchDataUC.ReplayRejections(ctx))
+
+		api, err := mongoStorage.GetApiByPath(ctx, path)
+		require.Nil(t, err)
+		require.NotNil(t, api)
+		hours, err := mongoStorage.GetApiStatsByApiId(ctx, api.Id, seenAt.Add(-time.Hour), seenAt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Equal(t, int64(1), entity.SummarizeApiStats(hours).Calls)
+		usages, err := mongoStorage.GetConsumerUsagesByApiId(ctx, api.Id, constants.ConsumerKindSubnet, nil)
+		require.Nil(t, err)
+		require.Len(t, usages, 1)
+		require.Equal(t, int64(1), usages[0].Count)
+		rejections, err := mongoStorage.GetRejections(ctx, &entity.GetRejectionsRequest{Source: source, Limit: 10})
+		require.Nil(t, err)
+		require.Empty(t, rejections)
+	}
+}
@@ -2,17 +2,25 @@ package loadstructure
 
 import (
 	"context"
+	"io"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/carousell/ct-go/pkg/container"
 	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
 	"github.com/ct-logic-api-document/internal/entity"
 	"github.com/ct-logic-api-document/internal/errors"
 	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
 )
 
 type ILoadstructure interface {
-	LoadStructureByApiId(ctx context.Context, apiId string) (*entity.LoadStructureByApiIdResponse, error)
+	LoadStructureByApiId(ctx context.Context, req *entity.LoadStructureByApiIdRequest) (*entity.LoadStructureByApiIdResponse, error)
+	// LoadOpenApiDocument streams to w a single OpenAPI document of the apis
+	// matching req. w may hold a partial document when an error is returned.
+	LoadOpenApiDocument(ctx context.Context, req *entity.LoadOpenApiDocumentRequest, w io.Writer) error
 }
 
 type loadStructureUC struct {
@@ -30,8 +38,10 @@ func NewLoadStructureUC(
 	}
 }
 
-func (uc *loadStructureUC) LoadStructureByApiId(ctx context.Context, apiId string) (*entity.LoadStructureByApiIdResponse, error) {
-	apiObject, err := uc.storage.GetApiByIdInStr(ctx, apiId)
+func (uc *loadStructureUC) LoadStructureByApiId(ctx context.Context,
+	req *entity.LoadStructureByApiIdRequest,
+) (*entity.LoadStructureByApiIdResponse, error) {
+	apiObject, err := uc.storage.GetApiByIdInStr(ctx, req.ApiId)
 	if err != nil {
 		return nil, err
 	}
@@ -46,43 +56,102 @@ func (uc *loadStructureUC) LoadStructureByApiId(ctx context.Context, apiId strin
 	if err != nil {
 		return nil, err
 	}
+	annotations, err := uc.storage.GetAnnotationsByApiId(ctx, apiObject.Id)
+	if err != nil {
+		return nil, err
+	}
+	traffic, err := uc.loadTraffic(ctx, apiObject)
+	if err != nil {
+		return nil, err
+	}
 
-	resp := uc.buildLoadStructureByApiIdResponse(ctx, apiObject, requestStructureByApiId, responseStructureByApiId)
+	resp := uc.buildLoadStructureByApiIdResponse(ctx, apiObject, requestStructureByApiId, responseStructureByApiId,
+		annotations, traffic, req.Version)
 	return resp, nil
 }
 
+func (uc *loadStructureUC) LoadOpenApiDocument(ctx context.Context,
+	req *entity.LoadOpenApiDocumentRequest, w io.Writer,
+) error {
+	writer := newOpenApiDocumentWriter(w, req.Version)
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	err := uc.storage.IterateApisByFilter(ctx, &req.ApiFilter, func(apiObject *entity.Api) error {
+		if req.Tag != "" && !slices.Contains(apiObject.OperationTags(), req.Tag) {
+			return nil
+		}
+		requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, apiObject.Id)
+		if err != nil {
+			return err
+		}
+		responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, apiObject.Id)
+		if err != nil {
+			return err
+		}
+		annotations, err := uc.storage.GetAnnotationsByApiId(ctx, apiObject.Id)
+		if err != nil {
+			return err
+		}
+		traffic, err := uc.loadTraffic(ctx, apiObject)
+		if err != nil {
+			return err
+		}
+		applyAnnotations(requestStructure, responseStructure, annotations)
+		operation := buildOperation(apiObject, requestStructure, responseStructure, req.Version)
+		applyTraffic(operation, traffic)
+		return writer.writeOperation(ctx, apiObject, operation, buildSecuritySchemes(requestStructure))
+	})
+	if err != nil {
+		return err
+	}
+	return writer.close()
+}
+
 func (uc *loadStructureUC) buildLoadStructureByApiIdResponse(_ context.Context,
 	apiObject *entity.Api,
 	requestStructure *entity.RequestStructure,
 	responseStructure *entity.ResponseStructure,
+	annotations []*entity.Annotation,
+	traffic container.Map,
+	version string,
 ) *entity.LoadStructureByApiIdResponse {
 	resp := &entity.LoadStructureByApiIdResponse{}
 	resp.LoadDefault()
+	resp.OpenApi = openapiutils.SpecVersion(version)
 	resp.Servers = []*entity.Server{
 		{
 			Url: apiObject.Host,
 		},
 	}
-	apiInfo := container.Map{}
-	if strings.Contains(apiObject.Path, "private") {
-		apiInfo["security"] = []container.Map{
-			{
-				"Bearer": []string{},
-			},
-		}
-	}
-	if parameters := requestStructure.BuildParameters(); parameters != nil {
-		apiInfo["parameters"] = parameters
-	}
-	if requestBody := requestStructure.BuildRequestBody(); requestBody != nil {
-		apiInfo["requestBody"] = requestBody
+	for _, tag := range apiObject.OperationTags() {
+		resp.Tags = append(resp.Tags, buildTag(tag))
 	}
-	if responseBody := responseStructure.BuildResponseBody(); responseBody != nil {
-		apiInfo["responses"] = responseBody
+	applyAnnotations(requestStructure, responseStructure, annotations)
+	if securitySchemes := buildSecuritySchemes(requestStructure); len(securitySchemes) > 0 {
+		resp.Components = &entity.Components{SecuritySchemes: securitySchemes}
 	}
+	operation := buildOperation(apiObject, requestStructure, responseStructure, version)
+	applyTraffic(operation, traffic)
 	resp.Paths[apiObject.Path] = container.Map{
-		strings.ToLower(apiObject.Method): apiInfo,
+		strings.ToLower(apiObject.Method): operation,
 	}
 
 	return resp
 }
+
+// loadTraffic returns the x-traffic extension of the operation of the api, nil
+// when it was not called over constants.StatsDocumentWindow.
+func (uc *loadStructureUC) loadTraffic(ctx context.Context, apiObject *entity.Api) (container.Map, error) {
+	to := time.Now().UTC()
+	from := to.Add(-constants.StatsDocumentWindow)
+	hours, err := uc.storage.GetApiStatsByApiId(ctx, apiObject.Id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	summary := entity.SummarizeApiStats(hours)
+	if summary == nil {
+		return nil, nil
+	}
+	return summary.BuildExtension(from), nil
+}
@@ -0,0 +1,313 @@
+package loadstructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+)
+
+var pathParameterRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// buildOperation builds the OpenAPI operation of an api in the given version,
+// its structures are nil when the api has not been built yet.
+func buildOperation(
+	apiObject *entity.Api,
+	requestStructure *entity.RequestStructure,
+	responseStructure *entity.ResponseStructure,
+	version string,
+) container.Map {
+	apiInfo := container.Map{
+		"operationId": apiObject.Id.Hex(),
+		"tags":        apiObject.OperationTags(),
+	}
+	if apiObject.Title != "" {
+		apiInfo["summary"] = apiObject.Title
+	}
+	if apiObject.Description != "" {
+		apiInfo["description"] = apiObject.Description
+	}
+	// the security is documented as seen in the samples, an api without any
+	// recorded credentials leaves it undeclared
+	if security := buildSecurityUsage(requestStructure); security != nil {
+		apiInfo["security"] = security.BuildSecurity()
+		apiInfo["x-security-usage"] = security.BuildUsage()
+	}
+	if apiObject.DeprecatedCandidateAt != nil {
+		apiInfo[constants.DeprecatedCandidateExtensionKey] = buildDeprecatedCandidate(apiObject)
+	}
+	parameters := buildPathParameters(apiObject.Path)
+	if requestStructure != nil {
+		if queryParameters, ok := requestStructure.BuildParameters().([]any); ok {
+			parameters = append(parameters, queryParameters...)
+		}
+		if requestBody := requestStructure.BuildRequestBody(); requestBody != nil {
+			apiInfo["requestBody"] = requestBody
+		}
+	}
+	if len(parameters) > 0 {
+		apiInfo["parameters"] = parameters
+	}
+	// responses is required, an api without response sample gets a default one
+	apiInfo["responses"] = container.Map{
+		"default": container.Map{
+			"description": "No response recorded",
+		},
+	}
+	if responseStructure != nil {
+		if responseBody := responseStructure.BuildResponseBody(); responseBody != nil {
+			apiInfo["responses"] = responseBody
+		}
+	}
+	if version == openapiutils.Version31 {
+		openapiutils.ConvertTo31(apiInfo)
+	}
+	return apiInfo
+}
+
+// buildDeprecatedCandidate returns the x-deprecated-candidate extension of the
+// operation of an api not called anymore.
+func buildDeprecatedCandidate(apiObject *entity.Api) container.Map {
+	candidate := container.Map{
+		"flagged_at": apiObject.DeprecatedCandidateAt.UTC().Format(time.RFC3339),
+	}
+	if apiObject.LastSeenAt != nil {
+		candidate["last_seen_at"] = apiObject.LastSeenAt.UTC().Format(time.RFC3339)
+	}
+	return candidate
+}
+
+// applyTraffic documents the traffic of the operation, the operations without
+// traffic are left as is.
+func applyTraffic(operation container.Map, traffic container.Map) {
+	if traffic == nil {
+		return
+	}
+	operation[constants.TrafficExtensionKey] = traffic
+}
+
+// buildSecurityUsage returns the security usage of the api, nil when no
+// sample recorded its credentials.
+func buildSecurityUsage(requestStructure *entity.RequestStructure) *entity.SecurityUsage {
+	if requestStructure == nil || requestStructure.Security == nil || requestStructure.Security.Samples == 0 {
+		return nil
+	}
+	return requestStructure.Security
+}
+
+// buildSecuritySchemes returns the security schemes the operation of the api
+// refers to.
+func buildSecuritySchemes(requestStructure *entity.RequestStructure) container.Map {
+	security := buildSecurityUsage(requestStructure)
+	if security == nil {
+		return container.Map{}
+	}
+	return security.BuildSecuritySchemes()
+}
+
+// buildPathParameters declares the parameters templated in path, the {id} and
+// {uuid} ones being the placeholders of the ingested urls.
+func buildPathParameters(path string) []any {
+	parameters := make([]any, 0)
+	seen := make(map[string]bool)
+	for _, match := range pathParameterRegexp.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		schema := container.Map{"type": constants.ParameterTypeString}
+		switch name {
+		case "id":
+			schema = container.Map{"type": constants.ParameterTypeInteger}
+		case "uuid":
+			schema["format"] = "uuid"
+		}
+		parameters = append(parameters, container.Map{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	return parameters
+}
+
+// applyAnnotations overlays the annotations onto the body schemas of the
+// structures, the orphaned ones are left out.
+func applyAnnotations(
+	requestStructure *entity.RequestStructure,
+	responseStructure *entity.ResponseStructure,
+	annotations []*entity.Annotation,
+) {
+	for _, annotation := range annotations {
+		schema, ok := annotation.Resolve(requestStructure, responseStructure)
+		if !ok {
+			continue
+		}
+		for key, value := range annotation.Schema {
+			schema[key] = value
+		}
+	}
+}
+
+func buildTag(name string) *entity.Tag {
+	return &entity.Tag{
+		Name:        name,
+		Description: fmt.Sprintf("Operations under %s", name),
+	}
+}
+
+// openApiDocumentWriter streams an OpenAPI document path by path, so the
+// operations of a path must be written consecutively. Tags and servers are
+// collected on the way and written after the paths.
+type openApiDocumentWriter struct {
+	w        io.Writer
+	document *entity.LoadStructureByApiIdResponse
+	path     string
+	// pathHost is the server of the path item, the host of its first operation
+	pathHost  string
+	pathItem  container.Map
+	pathCount int
+	tags      map[string]bool
+	servers   map[string]bool
+	schemes   container.Map
+}
+
+func newOpenApiDocumentWriter(w io.Writer, version string) *openApiDocumentWriter {
+	document := &entity.LoadStructureByApiIdResponse{}
+	document.LoadDefault()
+	document.OpenApi = openapiutils.SpecVersion(version)
+	return &openApiDocumentWriter{
+		w:        w,
+		document: document,
+		tags:     make(map[string]bool),
+		servers:  make(map[string]bool),
+		schemes:  container.Map{},
+	}
+}
+
+func (d *openApiDocumentWriter) writeHeader() error {
+	openApi, err := json.Marshal(d.document.OpenApi)
+	if err != nil {
+		return err
+	}
+	info, err := json.Marshal(d.document.Info)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(d.w, `{"openapi":%s,"info":%s,"paths":{`, openApi, info)
+	return err
+}
+
+// writeOperation adds the operation of apiObject, securitySchemes being the
+// ones its security requirements refer to. The operations of a path served by
+// another host than its first one declare their own server. A path item holds
+// one operation per method, so the method of a path served by several hosts is
+// only documented for the first one and the others are skipped, the stream
+// being already partly written.
+func (d *openApiDocumentWriter) writeOperation(ctx context.Context, apiObject *entity.Api, operation container.Map,
+	securitySchemes container.Map,
+) error {
+	if d.pathItem != nil && d.path != apiObject.Path {
+		if err := d.flushPath(); err != nil {
+			return err
+		}
+	}
+	if d.pathItem == nil {
+		d.path = apiObject.Path
+		d.pathHost = apiObject.Host
+		// the catalogue spans several hosts, each path declares its own server
+		d.pathItem = container.Map{
+			"servers": []*entity.Server{{Url: apiObject.Host}},
+		}
+	}
+	method := strings.ToLower(apiObject.Method)
+	if existing, ok := d.pathItem[method].(container.Map); ok {
+		logctx.Warnw(ctx, "operation served by several hosts skipped, filter the document by host",
+			"method", apiObject.Method, "path", apiObject.Path,
+			"host", apiObject.Host, "documented_host", operationHost(existing, d.pathHost))
+		return nil
+	}
+	if apiObject.Host != d.pathHost {
+		operation["servers"] = []*entity.Server{{Url: apiObject.Host}}
+	}
+	d.pathItem[method] = operation
+	for _, tag := range apiObject.OperationTags() {
+		d.tags[tag] = true
+	}
+	d.servers[apiObject.Host] = true
+	for name, scheme := range securitySchemes {
+		d.schemes[name] = scheme
+	}
+	return nil
+}
+
+// operationHost returns the host of an operation written to a path item
+// served by pathHost.
+func operationHost(operation container.Map, pathHost string) string {
+	if servers, ok := operation["servers"].([]*entity.Server); ok && len(servers) > 0 {
+		return servers[0].Url
+	}
+	return pathHost
+}
+
+func (d *openApiDocumentWriter) flushPath() error {
+	key, err := json.Marshal(d.path)
+	if err != nil {
+		return err
+	}
+	pathItem, err := json.Marshal(d.pathItem)
+	if err != nil {
+		return err
+	}
+	separator := ","
+	if d.pathCount == 0 {
+		separator = ""
+	}
+	if _, err := fmt.Fprintf(d.w, "%s%s:%s", separator, key, pathItem); err != nil {
+		return err
+	}
+	d.pathCount++
+	d.pathItem = nil
+	return nil
+}
+
+// close writes the pending path and the rest of the document.
+func (d *openApiDocumentWriter) close() error {
+	if d.pathItem != nil {
+		if err := d.flushPath(); err != nil {
+			return err
+		}
+	}
+	footer := *d.document
+	footer.OpenApi = ""
+	footer.Info = nil
+	footer.Paths = nil
+	for _, name := range mapsutils.SortedKeys(d.tags) {
+		footer.Tags = append(footer.Tags, buildTag(name))
+	}
+	for _, host := range mapsutils.SortedKeys(d.servers) {
+		footer.Servers = append(footer.Servers, &entity.Server{Url: host})
+	}
+	if len(d.schemes) > 0 {
+		footer.Components = &entity.Components{SecuritySchemes: d.schemes}
+	}
+	data, err := json.Marshal(footer)
+	if err != nil {
+		return err
+	}
+	// footer is a JSON object, its fields follow the paths
+	_, err = fmt.Fprintf(d.w, "},%s", data[1:])
+	return err
+}
@@ -0,0 +1,286 @@
+package loadstructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/spf13/cast"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyAnnotations(t *testing.T) {
+	t.Parallel()
+	requestStructure := &entity.RequestStructure{
+		BodySchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"subject": map[string]any{"type": "string"},
+			},
+		},
+	}
+	annotations := []*entity.Annotation{
+		{
+			Target:  constants.AnnotationTargetRequest,
+			Pointer: "/properties/subject",
+			Schema:  map[string]any{"description": "Title of the ad", "example": "iPhone 15"},
+		},
+		{
+			Target:  constants.AnnotationTargetRequest,
+			Pointer: "/properties/body",
+			Schema:  map[string]any{"description": "Orphaned"},
+		},
+		{
+			Target:  constants.AnnotationTargetResponse,
+			Pointer: "",
+			Schema:  map[string]any{"description": "No response structure"},
+		},
+	}
+	applyAnnotations(requestStructure, nil, annotations)
+	require.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"subject": map[string]any{"type": "string", "description": "Title of the ad", "example": "iPhone 15"},
+		},
+	}, requestStructure.BodySchema)
+}
+
+func TestBuildOperation_Security(t *testing.T) {
+	t.Parallel()
+	bearer := &entity.Credential{Type: "http", Scheme: "bearer"}
+	idKey := &entity.Credential{Type: "apiKey", In: "header", Name: "x-chotot-id-key"}
+	security := &entity.SecurityUsage{}
+	security.Add([]*entity.Credential{})
+	for i := 0; i < 2; i++ {
+		security.Add([]*entity.Credential{idKey, bearer})
+	}
+	security.Add([]*entity.Credential{bearer})
+	// a later sample reveals the format of the bearer token
+	security.Add([]*entity.Credential{{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}})
+	api := &entity.Api{Host: "gateway.chotot.org", Path: "/v1/private/ads", Method: "GET"}
+	requestStructure := &entity.RequestStructure{Security: security}
+
+	operation := buildOperation(api, requestStructure, nil, openapiutils.Version30)
+	// equally frequent requirements are sorted by their schemes
+	require.Equal(t, []container.Map{
+		{"bearerAuth": []string{}},
+		{"bearerAuth": []string{}, "header.x-chotot-id-key": []string{}},
+		{},
+	}, operation["security"])
+	require.Equal(t, container.Map{
+		"samples": int64(5),
+		"usages": []container.Map{
+			{"schemes": []string{"bearerAuth"}, "count": int64(2)},
+			{"schemes": []string{"bearerAuth", "header.x-chotot-id-key"}, "count": int64(2)},
+			{"schemes": []string{}, "count": int64(1)},
+		},
+	}, operation["x-security-usage"])
+	require.Equal(t, container.Map{
+		"bearerAuth":             container.Map{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		"header.x-chotot-id-key": container.Map{"type": "apiKey", "in": "header", "name": "x-chotot-id-key"},
+	}, buildSecuritySchemes(requestStructure))
+
+	operation = buildOperation(api, &entity.RequestStructure{}, nil, openapiutils.Version30)
+	require.NotContains(t, operation, "security")
+}
+
+func TestBuildOperation_DeprecatedCandidate(t *testing.T) {
+	t.Parallel()
+	flaggedAt := time.Date(2024, 12, 31, 8, 0, 0, 0, time.UTC)
+	lastSeenAt := time.Date(2024, 11, 29, 7, 0, 3, 0, time.UTC)
+	api := &entity.Api{Host: "gateway.chotot.org", Path: "/v1/private/ads", Method: "GET"}
+	operation := buildOperation(api, nil, nil, openapiutils.Version30)
+	require.NotContains(t, operation, constants.DeprecatedCandidateExtensionKey)
+
+	api.DeprecatedCandidateAt = &flaggedAt
+	api.LastSeenAt = &lastSeenAt
+	operation = buildOperation(api, nil, nil, openapiutils.Version31)
+	require.Equal(t, container.Map{
+		"flagged_at":   "2024-12-31T08:00:00Z",
+		"last_seen_at": "2024-11-29T07:00:03Z",
+	}, operation[constants.DeprecatedCandidateExtensionKey])
+}
+
+func TestApplyTraffic(t *testing.T) {
+	t.Parallel()
+	firstSeenAt := time.Date(2024, 11, 29, 6, 12, 0, 0, time.UTC)
+	lastSeenAt := time.Date(2024, 11, 29, 7, 48, 0, 0, time.UTC)
+	// 100 calls, the 95th and 99th falling into the 1000 and overflow buckets
+	summary := entity.SummarizeApiStats([]*entity.ApiStats{
+		{
+			Count:             60,
+			StatusCodes:       map[string]int64{"200": 60},
+			LatencyBuckets:    map[string]int64{"50": 60},
+			LatencyMsSum:      2400,
+			LatencyMsMax:      48,
+			ProxyLatencyMsSum: 120,
+			ResponseSizeSum:   60000,
+			ResponseSizeMax:   1200,
+			FirstSeenAt:       &firstSeenAt,
+			LastSeenAt:        &firstSeenAt,
+		},
+		{
+			Count:             40,
+			StatusCodes:       map[string]int64{"200": 38, "500": 2},
+			LatencyBuckets:    map[string]int64{"250": 30, "1000": 8, "inf": 2},
+			LatencyMsSum:      47600,
+			LatencyMsMax:      12500,
+			ProxyLatencyMsSum: 80,
+			ResponseSizeSum:   40000,
+			ResponseSizeMax:   2000,
+			FirstSeenAt:       &lastSeenAt,
+			LastSeenAt:        &lastSeenAt,
+		},
+	})
+	require.Equal(t, &entity.ApiStatsSummary{
+		Calls:       100,
+		StatusCodes: map[string]int64{"200": 98, "500": 2},
+		LatencyMs: &entity.LatencySummary{
+			Avg: 500, P50: 50, P95: 1000, P99: 12500, Max: 12500, ProxyAvg: 2,
+		},
+		RequestSize:  &entity.SizeSummary{},
+		ResponseSize: &entity.SizeSummary{Avg: 1000, Max: 2000},
+		FirstSeenAt:  &firstSeenAt,
+		LastSeenAt:   &lastSeenAt,
+	}, summary)
+
+	operation := container.Map{}
+	applyTraffic(operation, summary.BuildExtension(firstSeenAt.Truncate(time.Hour)))
+	require.Equal(t, container.Map{
+		"since":         "2024-11-29T06:00:00Z",
+		"calls":         int64(100),
+		"status_codes":  map[string]int64{"200": 98, "500": 2},
+		"latency_ms":    container.Map{"p50": int64(50), "p95": int64(1000), "p99": int64(12500)},
+		"request_size":  container.Map{"avg": int64(0), "max": int64(0)},
+		"response_size": container.Map{"avg": int64(1000), "max": int64(2000)},
+		"last_seen_at":  "2024-11-29T07:48:00Z",
+	}, operation[constants.TrafficExtensionKey])
+
+	// the apis without traffic get no extension
+	operation = container.Map{}
+	applyTraffic(operation, nil)
+	require.NotContains(t, operation, constants.TrafficExtensionKey)
+	require.Nil(t, entity.SummarizeApiStats([]*entity.ApiStats{}))
+}
+
+func TestOpenApiDocumentWriter(t *testing.T) {
+	t.Parallel()
+	apis := []*entity.Api{
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "GET"},
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "POST"},
+		{Host: "api.chotot.org", Path: "/v2/private/cart/items", Method: "GET"},
+	}
+	security := &entity.SecurityUsage{}
+	security.Add([]*entity.Credential{{Type: "http", Scheme: "bearer"}})
+	privateRequestStructure := &entity.RequestStructure{Security: security}
+	buf := &bytes.Buffer{}
+	writer := newOpenApiDocumentWriter(buf, openapiutils.Version30)
+	require.NoError(t, writer.writeHeader())
+	for _, api := range apis {
+		var requestStructure *entity.RequestStructure
+		if api.Host == "api.chotot.org" {
+			requestStructure = privateRequestStructure
+		}
+		operation := buildOperation(api, requestStructure, nil, openapiutils.Version30)
+		require.NoError(t, writer.writeOperation(context.Background(), api, operation, buildSecuritySchemes(requestStructure)))
+	}
+	require.NoError(t, writer.close())
+
+	document := &entity.LoadStructureByApiIdResponse{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), document))
+	require.Equal(t, "3.0.0", document.OpenApi)
+	require.Len(t, document.Paths, 2)
+	require.Len(t, document.Paths["/v1/public/ads"], 3)
+	require.Contains(t, document.Paths["/v1/public/ads"], "post")
+	require.Equal(t, []*entity.Server{{Url: "api.chotot.org"}, {Url: "gateway.chotot.org"}}, document.Servers)
+	tagNames := make([]string, 0)
+	for _, tag := range document.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	require.Equal(t, []string{"api.chotot.org/private/cart", "gateway.chotot.org/public/ads"}, tagNames)
+	require.Equal(t, container.Map{
+		"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+	}, document.Components.SecuritySchemes)
+	require.NotContains(t, document.Paths["/v1/public/ads"].(map[string]any)["get"], "security")
+}
+
+func TestOpenApiDocumentWriter_TwoHostsGolden(t *testing.T) {
+	t.Parallel()
+	apis := []*entity.Api{
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "GET"},
+		{Host: "api.chotot.org", Path: "/v1/public/ads", Method: "PUT"},
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "POST"},
+		{Host: "api.chotot.org", Path: "/v2/private/cart/items", Method: "GET"},
+	}
+	for i, api := range apis {
+		// the operation ids of a document are unique
+		api.Id = primitive.ObjectID{byte(i + 1)}
+	}
+	buf := &bytes.Buffer{}
+	writer := newOpenApiDocumentWriter(buf, openapiutils.Version30)
+	require.NoError(t, writer.writeHeader())
+	for _, api := range apis {
+		operation := buildOperation(api, nil, nil, openapiutils.Version30)
+		require.NoError(t, writer.writeOperation(context.Background(), api, operation, nil))
+	}
+	require.NoError(t, writer.close())
+	indented := &bytes.Buffer{}
+	require.NoError(t, json.Indent(indented, buf.Bytes(), "", "  "))
+
+	golden := filepath.Join("testdata", "openapi_document_two_hosts.json")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, indented.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(want), indented.String())
+	validateDocument(t, filepath.Join("testdata", "schemas", "openapi-3.0.json"), buf.Bytes())
+}
+
+func TestOpenApiDocumentWriter_MethodServedByTwoHosts(t *testing.T) {
+	t.Parallel()
+	apis := []*entity.Api{
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "GET"},
+		{Host: "api.chotot.org", Path: "/v1/public/ads", Method: "GET"},
+		{Host: "gateway.chotot.org", Path: "/v1/public/chats", Method: "GET"},
+	}
+	buf := &bytes.Buffer{}
+	writer := newOpenApiDocumentWriter(buf, openapiutils.Version30)
+	require.NoError(t, writer.writeHeader())
+	for _, api := range apis {
+		operation := buildOperation(api, nil, nil, openapiutils.Version30)
+		require.NoError(t, writer.writeOperation(context.Background(), api, operation, nil))
+	}
+	require.NoError(t, writer.close())
+
+	// the second host is skipped and the document stays whole
+	document := container.Map{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	paths := cast.ToStringMap(document["paths"])
+	require.Len(t, paths, 2)
+	ads := cast.ToStringMap(paths["/v1/public/ads"])
+	require.Equal(t, []any{map[string]any{"url": "gateway.chotot.org"}}, ads["servers"])
+	require.NotContains(t, cast.ToStringMap(ads["get"]), "servers")
+}
+
+func TestOpenApiDocumentWriter_Empty(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	writer := newOpenApiDocumentWriter(buf, openapiutils.Version30)
+	require.NoError(t, writer.writeHeader())
+	require.NoError(t, writer.close())
+
+	document := container.Map{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	require.Empty(t, document["paths"])
+	require.Empty(t, document["servers"])
+}
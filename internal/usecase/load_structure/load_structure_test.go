@@ -0,0 +1,252 @@
+package loadstructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestBuildLoadStructureByApiIdResponse_Golden(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		version string
+		format  string
+		golden  string
+		schema  string
+	}{
+		{
+			name:    "Test BuildLoadStructureByApiIdResponse - 3.0 json",
+			version: openapiutils.Version30,
+			format:  openapiutils.FormatJSON,
+			golden:  "load_structure_3.0.json",
+			schema:  "openapi-3.0.json",
+		},
+		{
+			name:    "Test BuildLoadStructureByApiIdResponse - 3.0 yaml",
+			version: openapiutils.Version30,
+			format:  openapiutils.FormatYAML,
+			golden:  "load_structure_3.0.yaml",
+			schema:  "openapi-3.0.json",
+		},
+		{
+			name:    "Test BuildLoadStructureByApiIdResponse - 3.1 json",
+			version: openapiutils.Version31,
+			format:  openapiutils.FormatJSON,
+			golden:  "load_structure_3.1.json",
+			schema:  "openapi-3.1.json",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			uc := &loadStructureUC{}
+			apiObject, requestStructure, responseStructure := goldenStructures(t)
+			resp := uc.buildLoadStructureByApiIdResponse(context.Background(),
+				apiObject, requestStructure, responseStructure, goldenAnnotations(apiObject), goldenTraffic(), tt.version)
+			buf := &bytes.Buffer{}
+			require.NoError(t, openapiutils.Encode(buf, resp, tt.format))
+			document := buf.Bytes()
+			if tt.format == openapiutils.FormatJSON {
+				// the golden files are indented to be readable in diffs
+				indented := &bytes.Buffer{}
+				require.NoError(t, json.Indent(indented, document, "", "  "))
+				buf = indented
+			}
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+
+			if tt.format == openapiutils.FormatYAML {
+				document = yamlToJSON(t, document)
+			}
+			validateDocument(t, filepath.Join("testdata", "schemas", tt.schema), document)
+		})
+	}
+}
+
+func TestBuildLoadStructureByApiIdResponse_Schema31(t *testing.T) {
+	t.Parallel()
+	uc := &loadStructureUC{}
+	apiObject, requestStructure, responseStructure := goldenStructures(t)
+	resp := uc.buildLoadStructureByApiIdResponse(context.Background(),
+		apiObject, requestStructure, responseStructure, goldenAnnotations(apiObject), nil, openapiutils.Version31)
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	// every schema of a 3.1 document is a JSON Schema 2020-12 one
+	operation := document["paths"].(map[string]any)[apiObject.Path].(map[string]any)["post"].(map[string]any)
+	schemas := []any{
+		operation["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"],
+		operation["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"],
+	}
+	for _, parameter := range operation["parameters"].([]any) {
+		schemas = append(schemas, parameter.(map[string]any)["schema"])
+	}
+	for i, schema := range schemas {
+		compiler := jsonschema.NewCompiler()
+		compiler.DefaultDraft(jsonschema.Draft2020)
+		url := fmt.Sprintf("schema-%d.json", i)
+		require.NoError(t, compiler.AddResource(url, schema))
+		_, err := compiler.Compile(url)
+		require.NoError(t, err)
+	}
+}
+
+func goldenStructures(t *testing.T) (*entity.Api, *entity.RequestStructure, *entity.ResponseStructure) {
+	apiId, err := primitive.ObjectIDFromHex("66f1c2a0e4b0a1b2c3d4e5f6")
+	require.NoError(t, err)
+	apiObject := &entity.Api{
+		Title:  "Update ad",
+		Host:   "gateway.chotot.org",
+		Path:   "/v1/private/ads/{id}",
+		Method: "POST",
+	}
+	apiObject.Id = apiId
+	sampleAt := time.Date(2024, 10, 1, 8, 30, 0, 0, time.UTC)
+	requestStructure := &entity.RequestStructure{
+		ApiId: apiId,
+		Parameters: []*entity.Parameter{
+			{Name: "limit", Type: "integer", In: "query", Examples: []string{"20", "all"}},
+			{Name: "q", Type: "any", In: "query"},
+		},
+		Security: &entity.SecurityUsage{
+			Samples: 4,
+			Usages: []*entity.CredentialUsage{
+				{Credentials: []*entity.Credential{}, Count: 1},
+				{
+					Credentials: []*entity.Credential{
+						{Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+						{Type: "apiKey", In: "header", Name: "x-chotot-id-key"},
+					},
+					Count: 3,
+				},
+			},
+		},
+		BodySchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"subject": map[string]any{"type": "string", "example": "iPhone 15"},
+				"price":   map[string]any{"type": "number", "nullable": true},
+			},
+		},
+		Examples: []*entity.Example{
+			{CreatedAt: &sampleAt, Value: `{"subject":"iPhone 15","price":null}`},
+		},
+	}
+	responseStructure := &entity.ResponseStructure{
+		ApiId: apiId,
+		BodySchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"ad_id": map[string]any{"type": "number"},
+				"images": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "string", "nullable": true},
+				},
+			},
+		},
+		Examples: map[string][]*entity.Example{
+			"200": {
+				{CreatedAt: &sampleAt, Value: `{"ad_id":1302748,"images":["https://cdn.chotot.com/1.jpg",null]}`},
+				{CreatedAt: &sampleAt, Value: `{"ad_id":1302749,"images":[]}`},
+			},
+			"404": {
+				{CreatedAt: &sampleAt, Value: `{"message":"ad not found"}`},
+			},
+		},
+	}
+	return apiObject, requestStructure, responseStructure
+}
+
+func goldenAnnotations(apiObject *entity.Api) []*entity.Annotation {
+	return []*entity.Annotation{
+		{
+			ApiId:   apiObject.Id,
+			Target:  constants.AnnotationTargetRequest,
+			Pointer: "/properties/price",
+			Schema:  map[string]any{"description": "Price in VND", "example": 1500000},
+		},
+		{
+			ApiId:   apiObject.Id,
+			Target:  constants.AnnotationTargetResponse,
+			Pointer: "/properties/images/items",
+			Schema:  map[string]any{"description": "Url of an image"},
+		},
+		{
+			ApiId:   apiObject.Id,
+			Target:  constants.AnnotationTargetResponse,
+			Pointer: "/properties/removed",
+			Schema:  map[string]any{"description": "Orphaned"},
+		},
+	}
+}
+
+func goldenTraffic() container.Map {
+	since := time.Date(2024, 11, 22, 7, 0, 0, 0, time.UTC)
+	lastSeenAt := time.Date(2024, 11, 29, 7, 0, 3, 0, time.UTC)
+	summary := entity.SummarizeApiStats([]*entity.ApiStats{
+		{
+			Count:           4,
+			StatusCodes:     map[string]int64{"200": 3, "404": 1},
+			LatencyBuckets:  map[string]int64{"50": 2, "100": 1, "500": 1},
+			LatencyMsSum:    520,
+			LatencyMsMax:    320,
+			RequestSizeSum:  2048,
+			RequestSizeMax:  1024,
+			ResponseSizeSum: 8192,
+			ResponseSizeMax: 4096,
+			LastSeenAt:      &lastSeenAt,
+		},
+	})
+	return summary.BuildExtension(since)
+}
+
+func validateDocument(t *testing.T, schemaPath string, document []byte) {
+	schemaFile, err := os.Open(schemaPath)
+	require.NoError(t, err)
+	defer schemaFile.Close()
+	schemaDoc, err := jsonschema.UnmarshalJSON(schemaFile)
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource(schemaPath, schemaDoc))
+	schema, err := compiler.Compile(schemaPath)
+	require.NoError(t, err)
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate(instance))
+}
+
+func yamlToJSON(t *testing.T, data []byte) []byte {
+	var document any
+	require.NoError(t, yaml.Unmarshal(data, &document))
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,166 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	"github.com/ct-logic-api-document/pkg/redact"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+)
+
+type IMockUC interface {
+	// Respond answers req as the api it matches would, from its stored samples
+	// or its structures.
+	Respond(ctx context.Context, req *entity.MockRequest) (*entity.MockResponse, error)
+}
+
+type mockUC struct {
+	conf     *config.Config
+	storage  mongodb.MongoStorage
+	redactor *redact.Redactor
+
+	mu       sync.Mutex
+	routes   []*route
+	loadedAt time.Time
+}
+
+func NewMockUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+) IMockUC {
+	return &mockUC{
+		conf:     conf,
+		storage:  storage,
+		redactor: redact.New(conf.Samples.RedactKeys),
+	}
+}
+
+func (uc *mockUC) Respond(ctx context.Context, req *entity.MockRequest) (*entity.MockResponse, error) {
+	routes, err := uc.getRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api, err := matchRoute(routes, req.Method, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	prefer, err := parsePrefer(req.Prefer)
+	if err != nil {
+		return nil, err
+	}
+	responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, api.Id)
+	if err != nil {
+		return nil, err
+	}
+	statusCode := prefer.code
+	if statusCode == 0 {
+		statusCodes, err := uc.storage.GetStatusCodesByApiId(ctx, api.Id)
+		if err != nil {
+			return nil, err
+		}
+		statusCode = defaultStatusCode(statusCodes)
+	}
+	resp, err := uc.buildResponse(ctx, api, responseStructure, statusCode, prefer.example)
+	if err != nil {
+		return nil, err
+	}
+	if uc.conf.Mock.Latency {
+		if resp.Latency, err = uc.getLatency(ctx, api, statusCode); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// buildResponse answers with the named example, or else the first example of
+// the status code, the latest sample of the status code and finally a body
+// generated from the schema for the successful status codes.
+func (uc *mockUC) buildResponse(
+	ctx context.Context,
+	api *entity.Api,
+	responseStructure *entity.ResponseStructure,
+	statusCode int,
+	exampleName string,
+) (*entity.MockResponse, error) {
+	resp := &entity.MockResponse{
+		StatusCode: statusCode,
+	}
+	if responseStructure != nil {
+		if example := responseStructure.Example(statusCode, exampleName); example != nil {
+			resp.Body = example.Value
+			resp.Source = constants.MockSourceExample
+			return resp, nil
+		}
+	}
+	if exampleName != "" {
+		return nil, errors.NotFound("example %s of status %d is not recorded for api %s", exampleName, statusCode, api.Path)
+	}
+	sampleResponses, err := uc.storage.GetSampleResponseByApiId(ctx, &entity.GetSampleResponseByApiIdRequest{
+		ApiId:          api.Id,
+		HttpStatusCode: statusCode,
+		Limit:          1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(sampleResponses) > 0 {
+		resp.Body = uc.redactor.Body(sampleResponses[0].Body)
+		resp.Source = constants.MockSourceSample
+		return resp, nil
+	}
+	if statusCode/100 == 2 && responseStructure != nil && len(responseStructure.Schema()) > 0 {
+		body, err := json.Marshal(openapiutils.GenerateValue(responseStructure.Schema()))
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = string(body)
+		resp.Source = constants.MockSourceSchema
+		return resp, nil
+	}
+	return nil, errors.NotFound("no sample nor schema of status %d for api %s", statusCode, api.Path)
+}
+
+func (uc *mockUC) getLatency(ctx context.Context, api *entity.Api, statusCode int) (time.Duration, error) {
+	sampleResponses, err := uc.storage.GetSampleResponseByApiId(ctx, &entity.GetSampleResponseByApiIdRequest{
+		ApiId:          api.Id,
+		HttpStatusCode: statusCode,
+		Limit:          constants.MockLatencySamples,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return medianLatency(sampleResponses), nil
+}
+
+// getRoutes returns the routes of the apis, reloaded once they are older than
+// the configured TTL so the new apis are served without a restart.
+func (uc *mockUC) getRoutes(ctx context.Context) ([]*route, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.routes != nil && time.Since(uc.loadedAt) < uc.conf.Mock.RoutesTTL {
+		return uc.routes, nil
+	}
+	routes := make([]*route, 0)
+	err := uc.storage.IterateApis(ctx, func(api *entity.Api) error {
+		if api.Path == "" || !strings.HasPrefix(api.Path, "/") {
+			return nil
+		}
+		routes = append(routes, newRoute(api))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortRoutes(routes)
+	uc.routes = routes
+	uc.loadedAt = time.Now()
+	return routes, nil
+}
@@ -0,0 +1,125 @@
+package mock
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{[^/{}]+\}`)
+
+// route matches the paths of an api, a placeholder of its path template
+// matches any value of a path segment.
+type route struct {
+	api          *entity.Api
+	pattern      *regexp.Regexp
+	placeholders int
+}
+
+func newRoute(api *entity.Api) *route {
+	literals := placeholderRegexp.Split(api.Path, -1)
+	for i, literal := range literals {
+		literals[i] = regexp.QuoteMeta(literal)
+	}
+	return &route{
+		api:          api,
+		pattern:      regexp.MustCompile("^" + strings.Join(literals, `[^/:]+`) + "/?$"),
+		placeholders: len(literals) - 1,
+	}
+}
+
+// sortRoutes puts the most specific routes first, so /ads/new is matched
+// before /ads/{id}.
+func sortRoutes(routes []*route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].placeholders != routes[j].placeholders {
+			return routes[i].placeholders < routes[j].placeholders
+		}
+		return len(routes[i].api.Path) > len(routes[j].api.Path)
+	})
+}
+
+// matchRoute returns the api of the first route matching method and path.
+func matchRoute(routes []*route, method, path string) (*entity.Api, error) {
+	pathMatched := false
+	for _, route := range routes {
+		if !route.pattern.MatchString(path) {
+			continue
+		}
+		if strings.EqualFold(route.api.Method, method) {
+			return route.api, nil
+		}
+		pathMatched = true
+	}
+	if pathMatched {
+		return nil, errors.NotFound("no api documented for %s %s", method, path)
+	}
+	return nil, errors.NotFound("no api documented for path %s", path)
+}
+
+// preference is the answer a request prefers, from its Prefer header.
+type preference struct {
+	code    int
+	example string
+}
+
+// parsePrefer parses the preferences of a Prefer header, the unknown ones are
+// ignored as RFC 7240 requires.
+func parsePrefer(header string) (*preference, error) {
+	prefer := &preference{}
+	fields := strings.FieldsFunc(header, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+	for _, field := range fields {
+		name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case constants.PreferCode:
+			code, err := strconv.Atoi(value)
+			if err != nil || code < 100 || code > 599 {
+				return nil, errors.InvalidArgument("preferred code %q is not a http status code", value)
+			}
+			prefer.code = code
+		case constants.PreferExample:
+			prefer.example = value
+		}
+	}
+	return prefer, nil
+}
+
+// defaultStatusCode returns 200 when the api answered it or was never seen
+// answering, otherwise the first successful status code it answered and
+// finally its first one.
+func defaultStatusCode(statusCodes []int) int {
+	if len(statusCodes) == 0 || slices.Contains(statusCodes, 200) {
+		return 200
+	}
+	for _, statusCode := range statusCodes {
+		if statusCode/100 == 2 {
+			return statusCode
+		}
+	}
+	return statusCodes[0]
+}
+
+// medianLatency returns the median latency of the samples which recorded it.
+func medianLatency(sampleResponses []*entity.SampleResponse) time.Duration {
+	latencies := make([]int64, 0, len(sampleResponses))
+	for _, sampleResponse := range sampleResponses {
+		if sampleResponse.LatencyMs > 0 {
+			latencies = append(latencies, sampleResponse.LatencyMs)
+		}
+	}
+	if len(latencies) == 0 {
+		return 0
+	}
+	slices.Sort(latencies)
+	return time.Duration(latencies[len(latencies)/2]) * time.Millisecond
+}
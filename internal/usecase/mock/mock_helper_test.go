@@ -0,0 +1,153 @@
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchRoute(t *testing.T) {
+	t.Parallel()
+	apis := []*entity.Api{
+		{Path: "/v1/private/ads/{id}", Method: "GET"},
+		{Path: "/v1/private/ads/{id}", Method: "PUT"},
+		{Path: "/v1/private/ads/new", Method: "GET"},
+		{Path: "/v1/private/ads/{id}:publish", Method: "POST"},
+		{Path: "/v1/public/users/{uuid}/ads", Method: "GET"},
+	}
+	routes := make([]*route, 0, len(apis))
+	for _, api := range apis {
+		routes = append(routes, newRoute(api))
+	}
+	sortRoutes(routes)
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		wantApi *entity.Api
+		wantErr bool
+	}{
+		{
+			name:    "Test MatchRoute - placeholder",
+			method:  "get",
+			path:    "/v1/private/ads/1302748",
+			wantApi: apis[0],
+		},
+		{
+			name:    "Test MatchRoute - method",
+			method:  "PUT",
+			path:    "/v1/private/ads/1302748/",
+			wantApi: apis[1],
+		},
+		{
+			name:    "Test MatchRoute - literal before placeholder",
+			method:  "GET",
+			path:    "/v1/private/ads/new",
+			wantApi: apis[2],
+		},
+		{
+			name:    "Test MatchRoute - action",
+			method:  "POST",
+			path:    "/v1/private/ads/1302748:publish",
+			wantApi: apis[3],
+		},
+		{
+			name:    "Test MatchRoute - nested placeholder",
+			method:  "GET",
+			path:    "/v1/public/users/0b3a1c1e-8d6f-4b7c-9b7e-3f1f0c2d4e5a/ads",
+			wantApi: apis[4],
+		},
+		{
+			name:    "Test MatchRoute - method not documented",
+			method:  "DELETE",
+			path:    "/v1/private/ads/1302748",
+			wantErr: true,
+		},
+		{
+			name:    "Test MatchRoute - path not documented",
+			method:  "GET",
+			path:    "/v1/private/ads/1302748/images",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			api, err := matchRoute(routes, tt.method, tt.path)
+			if tt.wantErr {
+				require.Equal(t, errors.KindNotFound, errors.KindOf(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Same(t, tt.wantApi, api)
+		})
+	}
+}
+
+func TestParsePrefer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		header     string
+		wantPrefer *preference
+		wantErr    bool
+	}{
+		{
+			name:       "Test ParsePrefer - empty",
+			header:     "",
+			wantPrefer: &preference{},
+		},
+		{
+			name:       "Test ParsePrefer - code and example",
+			header:     `code=404, example="sample_2"`,
+			wantPrefer: &preference{code: 404, example: "sample_2"},
+		},
+		{
+			name:       "Test ParsePrefer - unknown preference",
+			header:     "return=minimal; code=201",
+			wantPrefer: &preference{code: 201},
+		},
+		{
+			name:    "Test ParsePrefer - invalid code",
+			header:  "code=4o4",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			prefer, err := parsePrefer(tt.header)
+			if tt.wantErr {
+				require.Equal(t, errors.KindInvalidArgument, errors.KindOf(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPrefer, prefer)
+		})
+	}
+}
+
+func TestDefaultStatusCode(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, 200, defaultStatusCode(nil))
+	require.Equal(t, 200, defaultStatusCode([]int{200, 404}))
+	require.Equal(t, 201, defaultStatusCode([]int{201, 400}))
+	require.Equal(t, 400, defaultStatusCode([]int{400, 500}))
+}
+
+func TestMedianLatency(t *testing.T) {
+	t.Parallel()
+	sampleResponses := []*entity.SampleResponse{
+		{LatencyMs: 120},
+		{LatencyMs: 0},
+		{LatencyMs: 30},
+		{LatencyMs: 80},
+	}
+	require.Equal(t, 80*time.Millisecond, medianLatency(sampleResponses))
+	require.Equal(t, time.Duration(0), medianLatency(nil))
+}
@@ -0,0 +1,60 @@
+package postman
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+)
+
+type IPostmanUC interface {
+	// ExportPostmanCollection builds a Postman collection of the apis matching
+	// req, grouped by host and tag.
+	ExportPostmanCollection(ctx context.Context, req *entity.ExportPostmanRequest) (*entity.PostmanCollection, error)
+}
+
+type postmanUC struct {
+	conf    *config.Config
+	storage mongodb.MongoStorage
+}
+
+func NewPostmanUC(conf *config.Config, storage mongodb.MongoStorage) IPostmanUC {
+	return &postmanUC{
+		conf:    conf,
+		storage: storage,
+	}
+}
+
+func (uc *postmanUC) ExportPostmanCollection(ctx context.Context,
+	req *entity.ExportPostmanRequest,
+) (*entity.PostmanCollection, error) {
+	builder := newPostmanCollectionBuilder()
+	err := uc.storage.IterateApisByFilter(ctx, &req.ApiFilter, func(apiObject *entity.Api) error {
+		tags := apiObject.OperationTags()
+		if req.Tag != "" && !slices.Contains(tags, req.Tag) {
+			return nil
+		}
+		requestStructure, err := uc.storage.GetRequestStructureByApiId(ctx, apiObject.Id)
+		if err != nil {
+			return err
+		}
+		responseStructure, err := uc.storage.GetResponseStructureByApiId(ctx, apiObject.Id)
+		if err != nil {
+			return err
+		}
+		tag := tags[0]
+		if req.Tag != "" {
+			tag = req.Tag
+		}
+		builder.add(apiObject, tag, buildPostmanItem(apiObject, requestStructure, responseStructure))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	document := &entity.LoadStructureByApiIdResponse{}
+	document.LoadDefault()
+	return builder.build(document.Info), nil
+}
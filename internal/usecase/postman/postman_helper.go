@@ -0,0 +1,208 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+)
+
+var (
+	pathParameterRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+	nonIdentifierRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// postmanCollectionBuilder groups the requests of a Postman collection in a
+// folder per host holding a folder per tag.
+type postmanCollectionBuilder struct {
+	hosts map[string]map[string][]*entity.PostmanItem
+}
+
+func newPostmanCollectionBuilder() *postmanCollectionBuilder {
+	return &postmanCollectionBuilder{
+		hosts: make(map[string]map[string][]*entity.PostmanItem),
+	}
+}
+
+// add files the request of apiObject under its host and first tag.
+func (b *postmanCollectionBuilder) add(apiObject *entity.Api, tag string, item *entity.PostmanItem) {
+	tags, ok := b.hosts[apiObject.Host]
+	if !ok {
+		tags = make(map[string][]*entity.PostmanItem)
+		b.hosts[apiObject.Host] = tags
+	}
+	// the inferred tags start with the host of their folder
+	tag = strings.TrimPrefix(tag, apiObject.Host+"/")
+	tags[tag] = append(tags[tag], item)
+}
+
+func (b *postmanCollectionBuilder) build(info *entity.Info) *entity.PostmanCollection {
+	collection := &entity.PostmanCollection{
+		Info: &entity.PostmanInfo{
+			Name:        info.Title,
+			Description: info.Description,
+			Schema:      constants.PostmanSchema,
+		},
+		Item:     make([]*entity.PostmanItem, 0, len(b.hosts)),
+		Variable: make([]*entity.PostmanVariable, 0, len(b.hosts)),
+	}
+	for _, host := range mapsutils.SortedKeys(b.hosts) {
+		hostFolder := &entity.PostmanItem{Name: host}
+		for _, tag := range mapsutils.SortedKeys(b.hosts[host]) {
+			items := b.hosts[host][tag]
+			sort.SliceStable(items, func(i, j int) bool {
+				return items[i].Request.Url.Raw < items[j].Request.Url.Raw
+			})
+			hostFolder.Item = append(hostFolder.Item, &entity.PostmanItem{Name: tag, Item: items})
+		}
+		collection.Item = append(collection.Item, hostFolder)
+		collection.Variable = append(collection.Variable, &entity.PostmanVariable{
+			Key:   postmanHostVariable(host),
+			Value: "https://" + host,
+		})
+	}
+	return collection
+}
+
+// buildPostmanItem builds the request of an api, templated from its path and
+// parameters, with its representative examples saved as responses.
+func buildPostmanItem(
+	apiObject *entity.Api,
+	requestStructure *entity.RequestStructure,
+	responseStructure *entity.ResponseStructure,
+) *entity.PostmanItem {
+	request := buildPostmanRequest(apiObject, requestStructure)
+	name := apiObject.Title
+	if name == "" {
+		name = apiObject.Method + " " + apiObject.Path
+	}
+	item := &entity.PostmanItem{
+		Name:        name,
+		Description: apiObject.Description,
+		Request:     request,
+		Response:    make([]*entity.PostmanResponse, 0),
+	}
+	if responseStructure == nil {
+		return item
+	}
+	statusCodes := make([]int, 0, len(responseStructure.Examples))
+	for statusCode := range responseStructure.Examples {
+		if code, err := strconv.Atoi(statusCode); err == nil {
+			statusCodes = append(statusCodes, code)
+		}
+	}
+	sort.Ints(statusCodes)
+	for _, code := range statusCodes {
+		for i, example := range responseStructure.Examples[strconv.Itoa(code)] {
+			item.Response = append(item.Response, &entity.PostmanResponse{
+				Name:            fmt.Sprintf("%d %s", code, entity.ExampleName(i)),
+				OriginalRequest: request,
+				Status:          http.StatusText(code),
+				Code:            code,
+				Header:          []*entity.PostmanHeader{{Key: constants.HeaderContentType, Value: constants.MIMEApplicationJSON}},
+				Body:            example.Value,
+				PreviewLanguage: constants.PostmanLanguageJSON,
+			})
+		}
+	}
+	return item
+}
+
+func buildPostmanRequest(apiObject *entity.Api, requestStructure *entity.RequestStructure) *entity.PostmanRequest {
+	request := &entity.PostmanRequest{
+		Method: apiObject.Method,
+		Header: make([]*entity.PostmanHeader, 0),
+		Url:    buildPostmanUrl(apiObject, requestStructure),
+	}
+	if body := buildPostmanBody(requestStructure); body != "" {
+		request.Header = append(request.Header, &entity.PostmanHeader{Key: constants.HeaderContentType, Value: constants.MIMEApplicationJSON})
+		request.Body = &entity.PostmanBody{
+			Mode: constants.PostmanBodyModeRaw,
+			Raw:  body,
+			Options: &entity.PostmanBodyOptions{
+				Raw: &entity.PostmanRawOptions{Language: constants.PostmanLanguageJSON},
+			},
+		}
+	}
+	return request
+}
+
+// buildPostmanUrl templates the url of an api on the variable of its host, the
+// path placeholders becoming path variables and the query parameters taking
+// their most recent example, the optional ones disabled.
+func buildPostmanUrl(apiObject *entity.Api, requestStructure *entity.RequestStructure) *entity.PostmanUrl {
+	host := "{{" + postmanHostVariable(apiObject.Host) + "}}"
+	path := pathParameterRegexp.ReplaceAllString(apiObject.Path, ":$1")
+	postmanUrl := &entity.PostmanUrl{
+		Host: []string{host},
+		Path: strings.Split(strings.TrimPrefix(path, "/"), "/"),
+	}
+	seen := make(map[string]bool)
+	for _, match := range pathParameterRegexp.FindAllStringSubmatch(apiObject.Path, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		postmanUrl.Variable = append(postmanUrl.Variable, &entity.PostmanVariable{Key: match[1]})
+	}
+	query := make([]string, 0)
+	if requestStructure != nil {
+		for _, parameter := range requestStructure.Parameters {
+			value := ""
+			if len(parameter.Examples) > 0 {
+				value = parameter.Examples[0]
+			}
+			postmanUrl.Query = append(postmanUrl.Query, &entity.PostmanQuery{
+				Key:         parameter.Name,
+				Value:       value,
+				Description: parameter.Type,
+				Disabled:    !parameter.Required,
+			})
+			if parameter.Required {
+				query = append(query, parameter.Name+"="+value)
+			}
+		}
+	}
+	postmanUrl.Raw = host + path
+	if len(query) > 0 {
+		postmanUrl.Raw += "?" + strings.Join(query, "&")
+	}
+	return postmanUrl
+}
+
+// buildPostmanBody returns the most representative example of the request
+// body, or a body generated from its schema, "" when the api takes no body.
+func buildPostmanBody(requestStructure *entity.RequestStructure) string {
+	if requestStructure == nil {
+		return ""
+	}
+	if len(requestStructure.Examples) > 0 {
+		return requestStructure.Examples[0].Value
+	}
+	schema := requestStructure.Schema()
+	if len(schema) == 0 {
+		return ""
+	}
+	body, err := json.MarshalIndent(openapiutils.GenerateValue(schema), "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(body)
+}
+
+// postmanHostVariable names the collection variable holding the base url of
+// host, e.g. gateway_chotot_org.
+func postmanHostVariable(host string) string {
+	if host == "" {
+		return "base_url"
+	}
+	return nonIdentifierRegexp.ReplaceAllString(host, "_")
+}
@@ -0,0 +1,97 @@
+package postman
+
+import (
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPostmanItem(t *testing.T) {
+	t.Parallel()
+	apiObject := &entity.Api{Host: "gateway.chotot.org", Path: "/v1/ads/{id}/images", Method: "POST"}
+	requestStructure := &entity.RequestStructure{
+		Parameters: []*entity.Parameter{
+			{Name: "lang", Type: "string", Required: true, Examples: []string{"vi"}},
+			{Name: "limit", Type: "integer"},
+		},
+		BodySchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"url": map[string]any{"type": "string"}},
+		},
+	}
+	responseStructure := &entity.ResponseStructure{
+		Examples: map[string][]*entity.Example{
+			"404": {{Value: `{"message":"not found"}`}},
+			"200": {{Value: `{"id":1}`}, {Value: `{"id":2}`}},
+		},
+	}
+	item := buildPostmanItem(apiObject, requestStructure, responseStructure)
+	wantRequest := &entity.PostmanRequest{
+		Method: "POST",
+		Header: []*entity.PostmanHeader{{Key: constants.HeaderContentType, Value: constants.MIMEApplicationJSON}},
+		Body: &entity.PostmanBody{
+			Mode:    constants.PostmanBodyModeRaw,
+			Raw:     "{\n  \"url\": \"string\"\n}",
+			Options: &entity.PostmanBodyOptions{Raw: &entity.PostmanRawOptions{Language: constants.PostmanLanguageJSON}},
+		},
+		Url: &entity.PostmanUrl{
+			Raw:  "{{gateway_chotot_org}}/v1/ads/:id/images?lang=vi",
+			Host: []string{"{{gateway_chotot_org}}"},
+			Path: []string{"v1", "ads", ":id", "images"},
+			Query: []*entity.PostmanQuery{
+				{Key: "lang", Value: "vi", Description: "string"},
+				{Key: "limit", Description: "integer", Disabled: true},
+			},
+			Variable: []*entity.PostmanVariable{{Key: "id"}},
+		},
+	}
+	require.Equal(t, "POST /v1/ads/{id}/images", item.Name)
+	require.Equal(t, wantRequest, item.Request)
+	names := make([]string, 0, len(item.Response))
+	for _, response := range item.Response {
+		require.Same(t, item.Request, response.OriginalRequest)
+		names = append(names, response.Name)
+	}
+	require.Equal(t, []string{"200 sample_1", "200 sample_2", "404 sample_1"}, names)
+	require.Equal(t, `{"message":"not found"}`, item.Response[2].Body)
+	require.Equal(t, "Not Found", item.Response[2].Status)
+}
+
+func TestPostmanCollectionBuilder(t *testing.T) {
+	t.Parallel()
+	builder := newPostmanCollectionBuilder()
+	for _, apiObject := range []*entity.Api{
+		{Host: "gateway.chotot.org", Path: "/v1/public/ads", Method: "GET"},
+		{Host: "gateway.chotot.org", Path: "/v1/private/bank_transfer/contracts", Method: "GET"},
+		{Host: "gateway.chotot.org", Path: "/v1/public/ad-listing", Method: "GET"},
+		{Host: "api.chotot.com", Path: "/ads", Method: "GET", Tags: []string{"Ads"}},
+	} {
+		builder.add(apiObject, apiObject.OperationTags()[0], buildPostmanItem(apiObject, nil, nil))
+	}
+	collection := builder.build(&entity.Info{Title: "Chotot API Document"})
+	require.Equal(t, constants.PostmanSchema, collection.Info.Schema)
+	require.Equal(t, []*entity.PostmanVariable{
+		{Key: "api_chotot_com", Value: "https://api.chotot.com"},
+		{Key: "gateway_chotot_org", Value: "https://gateway.chotot.org"},
+	}, collection.Variable)
+	folders := make(map[string][]string)
+	for _, hostFolder := range collection.Item {
+		for _, tagFolder := range hostFolder.Item {
+			key := hostFolder.Name + " > " + tagFolder.Name
+			for _, item := range tagFolder.Item {
+				folders[key] = append(folders[key], item.Request.Url.Raw)
+			}
+		}
+	}
+	require.Equal(t, map[string][]string{
+		"api.chotot.com > Ads": {"{{api_chotot_com}}/ads"},
+		"gateway.chotot.org > private/bank_transfer": {
+			"{{gateway_chotot_org}}/v1/private/bank_transfer/contracts",
+		},
+		"gateway.chotot.org > public/ad-listing": {"{{gateway_chotot_org}}/v1/public/ad-listing"},
+		"gateway.chotot.org > public/ads":        {"{{gateway_chotot_org}}/v1/public/ads"},
+	}, folders)
+	require.Equal(t, "api.chotot.com", collection.Item[0].Name)
+}
@@ -0,0 +1,163 @@
+package testgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/token"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ITestgenUC interface {
+	// GenerateTests turns the recorded samples of the apis selected by req
+	// into a curl script, Go table tests and a k6 script.
+	GenerateTests(ctx context.Context, req *entity.GenerateTestsRequest) (*entity.GenerateTestsResponse, error)
+}
+
+type testgenUC struct {
+	conf            *config.Config
+	storage         mongodb.MongoStorage
+	loadStructureUC loadstructure.ILoadstructure
+	redactor        *redact.Redactor
+}
+
+func NewTestgenUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	loadStructureUC loadstructure.ILoadstructure,
+) ITestgenUC {
+	return &testgenUC{
+		conf:            conf,
+		storage:         storage,
+		loadStructureUC: loadStructureUC,
+		redactor:        redact.New(conf.Samples.RedactKeys),
+	}
+}
+
+func (uc *testgenUC) GenerateTests(ctx context.Context,
+	req *entity.GenerateTestsRequest,
+) (*entity.GenerateTestsResponse, error) {
+	pkg := req.Package
+	if pkg == "" {
+		pkg = constants.TestgenDefaultPackage
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, errors.InvalidArgument("package must be a Go identifier")
+	}
+	samplesPerApi := req.Samples
+	if samplesPerApi <= 0 {
+		samplesPerApi = constants.TestgenSamplesPerApi
+	}
+	document, err := uc.loadDocument(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]*testOperation, 0)
+	samples := 0
+	for _, operation := range documentOperations(document) {
+		apiId, err := primitive.ObjectIDFromHex(operation.apiId)
+		if err != nil {
+			continue
+		}
+		recorded, err := uc.recentSamples(ctx, apiId)
+		if err != nil {
+			return nil, err
+		}
+		count, err := uc.storage.CountSampleRequestsByApiId(ctx, apiId)
+		if err != nil {
+			return nil, err
+		}
+		testOperation := buildTestOperation(operation, recorded, count, samplesPerApi)
+		if len(testOperation.Samples) == 0 && testOperation.Weight == 0 {
+			continue
+		}
+		operations = append(operations, testOperation)
+		samples += len(testOperation.Samples)
+	}
+	if len(operations) == 0 {
+		return nil, errors.NotFound("no recorded samples to generate tests from")
+	}
+
+	files, err := renderTestFiles(pkg, operations)
+	if err != nil {
+		return nil, err
+	}
+	logctx.Infow(ctx, "generated tests", "operations", len(operations), "samples", samples)
+	return &entity.GenerateTestsResponse{
+		Operations: len(operations),
+		Samples:    samples,
+		Files:      files,
+	}, nil
+}
+
+// loadDocument returns the OpenAPI document of the selected apis, decoded
+// from its JSON, so the tests assert the documented schemas.
+func (uc *testgenUC) loadDocument(ctx context.Context, req *entity.GenerateTestsRequest) (map[string]any, error) {
+	var data []byte
+	if req.ApiId != "" {
+		resp, err := uc.loadStructureUC.LoadStructureByApiId(ctx, &entity.LoadStructureByApiIdRequest{
+			ApiId: req.ApiId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(resp); err != nil {
+			return nil, err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := uc.loadStructureUC.LoadOpenApiDocument(ctx, &entity.LoadOpenApiDocumentRequest{
+			ApiFilter: req.ApiFilter,
+			Tag:       req.Tag,
+		}, &buf); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+	document := map[string]any{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// recentSamples returns the latest samples of the api, redacted, with their
+// responses when they were recorded.
+func (uc *testgenUC) recentSamples(ctx context.Context, apiId primitive.ObjectID) ([]*entity.Sample, error) {
+	sampleRequests := make([]*entity.SampleRequest, 0)
+	err := uc.storage.IterateLatestSampleRequestByApiId(ctx, &entity.GetSampleRequestByApiIdRequest{ApiId: apiId},
+		func(sampleRequest *entity.SampleRequest) error {
+			sampleRequests = append(sampleRequests, sampleRequest)
+			if len(sampleRequests) == constants.TestgenDistributionSamples {
+				return mongodbutils.ErrStopIteration
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	correlationIds := make([]string, 0, len(sampleRequests))
+	for _, sampleRequest := range sampleRequests {
+		if sampleRequest.CorrelationId != "" {
+			correlationIds = append(correlationIds, sampleRequest.CorrelationId)
+		}
+	}
+	var sampleResponses []*entity.SampleResponse
+	if len(correlationIds) > 0 {
+		if sampleResponses, err = uc.storage.GetSampleResponsesByCorrelationIds(ctx, apiId, correlationIds); err != nil {
+			return nil, err
+		}
+	}
+	return pairSamples(uc.redactor, sampleRequests, sampleResponses), nil
+}
@@ -0,0 +1,504 @@
+package testgen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	"github.com/spf13/cast"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var testTemplates = template.Must(template.New("").Funcs(map[string]any{
+	"shellQuote": shellQuote,
+	"curlUrl":    curlUrl,
+	"goHeaders":  goHeaders,
+	"quote":      strconv.Quote,
+	"json":       toIndentedJSON,
+}).ParseFS(templatesFS, "templates/*.tmpl"))
+
+// httpMethods are the operations of a path item.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var (
+	pathParameterRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+	nonEnvNameRegexp    = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// documentOperation is an operation of a document, the id of its api being
+// its operation id.
+type documentOperation struct {
+	apiId     string
+	method    string
+	host      string
+	path      string
+	operation map[string]any
+}
+
+// testOperation is an api and the samples replayed against it.
+type testOperation struct {
+	Name    string
+	Method  string
+	BaseUrl string
+	Path    string
+	Samples []*testSample
+	// Weight is the number of samples recorded for the api, its share of the
+	// traffic replayed by the k6 script
+	Weight     int64
+	Parameters []*parameterDistribution
+	Bodies     []string
+	Statuses   []int
+	// Headers are the credentials of the most frequent way the api was called
+	Headers []*testHeader
+}
+
+type testSample struct {
+	Name string
+	// Query is the encoded query string of the sample
+	Query   string
+	Body    string
+	Headers []*testHeader
+	Status  int
+	// Schema is the JSON schema of the response body documented for Status,
+	// "" when there is none
+	Schema string
+}
+
+// parameterDistribution is how often a query parameter was sent, and with
+// which values, in the samples.
+type parameterDistribution struct {
+	Name string `json:"name"`
+	// Presence is the share of the samples the parameter was sent in
+	Presence float64          `json:"presence"`
+	Values   []*weightedValue `json:"values"`
+}
+
+type weightedValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// testHeader is a credential header, its value is Prefix followed by the
+// environment variable Env as the recorded value is never stored.
+type testHeader struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	Env    string `json:"env"`
+}
+
+// pathParameter is a path parameter of the replayed apis, its value is read
+// from the environment variable Env as the ingested urls do not keep it.
+type pathParameter struct {
+	Name    string
+	Env     string
+	Default string
+}
+
+// k6Operation is the data of an operation the k6 script picks from.
+type k6Operation struct {
+	Name       string                   `json:"name"`
+	Method     string                   `json:"method"`
+	BaseUrl    string                   `json:"baseUrl"`
+	Path       string                   `json:"path"`
+	Weight     int64                    `json:"weight"`
+	Parameters []*parameterDistribution `json:"parameters"`
+	Bodies     []string                 `json:"bodies"`
+	Statuses   []int                    `json:"statuses"`
+	Headers    []*testHeader            `json:"headers"`
+}
+
+type testFilesData struct {
+	Package        string
+	Operations     []*testOperation
+	PathParameters []*pathParameter
+	// PathDefaults are the default values of the path parameters, by name
+	PathDefaults map[string]string
+	K6Operations []*k6Operation
+}
+
+// documentOperations lists the operations of a document, decoded from its
+// JSON, with the host of their path or else of the document.
+func documentOperations(document map[string]any) []*documentOperation {
+	operations := make([]*documentOperation, 0)
+	paths, _ := document["paths"].(map[string]any)
+	for _, path := range mapsutils.SortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]any)
+		host := serverUrl(pathItem["servers"])
+		if host == "" {
+			host = serverUrl(document["servers"])
+		}
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			operations = append(operations, &documentOperation{
+				apiId:     cast.ToString(operation["operationId"]),
+				method:    strings.ToUpper(method),
+				host:      host,
+				path:      path,
+				operation: operation,
+			})
+		}
+	}
+	return operations
+}
+
+func serverUrl(servers any) string {
+	items, _ := servers.([]any)
+	if len(items) == 0 {
+		return ""
+	}
+	server, _ := items[0].(map[string]any)
+	return cast.ToString(server["url"])
+}
+
+// pairSamples pairs each sample request with the first sample response of its
+// correlation id, redacting both.
+func pairSamples(
+	redactor *redact.Redactor,
+	sampleRequests []*entity.SampleRequest,
+	sampleResponses []*entity.SampleResponse,
+) []*entity.Sample {
+	responses := make(map[string]*entity.SampleResponse, len(sampleResponses))
+	for _, sampleResponse := range sampleResponses {
+		if _, ok := responses[sampleResponse.CorrelationId]; !ok {
+			responses[sampleResponse.CorrelationId] = sampleResponse
+		}
+	}
+	samples := make([]*entity.Sample, 0, len(sampleRequests))
+	for _, sampleRequest := range sampleRequests {
+		sampleRequest.Body = redactor.Body(sampleRequest.Body)
+		for _, parameter := range sampleRequest.Parameters {
+			parameter.Value = redactor.Parameter(parameter.Name, parameter.Value)
+		}
+		sample := &entity.Sample{
+			CorrelationId: sampleRequest.CorrelationId,
+			Request:       sampleRequest,
+		}
+		if sampleResponse := responses[sampleRequest.CorrelationId]; sampleRequest.CorrelationId != "" &&
+			sampleResponse != nil {
+			sampleResponse.Body = redactor.Body(sampleResponse.Body)
+			sample.Response = sampleResponse
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// buildTestOperation replays the first samplesPerApi distinct samples with a
+// response, the distributions being taken from all the samples.
+func buildTestOperation(
+	operation *documentOperation,
+	samples []*entity.Sample,
+	count int64,
+	samplesPerApi int,
+) *testOperation {
+	testOperation := &testOperation{
+		Name:       operation.method + " " + operation.host + operation.path,
+		Method:     operation.method,
+		BaseUrl:    baseUrl(operation.host),
+		Path:       operation.path,
+		Samples:    make([]*testSample, 0),
+		Weight:     count,
+		Parameters: buildParameterDistributions(samples),
+		Bodies:     make([]string, 0),
+		Statuses:   make([]int, 0),
+		Headers:    make([]*testHeader, 0),
+	}
+	if len(samples) == 0 {
+		return testOperation
+	}
+	seen := make(map[string]bool)
+	bodies := make(map[string]bool)
+	statuses := make(map[int]bool)
+	credentials := make(map[string]int)
+	for _, sample := range samples {
+		if body := sample.Request.Body; body != "" && !bodies[body] && len(bodies) < constants.TestgenK6Bodies {
+			bodies[body] = true
+			testOperation.Bodies = append(testOperation.Bodies, body)
+		}
+		headers := buildTestHeaders(sample.Request.Credentials)
+		credentials[toJSON(headers)]++
+		if sample.Response == nil {
+			continue
+		}
+		statuses[sample.Response.HttpStatusCode] = true
+		query := buildQuery(sample.Request.Parameters)
+		key := strings.Join([]string{query, sample.Request.Body, strconv.Itoa(sample.Response.HttpStatusCode)}, "\n")
+		if seen[key] || len(testOperation.Samples) == samplesPerApi {
+			continue
+		}
+		seen[key] = true
+		testOperation.Samples = append(testOperation.Samples, &testSample{
+			Name:    entity.ExampleName(len(testOperation.Samples)),
+			Query:   query,
+			Body:    sample.Request.Body,
+			Headers: headers,
+			Status:  sample.Response.HttpStatusCode,
+			Schema:  responseSchema(operation.operation, sample.Response.HttpStatusCode),
+		})
+	}
+	for status := range statuses {
+		testOperation.Statuses = append(testOperation.Statuses, status)
+	}
+	sort.Ints(testOperation.Statuses)
+	// the k6 script authenticates the way the api was most often called
+	best := 0
+	for _, key := range mapsutils.SortedKeys(credentials) {
+		if credentials[key] > best {
+			best = credentials[key]
+			_ = json.Unmarshal([]byte(key), &testOperation.Headers)
+		}
+	}
+	return testOperation
+}
+
+// buildParameterDistributions counts the query parameters of the samples and
+// their values, from the most to the least frequent.
+func buildParameterDistributions(samples []*entity.Sample) []*parameterDistribution {
+	presences := make(map[string]int)
+	values := make(map[string]map[string]int)
+	for _, sample := range samples {
+		for _, parameter := range sample.Request.Parameters {
+			if parameter.In != constants.ParameterInQuery {
+				continue
+			}
+			presences[parameter.Name]++
+			if values[parameter.Name] == nil {
+				values[parameter.Name] = make(map[string]int)
+			}
+			values[parameter.Name][parameter.Value]++
+		}
+	}
+	distributions := make([]*parameterDistribution, 0, len(presences))
+	for _, name := range mapsutils.SortedKeys(presences) {
+		distribution := &parameterDistribution{
+			Name:     name,
+			Presence: float64(presences[name]) / float64(len(samples)),
+			Values:   make([]*weightedValue, 0, len(values[name])),
+		}
+		for _, value := range mapsutils.SortedKeys(values[name]) {
+			distribution.Values = append(distribution.Values, &weightedValue{Value: value, Count: values[name][value]})
+		}
+		sort.SliceStable(distribution.Values, func(i, j int) bool {
+			return distribution.Values[i].Count > distribution.Values[j].Count
+		})
+		distributions = append(distributions, distribution)
+	}
+	return distributions
+}
+
+// buildTestHeaders turns the credentials of a sample into the headers sending
+// them, the api keys of the query being left out.
+func buildTestHeaders(credentials []*entity.Credential) []*testHeader {
+	headers := make([]*testHeader, 0, len(credentials))
+	for _, credential := range credentials {
+		switch {
+		case credential.Type == constants.SecuritySchemeTypeHTTP && credential.Scheme == constants.HTTPSchemeBearer:
+			headers = append(headers, &testHeader{Name: "Authorization", Prefix: "Bearer ", Env: "API_TOKEN"})
+		case credential.Type == constants.SecuritySchemeTypeHTTP && credential.Scheme == constants.HTTPSchemeBasic:
+			headers = append(headers, &testHeader{Name: "Authorization", Prefix: "Basic ", Env: "API_BASIC_AUTH"})
+		case credential.In == constants.CredentialInHeader:
+			headers = append(headers, &testHeader{Name: credential.Name, Env: envName(credential.Name)})
+		case credential.In == constants.CredentialInCookie:
+			headers = append(headers, &testHeader{Name: "Cookie", Prefix: credential.Name + "=", Env: envName(credential.Name)})
+		}
+	}
+	return headers
+}
+
+// buildQuery encodes the query parameters of a sample.
+func buildQuery(parameters []*entity.Parameter) string {
+	query := url.Values{}
+	for _, parameter := range parameters {
+		if parameter.In == constants.ParameterInQuery {
+			query.Add(parameter.Name, parameter.Value)
+		}
+	}
+	return query.Encode()
+}
+
+// responseSchema returns the compact JSON of the schema documented for the
+// JSON response of statusCode, "" when there is none.
+func responseSchema(operation map[string]any, statusCode int) string {
+	responses, _ := operation["responses"].(map[string]any)
+	response, _ := responses[strconv.Itoa(statusCode)].(map[string]any)
+	content, _ := response["content"].(map[string]any)
+	mediaType, _ := content[constants.MIMEApplicationJSON].(map[string]any)
+	schema, ok := mediaType["schema"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	return toJSON(schema)
+}
+
+// collectPathParameters lists the path parameters of the operations, the
+// {id} and {uuid} placeholders of the ingested urls defaulting to valid
+// values.
+func collectPathParameters(operations []*testOperation) []*pathParameter {
+	parameters := make([]*pathParameter, 0)
+	seen := make(map[string]bool)
+	for _, operation := range operations {
+		for _, match := range pathParameterRegexp.FindAllStringSubmatch(operation.Path, -1) {
+			name := match[1]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			parameter := &pathParameter{Name: name, Env: "PATH_" + envName(name), Default: "1"}
+			if name == "uuid" {
+				parameter.Default = "00000000-0000-0000-0000-000000000000"
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+// renderTestFiles renders the curl script, the Go tests and the k6 script of
+// the operations.
+func renderTestFiles(pkg string, operations []*testOperation) ([]*entity.GeneratedFile, error) {
+	data := &testFilesData{
+		Package:        pkg,
+		Operations:     operations,
+		PathParameters: collectPathParameters(operations),
+		PathDefaults:   make(map[string]string),
+		K6Operations:   make([]*k6Operation, 0, len(operations)),
+	}
+	for _, parameter := range data.PathParameters {
+		data.PathDefaults[parameter.Name] = parameter.Default
+	}
+	for _, operation := range operations {
+		if operation.Weight == 0 {
+			continue
+		}
+		data.K6Operations = append(data.K6Operations, &k6Operation{
+			Name:       operation.Name,
+			Method:     operation.Method,
+			BaseUrl:    operation.BaseUrl,
+			Path:       operation.Path,
+			Weight:     operation.Weight,
+			Parameters: operation.Parameters,
+			Bodies:     operation.Bodies,
+			Statuses:   operation.Statuses,
+			Headers:    operation.Headers,
+		})
+	}
+	curl, err := renderTemplate("replay.sh.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	goTests, err := renderTemplate("api_test.go.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source([]byte(goTests))
+	if err != nil {
+		return nil, fmt.Errorf("format generated go tests: %w", err)
+	}
+	k6, err := renderTemplate("k6.js.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	return []*entity.GeneratedFile{
+		{Name: constants.TestgenCurlFile, Content: curl},
+		{Name: pkg + "_test.go", Content: string(formatted)},
+		{Name: constants.TestgenK6File, Content: k6},
+	}, nil
+}
+
+func renderTemplate(name string, data *testFilesData) (string, error) {
+	var buf bytes.Buffer
+	if err := testTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// curlUrl is the double quoted url of a sample, the server and the path
+// parameters being read from the environment.
+func curlUrl(operation *testOperation, sample *testSample) string {
+	path := pathParameterRegexp.ReplaceAllStringFunc(shellEscape(operation.Path), func(match string) string {
+		return "${PATH_" + envName(strings.Trim(match, "{}")) + "}"
+	})
+	rawUrl := `"${BASE_URL:-` + shellEscape(operation.BaseUrl) + `}` + path
+	if sample.Query != "" {
+		rawUrl += "?" + shellEscape(sample.Query)
+	}
+	return rawUrl + `"`
+}
+
+// goHeaders is the Go expression of the headers of a sample.
+func goHeaders(headers []*testHeader) string {
+	if len(headers) == 0 {
+		return "nil"
+	}
+	entries := make([]string, 0, len(headers))
+	for _, header := range headers {
+		value := "os.Getenv(" + strconv.Quote(header.Env) + ")"
+		if header.Prefix != "" {
+			value = strconv.Quote(header.Prefix) + " + " + value
+		}
+		entries = append(entries, strconv.Quote(header.Name)+": "+value)
+	}
+	return "map[string]string{" + strings.Join(entries, ", ") + "}"
+}
+
+// shellQuote single quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellEscape escapes s to be written between double quotes.
+func shellEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
+
+// envName is the environment variable of a name, e.g. X_CHOTOT_ID_KEY.
+func envName(name string) string {
+	return strings.Trim(nonEnvNameRegexp.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+}
+
+// baseUrl is the server of a host, https unless it already has a scheme.
+func baseUrl(host string) string {
+	if host == "" || strings.Contains(host, "://") {
+		return strings.TrimSuffix(host, "/")
+	}
+	return "https://" + host
+}
+
+func toJSON(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func toIndentedJSON(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
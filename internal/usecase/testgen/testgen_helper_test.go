@@ -0,0 +1,153 @@
+package testgen
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/pkg/redact"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testSamples() []*entity.Sample {
+	return pairSamples(redact.New(nil), []*entity.SampleRequest{
+		{
+			Parameters: []*entity.Parameter{
+				{Name: "limit", Value: "10", In: constants.ParameterInQuery},
+				{Name: "fields", Value: "id|subject", In: constants.ParameterInQuery},
+			},
+			Credentials:   []*entity.Credential{{Type: constants.SecuritySchemeTypeHTTP, Scheme: constants.HTTPSchemeBearer}},
+			CorrelationId: "a",
+		},
+		{
+			Parameters:    []*entity.Parameter{{Name: "limit", Value: "10", In: constants.ParameterInQuery}},
+			Credentials:   []*entity.Credential{{Type: constants.SecuritySchemeTypeHTTP, Scheme: constants.HTTPSchemeBearer}},
+			CorrelationId: "b",
+		},
+		{
+			Parameters:    []*entity.Parameter{{Name: "limit", Value: "20", In: constants.ParameterInQuery}},
+			Body:          `{"subject":"it's new","email":"user@chotot.vn"}`,
+			CorrelationId: "c",
+		},
+		{
+			Parameters:  []*entity.Parameter{{Name: "limit", Value: "10", In: constants.ParameterInQuery}},
+			Credentials: []*entity.Credential{{Type: constants.SecuritySchemeTypeHTTP, Scheme: constants.HTTPSchemeBearer}},
+		},
+	}, []*entity.SampleResponse{
+		{CorrelationId: "a", HttpStatusCode: 200, Body: `{"ad_id":1}`},
+		{CorrelationId: "b", HttpStatusCode: 200, Body: `{"ad_id":2}`},
+		{CorrelationId: "c", HttpStatusCode: 404},
+	})
+}
+
+func testDocumentOperation() *documentOperation {
+	return &documentOperation{
+		apiId:  "65a0f0f0f0f0f0f0f0f0f0f0",
+		method: "GET",
+		host:   "gateway.chotot.org",
+		path:   "/v1/ads/{id}",
+		operation: map[string]any{
+			"responses": map[string]any{
+				"200": map[string]any{
+					"content": map[string]any{
+						"application/json": map[string]any{
+							"schema": map[string]any{"type": "object", "properties": map[string]any{"ad_id": map[string]any{"type": "integer"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDocumentOperations(t *testing.T) {
+	t.Parallel()
+	document := map[string]any{
+		"servers": []any{map[string]any{"url": "gateway.chotot.org"}},
+		"paths": map[string]any{
+			"/v1/ads": map[string]any{
+				"servers": []any{map[string]any{"url": "api.chotot.org"}},
+				"post":    map[string]any{"operationId": "65a0f0f0f0f0f0f0f0f0f0f1"},
+				"get":     map[string]any{"operationId": "65a0f0f0f0f0f0f0f0f0f0f2"},
+			},
+			"/v1/ads/{id}": map[string]any{
+				"get": map[string]any{"operationId": "65a0f0f0f0f0f0f0f0f0f0f3"},
+			},
+		},
+	}
+	operations := documentOperations(document)
+	require.Len(t, operations, 3)
+	for i, want := range []documentOperation{
+		{apiId: "65a0f0f0f0f0f0f0f0f0f0f2", method: "GET", host: "api.chotot.org", path: "/v1/ads"},
+		{apiId: "65a0f0f0f0f0f0f0f0f0f0f1", method: "POST", host: "api.chotot.org", path: "/v1/ads"},
+		{apiId: "65a0f0f0f0f0f0f0f0f0f0f3", method: "GET", host: "gateway.chotot.org", path: "/v1/ads/{id}"},
+	} {
+		require.Equal(t, want.apiId, operations[i].apiId)
+		require.Equal(t, want.method, operations[i].method)
+		require.Equal(t, want.host, operations[i].host)
+		require.Equal(t, want.path, operations[i].path)
+	}
+}
+
+func TestBuildTestOperation(t *testing.T) {
+	t.Parallel()
+	bearer := []*testHeader{{Name: "Authorization", Prefix: "Bearer ", Env: "API_TOKEN"}}
+	operation := buildTestOperation(testDocumentOperation(), testSamples(), 40, 2)
+	require.Equal(t, "GET gateway.chotot.org/v1/ads/{id}", operation.Name)
+	require.Equal(t, "https://gateway.chotot.org", operation.BaseUrl)
+	require.Equal(t, int64(40), operation.Weight)
+	require.Equal(t, []*testSample{
+		{
+			Name:    "sample_1",
+			Query:   "fields=id%7Csubject&limit=10",
+			Headers: bearer,
+			Status:  200,
+			Schema:  `{"properties":{"ad_id":{"type":"integer"}},"type":"object"}`,
+		},
+		{
+			Name:    "sample_2",
+			Query:   "limit=10",
+			Headers: bearer,
+			Status:  200,
+			Schema:  `{"properties":{"ad_id":{"type":"integer"}},"type":"object"}`,
+		},
+	}, operation.Samples)
+	require.Equal(t, []*parameterDistribution{
+		{Name: "fields", Presence: 0.25, Values: []*weightedValue{{Value: "id|subject", Count: 1}}},
+		{Name: "limit", Presence: 1, Values: []*weightedValue{{Value: "10", Count: 3}, {Value: "20", Count: 1}}},
+	}, operation.Parameters)
+	require.Equal(t, []string{`{"email":"REDACTED","subject":"it's new"}`}, operation.Bodies)
+	require.Equal(t, []int{200, 404}, operation.Statuses)
+	require.Equal(t, bearer, operation.Headers)
+}
+
+func TestRenderTestFiles(t *testing.T) {
+	t.Parallel()
+	operation := buildTestOperation(testDocumentOperation(), testSamples(), 40, 5)
+	files, err := renderTestFiles("apitest", []*testOperation{operation})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, file := range files {
+		golden := filepath.Join("testdata", file.Name+".golden")
+		if *update {
+			require.NoError(t, os.WriteFile(golden, []byte(file.Content), 0o644))
+		}
+		want, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		require.Equal(t, string(want), file.Content, file.Name)
+	}
+}
+
+func TestCurlUrl(t *testing.T) {
+	t.Parallel()
+	operation := &testOperation{BaseUrl: "https://gateway.chotot.org", Path: "/v1/ads/{ad-id}/$images"}
+	require.Equal(t, `"${BASE_URL:-https://gateway.chotot.org}/v1/ads/${PATH_AD_ID}/\$images?q=a%22b"`,
+		curlUrl(operation, &testSample{Query: "q=a%22b"}))
+	require.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	require.Equal(t, "X_CHOTOT_ID_KEY", envName("x-chotot-id-key"))
+}
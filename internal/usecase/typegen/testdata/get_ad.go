@@ -0,0 +1,55 @@
+// Code generated by ct-logic-api-document from the observed traffic. DO NOT EDIT.
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type GetAdsByIDParams struct {
+	// fields to return
+	Fields *string `url:"fields,omitempty"`
+	Limit  int64   `url:"limit"`
+}
+
+type GetAdsByIDResponse struct {
+	// id of the ad
+	AdID      int64                          `json:"ad_id"`
+	Contact   GetAdsByIDResponseContact      `json:"contact,omitempty"`
+	CreatedAt *time.Time                     `json:"created_at,omitempty"`
+	ImageUrls []string                       `json:"image_urls,omitempty"`
+	Images    []GetAdsByIDResponseImagesItem `json:"images,omitempty"`
+	Params    map[string]any                 `json:"params,omitempty"`
+	Price     *float64                       `json:"price,omitempty"`
+	Region    *string                        `json:"region,omitempty"`
+	Seller    *GetAdsByIDResponseSeller      `json:"seller,omitempty"`
+	Status    GetAdsByIDResponseStatus       `json:"status"`
+}
+
+// GetAdsByIDResponseContact is one of GetAdsByIDResponseContactVariant1, string, decode it into the variant matching its content.
+type GetAdsByIDResponseContact = json.RawMessage
+
+type GetAdsByIDResponseContactVariant1 struct {
+	Phone *string `json:"phone,omitempty"`
+}
+
+type GetAdsByIDResponseImagesItem struct {
+	URL *string `json:"url,omitempty"`
+}
+
+type GetAdsByIDResponseSeller struct {
+	Account
+	Name string `json:"name"`
+}
+
+type Account struct {
+	AccountID *int64 `json:"account_id,omitempty"`
+}
+
+type GetAdsByIDResponseStatus string
+
+const (
+	GetAdsByIDResponseStatusActive GetAdsByIDResponseStatus = "active"
+	GetAdsByIDResponseStatusHidden GetAdsByIDResponseStatus = "hidden"
+)
@@ -0,0 +1,41 @@
+// Code generated by ct-logic-api-document from the observed traffic. DO NOT EDIT.
+
+export interface GetAdsByIDParams {
+  /** fields to return */
+  fields?: string;
+  limit: number;
+}
+
+export interface GetAdsByIDResponse {
+  /** id of the ad */
+  ad_id: number;
+  contact?: GetAdsByIDResponseContact;
+  created_at?: string;
+  image_urls?: string[];
+  images?: GetAdsByIDResponseImagesItem[];
+  params?: Record<string, unknown>;
+  price?: number | null;
+  region?: string | null;
+  seller?: GetAdsByIDResponseSeller;
+  status: GetAdsByIDResponseStatus;
+}
+
+export type GetAdsByIDResponseContact = GetAdsByIDResponseContactVariant1 | string;
+
+export interface GetAdsByIDResponseContactVariant1 {
+  phone?: string;
+}
+
+export interface GetAdsByIDResponseImagesItem {
+  url?: string;
+}
+
+export interface GetAdsByIDResponseSeller extends Account {
+  name: string;
+}
+
+export interface Account {
+  account_id?: number;
+}
+
+export type GetAdsByIDResponseStatus = "active" | "hidden";
@@ -0,0 +1,113 @@
+package typegen
+
+import (
+	"context"
+	"encoding/json"
+	"go/token"
+
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/errors"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	loadstructure "github.com/ct-logic-api-document/internal/usecase/load_structure"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+)
+
+type ITypegenUC interface {
+	// GenerateTypes turns the structures of an api, or a type of the types
+	// collection, into Go structs or TypeScript interfaces.
+	GenerateTypes(ctx context.Context, req *entity.GenerateTypesRequest) (*entity.GenerateTypesResponse, error)
+}
+
+type typegenUC struct {
+	conf            *config.Config
+	storage         mongodb.MongoStorage
+	loadStructureUC loadstructure.ILoadstructure
+}
+
+func NewTypegenUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	loadStructureUC loadstructure.ILoadstructure,
+) ITypegenUC {
+	return &typegenUC{
+		conf:            conf,
+		storage:         storage,
+		loadStructureUC: loadStructureUC,
+	}
+}
+
+func (uc *typegenUC) GenerateTypes(ctx context.Context,
+	req *entity.GenerateTypesRequest,
+) (*entity.GenerateTypesResponse, error) {
+	if req.Language != constants.TypegenLanguageGo && req.Language != constants.TypegenLanguageTypeScript {
+		return nil, errors.InvalidArgument("lang must be %s or %s",
+			constants.TypegenLanguageGo, constants.TypegenLanguageTypeScript)
+	}
+	pkg := req.Package
+	if pkg == "" {
+		pkg = constants.TypegenDefaultPackage
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, errors.InvalidArgument("package must be a Go identifier")
+	}
+	if (req.ApiId == "") == (req.TypeName == "") {
+		return nil, errors.InvalidArgument("either an api or a type must be given")
+	}
+
+	builder := newTypeBuilder()
+	if req.ApiId != "" {
+		if err := uc.declareApiTypes(ctx, builder, req.ApiId); err != nil {
+			return nil, err
+		}
+	} else {
+		t, err := uc.storage.GetTypeByName(ctx, req.TypeName)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return nil, errors.NotFound("type %s not found", req.TypeName)
+		}
+		builder.declareRoot(t.Name, typeSchema(t))
+	}
+
+	code := ""
+	switch req.Language {
+	case constants.TypegenLanguageGo:
+		var err error
+		if code, err = renderGo(pkg, builder.declarations); err != nil {
+			return nil, err
+		}
+	case constants.TypegenLanguageTypeScript:
+		code = renderTypeScript(builder.declarations)
+	}
+	return &entity.GenerateTypesResponse{
+		Language: req.Language,
+		Code:     code,
+	}, nil
+}
+
+// declareApiTypes declares the types of the operation of the api, as it is
+// documented: with its annotations and schema override applied.
+func (uc *typegenUC) declareApiTypes(ctx context.Context, builder *typeBuilder, apiId string) error {
+	resp, err := uc.loadStructureUC.LoadStructureByApiId(ctx, &entity.LoadStructureByApiIdRequest{
+		ApiId:   apiId,
+		Version: openapiutils.Version30,
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	document := map[string]any{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if declared := declareOperationTypes(builder, document); declared == 0 {
+		return errors.NotFound("api %s has no structure to generate types from", apiId)
+	}
+	return nil
+}
@@ -0,0 +1,726 @@
+package typegen
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	mapsutils "github.com/ct-logic-api-document/utils/maps"
+	openapiutils "github.com/ct-logic-api-document/utils/openapi"
+	"github.com/spf13/cast"
+)
+
+const generatedHeader = "// Code generated by ct-logic-api-document from the observed traffic. DO NOT EDIT.\n"
+
+// The kinds of a type expression besides the types of a schema.
+const (
+	kindAny   = "any"
+	kindArray = "array"
+	kindMap   = "map"
+	kindRef   = "ref"
+)
+
+// httpMethods are the operations of a path item.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var (
+	versionSegmentRegexp = regexp.MustCompile(`^v\d+$`)
+	nonAlphanumRegexp    = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	tsIdentifierRegexp   = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// goInitialisms are written upper case in Go names, e.g. AdID.
+var goInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "SSL": true, "TTL": true, "UID": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// typeExpr is the type of a value: a schema type, an array or a map of its
+// items, or a reference to a declaration.
+type typeExpr struct {
+	kind     string
+	format   string
+	items    *typeExpr
+	ref      string
+	nullable bool
+}
+
+// declaration is a named type of the generated code, a struct unless it is an
+// enum, a union of variants or an alias.
+type declaration struct {
+	name        string
+	description string
+	// tag is the struct tag key of the fields, json unless they are query
+	// parameters
+	tag      string
+	fields   []*field
+	embeds   []string
+	enumType string
+	enum     []any
+	variants []*typeExpr
+	alias    *typeExpr
+}
+
+type field struct {
+	name        string
+	typ         *typeExpr
+	required    bool
+	description string
+}
+
+// typeBuilder declares the types of schemas, a nested object, enum or union
+// being declared under the name of its parent followed by its own.
+type typeBuilder struct {
+	declarations []*declaration
+	byName       map[string]*declaration
+}
+
+func newTypeBuilder() *typeBuilder {
+	return &typeBuilder{
+		declarations: make([]*declaration, 0),
+		byName:       make(map[string]*declaration),
+	}
+}
+
+// declareRoot declares schema under name, aliasing the types which are not
+// declared on their own, e.g. arrays, the nullability of a root is dropped.
+func (b *typeBuilder) declareRoot(name string, schema map[string]any) {
+	b.declareRootWithTag(name, schema, "json")
+}
+
+func (b *typeBuilder) declareRootWithTag(name string, schema map[string]any, tag string) {
+	expr := b.expr(pascalName(name), schema, tag)
+	if expr.kind == kindRef {
+		return
+	}
+	b.add(&declaration{name: pascalName(name), alias: expr})
+}
+
+// add declares d under a free name, suffixing its name by a number when it is
+// taken.
+func (b *typeBuilder) add(d *declaration) {
+	name := d.name
+	for i := 2; b.byName[d.name] != nil; i++ {
+		d.name = name + strconv.Itoa(i)
+	}
+	b.byName[d.name] = d
+	b.declarations = append(b.declarations, d)
+}
+
+func (b *typeBuilder) expr(name string, schema map[string]any, tag string) *typeExpr {
+	if schema == nil {
+		return &typeExpr{kind: kindAny}
+	}
+	// the references left unresolved, e.g. recursive ones, take any value
+	if _, ok := schema["$ref"]; ok {
+		return &typeExpr{kind: kindAny}
+	}
+	if _, ok := schema[openapiutils.RecursiveRefKey]; ok {
+		return &typeExpr{kind: kindAny}
+	}
+	types, nullable := schemaTypes(schema)
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return b.enumExpr(name, schema, enum, types, nullable)
+	}
+	variants := make([]map[string]any, 0)
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		subschemas, _ := schema[keyword].([]any)
+		for _, item := range subschemas {
+			subschema, _ := item.(map[string]any)
+			if subschemaTypes, subschemaNullable := schemaTypes(subschema); subschemaNullable &&
+				len(subschemaTypes) == 0 {
+				nullable = true
+				continue
+			}
+			variants = append(variants, subschema)
+		}
+	}
+	// the types of a 3.1 type array are the variants of a union
+	if len(variants) == 0 && len(types) > 1 {
+		for _, t := range types {
+			variant := make(map[string]any, len(schema))
+			for key, value := range schema {
+				variant[key] = value
+			}
+			variant["type"] = t
+			variants = append(variants, variant)
+		}
+	}
+	var expr *typeExpr
+	switch {
+	case len(variants) == 1:
+		expr = b.expr(name, variants[0], tag)
+	case len(variants) > 1:
+		expr = b.unionExpr(name, schema, variants, tag)
+	default:
+		expr = b.typedExpr(name, schema, types, tag)
+	}
+	expr.nullable = expr.nullable || nullable
+	return expr
+}
+
+func (b *typeBuilder) typedExpr(name string, schema map[string]any, types []string, tag string) *typeExpr {
+	schemaType := ""
+	if len(types) > 0 {
+		schemaType = types[0]
+	}
+	if _, ok := schema["properties"]; ok && schemaType == "" {
+		schemaType = "object"
+	}
+	if _, ok := schema["allOf"]; ok && schemaType == "" {
+		schemaType = "object"
+	}
+	switch schemaType {
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		return &typeExpr{kind: kindArray, items: b.expr(name+"Item", items, "json")}
+	case "object":
+		// an object seen empty is a map rather than an empty struct
+		properties, _ := schema["properties"].(map[string]any)
+		_, hasAllOf := schema["allOf"]
+		if len(properties) > 0 || hasAllOf {
+			return b.structExpr(name, schema, tag)
+		}
+		additionalProperties, _ := schema["additionalProperties"].(map[string]any)
+		return &typeExpr{kind: kindMap, items: b.expr(name+"Value", additionalProperties, "json")}
+	case "string", "integer", "number", "boolean":
+		return &typeExpr{kind: schemaType, format: cast.ToString(schema["format"])}
+	}
+	return &typeExpr{kind: kindAny}
+}
+
+// structExpr declares an object, the members of its allOf being embedded.
+func (b *typeBuilder) structExpr(name string, schema map[string]any, tag string) *typeExpr {
+	d := &declaration{
+		name:        name,
+		description: cast.ToString(schema["description"]),
+		tag:         tag,
+		fields:      make([]*field, 0),
+	}
+	b.add(d)
+	required := make(map[string]bool)
+	for _, property := range cast.ToStringSlice(schema["required"]) {
+		required[property] = true
+	}
+	allOf, _ := schema["allOf"].([]any)
+	for i, item := range allOf {
+		member, _ := item.(map[string]any)
+		memberName := d.name + "Part" + strconv.Itoa(i+1)
+		if title := cast.ToString(member["title"]); title != "" {
+			memberName = pascalName(title)
+		}
+		expr := b.expr(memberName, member, tag)
+		if expr.kind == kindRef && b.byName[expr.ref].isStruct() {
+			d.embeds = append(d.embeds, expr.ref)
+			continue
+		}
+		// a member without properties only adds constraints, e.g. required
+		for _, property := range cast.ToStringSlice(member["required"]) {
+			required[property] = true
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	for _, property := range mapsutils.SortedKeys(properties) {
+		propertySchema, _ := properties[property].(map[string]any)
+		d.fields = append(d.fields, &field{
+			name:        property,
+			typ:         b.expr(d.name+pascalName(property), propertySchema, "json"),
+			required:    required[property],
+			description: cast.ToString(propertySchema["description"]),
+		})
+	}
+	return &typeExpr{kind: kindRef, ref: d.name}
+}
+
+func (b *typeBuilder) enumExpr(name string, schema map[string]any, enum []any, types []string,
+	nullable bool,
+) *typeExpr {
+	d := &declaration{
+		name:        name,
+		description: cast.ToString(schema["description"]),
+		enum:        make([]any, 0, len(enum)),
+	}
+	if len(types) > 0 {
+		d.enumType = types[0]
+	}
+	for _, value := range enum {
+		if value == nil {
+			nullable = true
+			continue
+		}
+		if d.enumType == "" {
+			d.enumType = valueType(value)
+		}
+		d.enum = append(d.enum, value)
+	}
+	b.add(d)
+	return &typeExpr{kind: kindRef, ref: d.name, nullable: nullable}
+}
+
+func (b *typeBuilder) unionExpr(name string, schema map[string]any, variants []map[string]any,
+	tag string,
+) *typeExpr {
+	d := &declaration{
+		name:        name,
+		description: cast.ToString(schema["description"]),
+		variants:    make([]*typeExpr, 0, len(variants)),
+	}
+	b.add(d)
+	for i, variant := range variants {
+		variantName := d.name + "Variant" + strconv.Itoa(i+1)
+		if title := cast.ToString(variant["title"]); title != "" {
+			variantName = pascalName(title)
+		}
+		d.variants = append(d.variants, b.expr(variantName, variant, tag))
+	}
+	return &typeExpr{kind: kindRef, ref: d.name}
+}
+
+func (d *declaration) isStruct() bool {
+	return d != nil && d.enum == nil && d.variants == nil && d.alias == nil
+}
+
+// schemaTypes returns the types of schema but null, and whether it is
+// nullable, in both dialects.
+func schemaTypes(schema map[string]any) ([]string, bool) {
+	nullable := cast.ToBool(schema["nullable"])
+	types := make([]string, 0)
+	for _, schemaType := range cast.ToStringSlice(schema["type"]) {
+		if schemaType == "null" {
+			nullable = true
+			continue
+		}
+		types = append(types, schemaType)
+	}
+	return types, nullable
+}
+
+func valueType(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case int, int32, int64:
+		return "integer"
+	}
+	return "string"
+}
+
+// declareOperationTypes declares the query parameters, the request body and
+// the response bodies of the operation of a document, decoded from its JSON,
+// and returns the number of types declared.
+func declareOperationTypes(b *typeBuilder, document map[string]any) int {
+	paths, _ := document["paths"].(map[string]any)
+	for _, path := range mapsutils.SortedKeys(paths) {
+		pathItem, _ := paths[path].(map[string]any)
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			name := operationTypeName(method, path)
+			declareParameters(b, name+"Params", operation)
+			if requestBody, ok := operation["requestBody"].(map[string]any); ok {
+				if schema := jsonSchema(requestBody["content"]); schema != nil {
+					b.declareRoot(name+"Request", schema)
+				}
+			}
+			responses, _ := operation["responses"].(map[string]any)
+			for _, statusCode := range mapsutils.SortedKeys(responses) {
+				response, _ := responses[statusCode].(map[string]any)
+				schema := jsonSchema(response["content"])
+				if schema == nil {
+					continue
+				}
+				// the success response is the one types are usually wanted for
+				responseName := name + "Response"
+				if statusCode != "200" {
+					responseName += pascalName(statusCode)
+				}
+				b.declareRoot(responseName, schema)
+			}
+		}
+	}
+	return len(b.declarations)
+}
+
+// declareParameters declares the query parameters of an operation as the
+// fields of a struct.
+func declareParameters(b *typeBuilder, name string, operation map[string]any) {
+	properties := make(map[string]any)
+	required := make([]any, 0)
+	parameters, _ := operation["parameters"].([]any)
+	for _, item := range parameters {
+		parameter, _ := item.(map[string]any)
+		if cast.ToString(parameter["in"]) != "query" {
+			continue
+		}
+		parameterName := cast.ToString(parameter["name"])
+		schema, _ := parameter["schema"].(map[string]any)
+		// the description of the parameter documents its field
+		if description := cast.ToString(parameter["description"]); description != "" {
+			schema, _ = openapiutils.CopySchema(schema).(map[string]any)
+			if schema == nil {
+				schema = map[string]any{}
+			}
+			schema["description"] = description
+		}
+		properties[parameterName] = schema
+		if cast.ToBool(parameter["required"]) {
+			required = append(required, parameterName)
+		}
+	}
+	if len(properties) == 0 {
+		return
+	}
+	b.declareRootWithTag(name, map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, "url")
+}
+
+// jsonSchema returns the schema of the JSON media type of a content object,
+// nil when there is none.
+func jsonSchema(content any) map[string]any {
+	mediaTypes, _ := content.(map[string]any)
+	mediaType, _ := mediaTypes["application/json"].(map[string]any)
+	schema, _ := mediaType["schema"].(map[string]any)
+	return schema
+}
+
+// typeSchema returns the object schema of a type of the types collection.
+func typeSchema(t *entity.Type) map[string]any {
+	properties, _ := openapiutils.CopySchema(t.Properties).(map[string]any)
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// operationTypeName names the types of an operation after its method and
+// path, skipping the version, e.g. GET /v1/ads/{id}/images is GetAdsByIDImages.
+func operationTypeName(method, path string) string {
+	name := pascalName(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || versionSegmentRegexp.MatchString(segment) {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name += "By" + pascalName(strings.Trim(segment, "{}"))
+			continue
+		}
+		name += pascalName(segment)
+	}
+	return name
+}
+
+// pascalName turns a property or path segment into an exported Go name, e.g.
+// ad_id and adId are AdID.
+func pascalName(s string) string {
+	name := pascalWords(s)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+// pascalWords joins the words of s, each capitalized.
+func pascalWords(s string) string {
+	var sb strings.Builder
+	for _, part := range nonAlphanumRegexp.Split(s, -1) {
+		for _, word := range splitCamelCase(part) {
+			if goInitialisms[strings.ToUpper(word)] {
+				sb.WriteString(strings.ToUpper(word))
+				continue
+			}
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			sb.WriteString(string(runes))
+		}
+	}
+	return sb.String()
+}
+
+// splitCamelCase splits a word before each upper case letter following a
+// lower case one.
+func splitCamelCase(s string) []string {
+	words := make([]string, 0)
+	start := 0
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// renderGo writes the declarations as a Go file of package pkg.
+func renderGo(pkg string, declarations []*declaration) (string, error) {
+	r := &goRenderer{byName: make(map[string]*declaration), imports: make(map[string]bool)}
+	for _, d := range declarations {
+		r.byName[d.name] = d
+	}
+	var body strings.Builder
+	for _, d := range declarations {
+		body.WriteString("\n")
+		r.writeDeclaration(&body, d)
+	}
+	var sb strings.Builder
+	sb.WriteString(generatedHeader)
+	sb.WriteString("\npackage " + pkg + "\n")
+	if len(r.imports) > 0 {
+		sb.WriteString("\nimport (\n")
+		for _, path := range mapsutils.SortedKeys(r.imports) {
+			sb.WriteString(strconv.Quote(path) + "\n")
+		}
+		sb.WriteString(")\n")
+	}
+	sb.WriteString(body.String())
+	code, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("format generated go code: %w", err)
+	}
+	return string(code), nil
+}
+
+type goRenderer struct {
+	byName  map[string]*declaration
+	imports map[string]bool
+}
+
+func (r *goRenderer) writeDeclaration(sb *strings.Builder, d *declaration) {
+	switch {
+	case d.enum != nil:
+		writeGoComment(sb, d.description)
+		baseType := r.goType(&typeExpr{kind: d.enumType}, false)
+		sb.WriteString("type " + d.name + " " + baseType + "\n\nconst (\n")
+		names := make(map[string]bool)
+		for _, value := range d.enum {
+			// the constants are prefixed by the type, e.g. StatusActive
+			suffix := pascalWords(cast.ToString(value))
+			if suffix == "" {
+				suffix = "Empty"
+			}
+			constName := d.name + suffix
+			for i := 2; names[constName]; i++ {
+				constName = d.name + suffix + strconv.Itoa(i)
+			}
+			names[constName] = true
+			sb.WriteString(constName + " " + d.name + " = " + goLiteral(value, d.enumType) + "\n")
+		}
+		sb.WriteString(")\n")
+	case d.variants != nil:
+		variants := make([]string, 0, len(d.variants))
+		for _, variant := range d.variants {
+			variants = append(variants, r.goType(variant, false))
+		}
+		description := d.name + " is one of " + strings.Join(variants, ", ") +
+			", decode it into the variant matching its content."
+		if d.description != "" {
+			description = d.description + "\n\n" + description
+		}
+		writeGoComment(sb, description)
+		r.imports["encoding/json"] = true
+		sb.WriteString("type " + d.name + " = json.RawMessage\n")
+	case d.alias != nil:
+		writeGoComment(sb, d.description)
+		sb.WriteString("type " + d.name + " " + r.goType(d.alias, false) + "\n")
+	default:
+		writeGoComment(sb, d.description)
+		sb.WriteString("type " + d.name + " struct {\n")
+		for _, embed := range d.embeds {
+			sb.WriteString(embed + "\n")
+		}
+		names := make(map[string]bool)
+		for _, f := range d.fields {
+			fieldName := pascalName(f.name)
+			for i := 2; names[fieldName]; i++ {
+				fieldName = pascalName(f.name) + strconv.Itoa(i)
+			}
+			names[fieldName] = true
+			writeGoComment(sb, f.description)
+			tag := f.name
+			if !f.required {
+				tag += ",omitempty"
+			}
+			sb.WriteString(fieldName + " " + r.goType(f.typ, !f.required) + " `" + d.tag + ":" +
+				strconv.Quote(tag) + "`\n")
+		}
+		sb.WriteString("}\n")
+	}
+}
+
+// goType returns the Go type of expr, a pointer when it is nullable or
+// optional and its zero value is not already nil.
+func (r *goRenderer) goType(expr *typeExpr, optional bool) string {
+	var goType string
+	hasNil := false
+	switch expr.kind {
+	case "string":
+		goType = "string"
+		if expr.format == "date-time" {
+			r.imports["time"] = true
+			goType = "time.Time"
+		}
+	case "integer":
+		goType = "int64"
+		if expr.format == "int32" {
+			goType = "int32"
+		}
+	case "number":
+		goType = "float64"
+		if expr.format == "float" {
+			goType = "float32"
+		}
+	case "boolean":
+		goType = "bool"
+	case kindArray:
+		goType = "[]" + r.goType(expr.items, false)
+		hasNil = true
+	case kindMap:
+		goType = "map[string]" + r.goType(expr.items, false)
+		hasNil = true
+	case kindRef:
+		goType = expr.ref
+		if d := r.byName[expr.ref]; d != nil {
+			hasNil = d.variants != nil || (d.alias != nil && d.alias.kind != kindRef)
+		}
+	default:
+		goType = "any"
+		hasNil = true
+	}
+	if (expr.nullable || optional) && !hasNil {
+		return "*" + goType
+	}
+	return goType
+}
+
+func goLiteral(value any, enumType string) string {
+	switch enumType {
+	case "string":
+		return strconv.Quote(cast.ToString(value))
+	case "boolean":
+		return strconv.FormatBool(cast.ToBool(value))
+	}
+	return cast.ToString(value)
+}
+
+func writeGoComment(sb *strings.Builder, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		sb.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// renderTypeScript writes the declarations as TypeScript interfaces and type
+// aliases.
+func renderTypeScript(declarations []*declaration) string {
+	var sb strings.Builder
+	sb.WriteString(generatedHeader)
+	for _, d := range declarations {
+		sb.WriteString("\n")
+		writeTSComment(&sb, "", d.description)
+		switch {
+		case d.enum != nil:
+			values := make([]string, 0, len(d.enum))
+			for _, value := range d.enum {
+				values = append(values, tsLiteral(value, d.enumType))
+			}
+			sb.WriteString("export type " + d.name + " = " + strings.Join(values, " | ") + ";\n")
+		case d.variants != nil:
+			variants := make([]string, 0, len(d.variants))
+			for _, variant := range d.variants {
+				variants = append(variants, tsType(variant))
+			}
+			sb.WriteString("export type " + d.name + " = " + strings.Join(variants, " | ") + ";\n")
+		case d.alias != nil:
+			sb.WriteString("export type " + d.name + " = " + tsType(d.alias) + ";\n")
+		default:
+			sb.WriteString("export interface " + d.name)
+			if len(d.embeds) > 0 {
+				sb.WriteString(" extends " + strings.Join(d.embeds, ", "))
+			}
+			sb.WriteString(" {\n")
+			for _, f := range d.fields {
+				writeTSComment(&sb, "  ", f.description)
+				name := f.name
+				if !tsIdentifierRegexp.MatchString(name) {
+					name = strconv.Quote(name)
+				}
+				if !f.required {
+					name += "?"
+				}
+				sb.WriteString("  " + name + ": " + tsType(f.typ) + ";\n")
+			}
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+func tsType(expr *typeExpr) string {
+	var t string
+	switch expr.kind {
+	case "string":
+		t = "string"
+	case "integer", "number":
+		t = "number"
+	case "boolean":
+		t = "boolean"
+	case kindArray:
+		t = tsType(expr.items)
+		if strings.Contains(t, " ") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	case kindMap:
+		t = "Record<string, " + tsType(expr.items) + ">"
+	case kindRef:
+		t = expr.ref
+	default:
+		t = "unknown"
+	}
+	if expr.nullable {
+		t += " | null"
+	}
+	return t
+}
+
+func tsLiteral(value any, enumType string) string {
+	if enumType == "string" {
+		return strconv.Quote(cast.ToString(value))
+	}
+	return cast.ToString(value)
+}
+
+func writeTSComment(sb *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	if len(lines) == 1 {
+		sb.WriteString(indent + "/** " + strings.ReplaceAll(lines[0], "*/", "*\\/") + " */\n")
+		return
+	}
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(indent+" * "+strings.ReplaceAll(line, "*/", "*\\/"), " ") + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+}
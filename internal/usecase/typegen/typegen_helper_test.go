@@ -0,0 +1,189 @@
+package typegen
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const adDocument = `{
+  "paths": {
+    "/v1/ads/{id}": {
+      "get": {
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+          {"name": "fields", "in": "query", "description": "fields to return", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "required": ["ad_id", "status"],
+                  "properties": {
+                    "ad_id": {"type": "integer", "description": "id of the ad"},
+                    "status": {"type": "string", "enum": ["active", "hidden"]},
+                    "price": {"type": "number", "nullable": true},
+                    "created_at": {"type": "string", "format": "date-time"},
+                    "image_urls": {"type": "array", "items": {"type": "string"}},
+                    "params": {"type": "object", "properties": {}},
+                    "seller": {
+                      "allOf": [
+                        {"title": "account", "type": "object", "properties": {"account_id": {"type": "integer"}}},
+                        {"required": ["name"]}
+                      ],
+                      "properties": {"name": {"type": "string"}}
+                    },
+                    "images": {
+                      "type": "array",
+                      "items": {"type": "object", "properties": {"url": {"type": "string"}}}
+                    },
+                    "contact": {
+                      "oneOf": [
+                        {"type": "object", "properties": {"phone": {"type": "string"}}},
+                        {"type": "string"}
+                      ]
+                    },
+                    "region": {"oneOf": [{"type": "string"}, {"type": "null"}]}
+                  }
+                }
+              }
+            }
+          },
+          "404": {"description": "Not Found", "content": {"application/json": {}}}
+        }
+      }
+    }
+  }
+}`
+
+func TestRenderOperationTypes(t *testing.T) {
+	t.Parallel()
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(adDocument), &document))
+	b := newTypeBuilder()
+	require.Equal(t, 8, declareOperationTypes(b, document))
+	goCode, err := renderGo("types", b.declarations)
+	require.NoError(t, err)
+	tests := []struct {
+		name   string
+		code   string
+		golden string
+	}{
+		{
+			name:   "Test RenderOperationTypes - go",
+			code:   goCode,
+			golden: "get_ad.go",
+		},
+		{
+			name:   "Test RenderOperationTypes - typescript",
+			code:   renderTypeScript(b.declarations),
+			golden: "get_ad.ts",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(tt.code), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), tt.code)
+		})
+	}
+}
+
+func TestDeclareRoot(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		schema map[string]any
+		wantGo string
+		wantTS string
+	}{
+		{
+			name:   "Test DeclareRoot - array of objects",
+			schema: map[string]any{"type": "array", "items": map[string]any{"type": "object", "properties": map[string]any{"id": map[string]any{"type": "integer"}}}},
+			wantGo: "type AdsItem struct {\n\tID *int64 `json:\"id,omitempty\"`\n}\n\ntype Ads []AdsItem\n",
+			wantTS: "export interface AdsItem {\n  id?: number;\n}\n\nexport type Ads = AdsItem[];\n",
+		},
+		{
+			name:   "Test DeclareRoot - 3.1 type array",
+			schema: map[string]any{"type": []any{"string", "integer", "null"}},
+			wantGo: "// Ads is one of string, int64, decode it into the variant matching its content.\ntype Ads = json.RawMessage\n",
+			wantTS: "export type Ads = string | number;\n",
+		},
+		{
+			name:   "Test DeclareRoot - integer enum and free-form map",
+			schema: map[string]any{"type": "object", "properties": map[string]any{"type": map[string]any{"enum": []any{1.0, 2.0, nil}}, "extra": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}}, "ref": map[string]any{"$ref": "#/components/schemas/Ad"}}},
+			wantGo: "type Ads struct {\n\tExtra map[string]string `json:\"extra,omitempty\"`\n\tRef   any               `json:\"ref,omitempty\"`\n\tType  *AdsType          `json:\"type,omitempty\"`\n}\n\ntype AdsType int64\n\nconst (\n\tAdsType1 AdsType = 1\n\tAdsType2 AdsType = 2\n)\n",
+			wantTS: "export interface Ads {\n  extra?: Record<string, string>;\n  ref?: unknown;\n  type?: AdsType | null;\n}\n\nexport type AdsType = 1 | 2;\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := newTypeBuilder()
+			b.declareRoot("ads", tt.schema)
+			goCode, err := renderGo("types", b.declarations)
+			require.NoError(t, err)
+			require.Contains(t, goCode, tt.wantGo)
+			require.Equal(t, generatedHeader+"\n"+tt.wantTS, renderTypeScript(b.declarations))
+		})
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	t.Parallel()
+	b := newTypeBuilder()
+	b.declareRoot("ad_params", typeSchema(&entity.Type{
+		Name:       "ad_params",
+		Properties: map[string]any{"size": map[string]any{"type": "string"}},
+	}))
+	require.Equal(t, generatedHeader+"\nexport interface AdParams {\n  size?: string;\n}\n",
+		renderTypeScript(b.declarations))
+}
+
+func TestOperationTypeName(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "GetAdsByIDImages", operationTypeName("get", "/v1/ads/{id}/images"))
+	require.Equal(t, "PostPrivateBankTransferContractHistory",
+		operationTypeName("POST", "/v2/private/bank_transfer/contract-history"))
+	require.Equal(t, "Get", operationTypeName("get", "/"))
+}
+
+func TestPascalName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Test PascalName - snake case", in: "ad_id", want: "AdID"},
+		{name: "Test PascalName - camel case", in: "imageUrl", want: "ImageURL"},
+		{name: "Test PascalName - kebab case", in: "x-request-id", want: "XRequestID"},
+		{name: "Test PascalName - leading digit", in: "3d_view", want: "X3dView"},
+		{name: "Test PascalName - empty", in: "$", want: "X"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, pascalName(tt.in))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package watchstructure
+
+import (
+	"context"
+	"time"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+	"github.com/carousell/ct-go/pkg/workerpool"
+	"github.com/ct-logic-api-document/config"
+	"github.com/ct-logic-api-document/internal/constants"
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/ct-logic-api-document/internal/repository/mongodb"
+	buildstructure "github.com/ct-logic-api-document/internal/usecase/build_structure"
+	mongodbutils "github.com/ct-logic-api-document/utils/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	streamSampleRequests  = "watch_structure:" + constants.SampleRequestsCollection
+	streamSampleResponses = "watch_structure:" + constants.SampleResponsesCollection
+	minFlushInterval      = 100 * time.Millisecond
+)
+
+type IWatchStructureUC interface {
+	// Watch merges new samples into the structures as they are inserted, until ctx is done.
+	Watch(ctx context.Context) error
+}
+
+type watchStructureUC struct {
+	conf             *config.Config
+	storage          mongodb.MongoStorage
+	buildStructureUC buildstructure.IBuildStructureUC
+}
+
+func NewWatchStructureUC(
+	conf *config.Config,
+	storage mongodb.MongoStorage,
+	buildStructureUC buildstructure.IBuildStructureUC,
+) IWatchStructureUC {
+	return &watchStructureUC{
+		conf:             conf,
+		storage:          storage,
+		buildStructureUC: buildStructureUC,
+	}
+}
+
+func (uc *watchStructureUC) Watch(ctx context.Context) error {
+	coverage := newStreamCoverage()
+	requestResumeToken, err := uc.loadResumeToken(ctx, streamSampleRequests, coverage)
+	if err != nil {
+		return err
+	}
+	responseResumeToken, err := uc.loadResumeToken(ctx, streamSampleResponses, coverage)
+	if err != nil {
+		return err
+	}
+	batcher := newSampleBatcher(uc.conf.Watch.Debounce, uc.conf.Watch.MaxDelay)
+
+	// stop every goroutine as soon as one of them returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pool := workerpool.NewE(3)
+	defer pool.Close()
+	pool.Run(func() error {
+		defer cancel()
+		return uc.storage.WatchSampleRequests(ctx, requestResumeToken,
+			func(event *mongodbutils.ChangeEvent[*entity.SampleRequest]) error {
+				coverage.observe(streamSampleRequests, event.Resumed, time.Now())
+				batcher.addSampleRequest(streamSampleRequests, event.ResumeToken, event.FullDocument, time.Now())
+				return nil
+			})
+	})
+	pool.Run(func() error {
+		defer cancel()
+		return uc.storage.WatchSampleResponses(ctx, responseResumeToken,
+			func(event *mongodbutils.ChangeEvent[*entity.SampleResponse]) error {
+				coverage.observe(streamSampleResponses, event.Resumed, time.Now())
+				batcher.addSampleResponse(streamSampleResponses, event.ResumeToken, event.FullDocument, time.Now())
+				return nil
+			})
+	})
+	pool.Run(func() error {
+		defer cancel()
+		return uc.flushLoop(ctx, batcher, coverage)
+	})
+	return pool.Wait()
+}
+
+func (uc *watchStructureUC) loadResumeToken(ctx context.Context, stream string, coverage *streamCoverage) (bson.Raw, error) {
+	checkpoint, err := uc.storage.GetWatchCheckpoint(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		logctx.Infof(ctx, "no checkpoint found for %s, watching from now", stream)
+		return nil, nil
+	}
+	coverage.resume(stream, checkpoint.WatchedSince)
+	return checkpoint.ResumeToken, nil
+}
+
+func (uc *watchStructureUC) flushLoop(ctx context.Context, batcher *sampleBatcher, coverage *streamCoverage) error {
+	interval := uc.conf.Watch.Debounce / 2
+	if interval < minFlushInterval {
+		interval = minFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// pending samples are merged again after a restart since their
+			// resume tokens have not been persisted
+			return nil
+		case now := <-ticker.C:
+			for _, batch := range batcher.due(now) {
+				uc.flush(ctx, batcher, coverage, batch)
+			}
+		}
+	}
+}
+
+func (uc *watchStructureUC) flush(ctx context.Context, batcher *sampleBatcher, coverage *streamCoverage, batch *sampleBatch) {
+	api, err := uc.storage.GetApiById(ctx, batch.apiId)
+	if err != nil {
+		logctx.Errorw(ctx, "failed to get api", "api_id", batch.apiId, "err", err)
+		batcher.retry(batch, time.Now())
+		return
+	}
+	if api == nil {
+		logctx.Infow(ctx, "api not found, samples skipped", "api_id", batch.apiId)
+	} else {
+		// the watermark skips the samples the streams may have missed otherwise,
+		// the cronjob merges them again instead
+		advanceWatermark := coverage.covers(api.LatestBuildStructure, streamSampleRequests, streamSampleResponses)
+		err := uc.buildStructureUC.MergeSamples(ctx, api, batch.sampleRequests, batch.sampleResponses, advanceWatermark)
+		if err != nil {
+			logctx.Errorw(ctx, "failed to merge samples", "api_id", batch.apiId, "err", err)
+			batcher.retry(batch, time.Now())
+			return
+		}
+		logctx.Infow(ctx, "samples merged", "api_id", batch.apiId,
+			"sample_requests", len(batch.sampleRequests), "sample_responses", len(batch.sampleResponses))
+	}
+	for stream, resumeToken := range batcher.done(batch) {
+		if err := uc.storage.SaveWatchCheckpoint(ctx, stream, resumeToken, coverage.watchedSince(stream)); err != nil {
+			logctx.Errorw(ctx, "failed to save watch checkpoint", "stream", stream, "err", err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package watchstructure
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sampleBatch holds the samples of one api received during its debounce window.
+type sampleBatch struct {
+	apiId           primitive.ObjectID
+	sampleRequests  []*entity.SampleRequest
+	sampleResponses []*entity.SampleResponse
+	seqs            []uint64
+	firstSeenAt     time.Time
+	lastSeenAt      time.Time
+}
+
+type checkpointEntry struct {
+	resumeToken bson.Raw
+	done        bool
+}
+
+// sampleBatcher debounces change events per api and tracks, per stream, the
+// latest resume token which is safe to persist: the one of the newest event
+// such that this event and every event before it have been merged.
+type sampleBatcher struct {
+	mu       sync.Mutex
+	debounce time.Duration
+	maxDelay time.Duration
+	seq      uint64
+	batches  map[primitive.ObjectID]*sampleBatch
+	// entries keeps the events of each stream in arrival order
+	entries map[string][]*checkpointEntry
+	bySeq   map[uint64]*checkpointEntry
+}
+
+func newSampleBatcher(debounce, maxDelay time.Duration) *sampleBatcher {
+	return &sampleBatcher{
+		debounce: debounce,
+		maxDelay: maxDelay,
+		batches:  make(map[primitive.ObjectID]*sampleBatch),
+		entries:  make(map[string][]*checkpointEntry),
+		bySeq:    make(map[uint64]*checkpointEntry),
+	}
+}
+
+func (b *sampleBatcher) addSampleRequest(stream string, resumeToken bson.Raw, sampleRequest *entity.SampleRequest, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if batch := b.add(stream, resumeToken, sampleRequest.ApiId, now); batch != nil {
+		batch.sampleRequests = append(batch.sampleRequests, sampleRequest)
+	}
+}
+
+func (b *sampleBatcher) addSampleResponse(stream string, resumeToken bson.Raw, sampleResponse *entity.SampleResponse, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if batch := b.add(stream, resumeToken, sampleResponse.ApiId, now); batch != nil {
+		batch.sampleResponses = append(batch.sampleResponses, sampleResponse)
+	}
+}
+
+// add records the event and returns the batch of apiId, or nil when the event
+// has no api to merge into and is done straight away.
+func (b *sampleBatcher) add(stream string, resumeToken bson.Raw, apiId primitive.ObjectID, now time.Time) *sampleBatch {
+	b.seq++
+	entry := &checkpointEntry{resumeToken: resumeToken}
+	b.entries[stream] = append(b.entries[stream], entry)
+	if apiId.IsZero() {
+		entry.done = true
+		return nil
+	}
+	b.bySeq[b.seq] = entry
+	batch, ok := b.batches[apiId]
+	if !ok {
+		batch = &sampleBatch{apiId: apiId, firstSeenAt: now}
+		b.batches[apiId] = batch
+	}
+	batch.seqs = append(batch.seqs, b.seq)
+	batch.lastSeenAt = now
+	return batch
+}
+
+// due removes and returns the batches which received no event for the debounce
+// window, or which have been waiting longer than maxDelay.
+func (b *sampleBatcher) due(now time.Time) []*sampleBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	batches := make([]*sampleBatch, 0)
+	for apiId, batch := range b.batches {
+		if now.Sub(batch.lastSeenAt) >= b.debounce || now.Sub(batch.firstSeenAt) >= b.maxDelay {
+			batches = append(batches, batch)
+			delete(b.batches, apiId)
+		}
+	}
+	return batches
+}
+
+// done marks the events of a merged batch and returns, per stream, the resume
+// token to persist when it moved forward.
+func (b *sampleBatcher) done(batch *sampleBatch) map[string]bson.Raw {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, seq := range batch.seqs {
+		if entry, ok := b.bySeq[seq]; ok {
+			entry.done = true
+			delete(b.bySeq, seq)
+		}
+	}
+	resumeTokens := make(map[string]bson.Raw)
+	for stream, entries := range b.entries {
+		i := 0
+		for i < len(entries) && entries[i].done {
+			resumeTokens[stream] = entries[i].resumeToken
+			i++
+		}
+		b.entries[stream] = entries[i:]
+	}
+	return resumeTokens
+}
+
+// retry puts the samples of a batch which failed to merge back, they wait for
+// another debounce window together with the events received meanwhile.
+func (b *sampleBatcher) retry(batch *sampleBatch, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	batch.firstSeenAt = now
+	batch.lastSeenAt = now
+	pending, ok := b.batches[batch.apiId]
+	if !ok {
+		b.batches[batch.apiId] = batch
+		return
+	}
+	pending.sampleRequests = append(batch.sampleRequests, pending.sampleRequests...)
+	pending.sampleResponses = append(batch.sampleResponses, pending.sampleResponses...)
+	pending.seqs = append(batch.seqs, pending.seqs...)
+	pending.firstSeenAt = now
+}
+
+// streamCoverage tracks, per stream, the time since which every insert has
+// been received. It is carried over from the checkpoint the stream resumes
+// from, and restarts at the first event of a stream which started from now.
+type streamCoverage struct {
+	mu      sync.Mutex
+	since   map[string]*time.Time
+	started map[string]bool
+}
+
+func newStreamCoverage() *streamCoverage {
+	return &streamCoverage{
+		since:   make(map[string]*time.Time),
+		started: make(map[string]bool),
+	}
+}
+
+// resume sets the coverage of a stream resuming from a checkpoint.
+func (c *streamCoverage) resume(stream string, watchedSince *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.since[stream] = watchedSince
+}
+
+// observe records an event of stream received at now, resumed reports whether
+// the stream resumed from its checkpoint.
+func (c *streamCoverage) observe(stream string, resumed bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started[stream] {
+		return
+	}
+	c.started[stream] = true
+	if !resumed {
+		c.since[stream] = &now
+	}
+}
+
+func (c *streamCoverage) watchedSince(stream string) *time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.since[stream]
+}
+
+// covers reports whether every stream has received every insert since
+// watermark, none is covered when watermark is nil.
+func (c *streamCoverage) covers(watermark *time.Time, streams ...string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if watermark == nil {
+		return false
+	}
+	for _, stream := range streams {
+		since := c.since[stream]
+		if since == nil || since.After(*watermark) {
+			return false
+		}
+	}
+	return true
+}
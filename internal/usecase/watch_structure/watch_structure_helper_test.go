@@ -0,0 +1,179 @@
+package watchstructure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ct-logic-api-document/internal/entity"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSampleBatcher_Due(t *testing.T) {
+	t.Parallel()
+	apiId := primitive.NewObjectID()
+	start := time.Now()
+	tests := []struct {
+		name      string
+		eventsAt  []time.Duration
+		dueAt     time.Duration
+		wantCount int
+	}{
+		{
+			name:      "Test Due - within debounce window",
+			eventsAt:  []time.Duration{0, time.Second},
+			dueAt:     2 * time.Second,
+			wantCount: 0,
+		},
+		{
+			name:      "Test Due - debounce window elapsed",
+			eventsAt:  []time.Duration{0, time.Second},
+			dueAt:     4 * time.Second,
+			wantCount: 1,
+		},
+		{
+			name:      "Test Due - max delay elapsed",
+			eventsAt:  []time.Duration{0, 2 * time.Second, 4 * time.Second, 6 * time.Second, 8 * time.Second, 10 * time.Second},
+			dueAt:     11 * time.Second,
+			wantCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			batcher := newSampleBatcher(3*time.Second, 10*time.Second)
+			for _, at := range tt.eventsAt {
+				batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 1), &entity.SampleRequest{ApiId: apiId}, start.Add(at))
+			}
+			batches := batcher.due(start.Add(tt.dueAt))
+			require.Len(t, batches, tt.wantCount)
+			if tt.wantCount > 0 {
+				require.Len(t, batches[0].sampleRequests, len(tt.eventsAt))
+			}
+		})
+	}
+}
+
+func TestSampleBatcher_Done(t *testing.T) {
+	t.Parallel()
+	apiId1 := primitive.NewObjectID()
+	apiId2 := primitive.NewObjectID()
+	start := time.Now()
+	batcher := newSampleBatcher(time.Second, time.Minute)
+	batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 1), &entity.SampleRequest{ApiId: apiId1}, start)
+	batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 2), &entity.SampleRequest{ApiId: apiId2}, start.Add(2*time.Second))
+	batcher.addSampleResponse(streamSampleResponses, resumeToken(t, 3), &entity.SampleResponse{ApiId: apiId1}, start)
+	batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 4), &entity.SampleRequest{ApiId: apiId1}, start)
+
+	// only api 1 is due, the token of api 2 holds the sample requests stream back
+	batches := batcher.due(start.Add(1500 * time.Millisecond))
+	require.Len(t, batches, 1)
+	require.Equal(t, apiId1, batches[0].apiId)
+	require.Len(t, batches[0].sampleRequests, 2)
+	require.Len(t, batches[0].sampleResponses, 1)
+	resumeTokens := batcher.done(batches[0])
+	require.Equal(t, map[string]bson.Raw{
+		streamSampleRequests:  resumeToken(t, 1),
+		streamSampleResponses: resumeToken(t, 3),
+	}, resumeTokens)
+
+	batches = batcher.due(start.Add(5 * time.Second))
+	require.Len(t, batches, 1)
+	resumeTokens = batcher.done(batches[0])
+	require.Equal(t, map[string]bson.Raw{
+		streamSampleRequests: resumeToken(t, 4),
+	}, resumeTokens)
+}
+
+func TestSampleBatcher_Retry(t *testing.T) {
+	t.Parallel()
+	apiId := primitive.NewObjectID()
+	start := time.Now()
+	batcher := newSampleBatcher(time.Second, time.Minute)
+	batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 1), &entity.SampleRequest{ApiId: apiId}, start)
+
+	batches := batcher.due(start.Add(2 * time.Second))
+	require.Len(t, batches, 1)
+	batcher.addSampleRequest(streamSampleRequests, resumeToken(t, 2), &entity.SampleRequest{ApiId: apiId}, start.Add(3*time.Second))
+	batcher.retry(batches[0], start.Add(3*time.Second))
+
+	// the failed samples wait for another window with the new ones
+	require.Empty(t, batcher.due(start.Add(3500*time.Millisecond)))
+	batches = batcher.due(start.Add(5 * time.Second))
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0].sampleRequests, 2)
+	require.Equal(t, map[string]bson.Raw{
+		streamSampleRequests: resumeToken(t, 2),
+	}, batcher.done(batches[0]))
+}
+
+func resumeToken(t *testing.T, n int) bson.Raw {
+	token, err := bson.Marshal(bson.M{"_data": n})
+	require.NoError(t, err)
+	return token
+}
+
+func TestStreamCoverage_Covers(t *testing.T) {
+	t.Parallel()
+	checkpointAt := time.Date(2024, 12, 2, 3, 0, 0, 0, time.UTC)
+	startedAt := checkpointAt.Add(time.Hour)
+	tests := []struct {
+		name         string
+		watchedSince *time.Time
+		resumed      bool
+		watermark    *time.Time
+		want         bool
+	}{
+		{
+			name:         "Test Covers - resumed from a checkpoint before the watermark",
+			watchedSince: &checkpointAt,
+			resumed:      true,
+			watermark:    &startedAt,
+			want:         true,
+		},
+		{
+			name:         "Test Covers - resumed from a checkpoint after the watermark",
+			watchedSince: &startedAt,
+			resumed:      true,
+			watermark:    &checkpointAt,
+			want:         false,
+		},
+		{
+			name:      "Test Covers - no checkpoint",
+			resumed:   false,
+			watermark: &checkpointAt,
+			want:      false,
+		},
+		{
+			name:         "Test Covers - resume token lost",
+			watchedSince: &checkpointAt,
+			resumed:      false,
+			watermark:    &checkpointAt,
+			want:         false,
+		},
+		{
+			name:         "Test Covers - never built",
+			watchedSince: &checkpointAt,
+			resumed:      true,
+			want:         false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			coverage := newStreamCoverage()
+			for _, stream := range []string{streamSampleRequests, streamSampleResponses} {
+				if tt.watchedSince != nil {
+					coverage.resume(stream, tt.watchedSince)
+				}
+				coverage.observe(stream, tt.resumed, startedAt)
+				// only the first event of a stream restarts its coverage
+				coverage.observe(stream, false, startedAt.Add(time.Hour))
+			}
+			require.Equal(t, tt.want, coverage.covers(tt.watermark, streamSampleRequests, streamSampleResponses))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"net/http"
+)
+
+const HeaderAPIKey = "X-Api-Key"
+
+// APIKeyAuthenticator authenticates the requests carrying a static key in
+// the X-Api-Key header.
+type APIKeyAuthenticator struct {
+	// keys are indexed by their digest so a lookup does not compare secrets
+	keys map[[sha256.Size]byte]*APIKey
+}
+
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+
+func NewAPIKeyAuthenticator(apiKeys []*APIKey) *APIKeyAuthenticator {
+	keys := make(map[[sha256.Size]byte]*APIKey, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		keys[sha256.Sum256([]byte(apiKey.Key))] = apiKey
+	}
+	return &APIKeyAuthenticator{
+		keys: keys,
+	}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(HeaderAPIKey)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	apiKey, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		Subject: apiKey.Name,
+		Method:  MethodAPIKey,
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
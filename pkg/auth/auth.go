@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// Scopes a client can be granted, every protected route requires one of them.
+const (
+	ScopeReadDocs        = "docs:read"
+	ScopeReadSamples     = "samples:read"
+	ScopeEditAnnotations = "annotations:write"
+	ScopeTriggerBuilds   = "builds:trigger"
+	ScopeWriteSamples    = "samples:write"
+)
+
+var Scopes = []string{
+	ScopeReadDocs,
+	ScopeReadSamples,
+	ScopeEditAnnotations,
+	ScopeTriggerBuilds,
+	ScopeWriteSamples,
+}
+
+// Methods a principal can be authenticated with.
+const (
+	MethodAPIKey = "api_key"
+	MethodHMAC   = "hmac"
+	MethodJWT    = "jwt"
+)
+
+var (
+	// ErrNoCredentials is returned by an authenticator when the request does
+	// not carry its kind of credentials, the next authenticator is tried.
+	ErrNoCredentials      = errors.New("no credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the client a request is authenticated as.
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain authenticates a request with the first authenticator whose kind of
+// credentials it carries.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of an authenticated request, nil for
+// an anonymous one.
+func PrincipalFrom(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
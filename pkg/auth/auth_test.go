@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+	authenticator := NewAPIKeyAuthenticator([]*APIKey{
+		{Name: "ci", Key: "secret-key", Scopes: []string{ScopeReadDocs}},
+	})
+	tests := []struct {
+		name          string
+		key           string
+		wantPrincipal *Principal
+		wantErr       error
+	}{
+		{
+			name:          "Test APIKeyAuthenticator - valid key",
+			key:           "secret-key",
+			wantPrincipal: &Principal{Subject: "ci", Method: MethodAPIKey, Scopes: []string{ScopeReadDocs}},
+		},
+		{
+			name:    "Test APIKeyAuthenticator - unknown key",
+			key:     "other-key",
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "Test APIKeyAuthenticator - no key",
+			wantErr: ErrNoCredentials,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest(http.MethodGet, "/internal/apis", nil)
+			if tt.key != "" {
+				r.Header.Set(HeaderAPIKey, tt.key)
+			}
+			principal, err := authenticator.Authenticate(r)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantPrincipal, principal)
+		})
+	}
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	authenticator := NewHMACAuthenticator([]*HMACKey{
+		{Id: "builder", Secret: "hmac-secret", Scopes: []string{ScopeTriggerBuilds}},
+	}, 5*time.Minute)
+	authenticator.now = func() time.Time { return now }
+	tests := []struct {
+		name     string
+		keyId    string
+		secret   string
+		signedAt time.Time
+		tamper   func(r *http.Request)
+		wantErr  error
+	}{
+		{
+			name:     "Test HMACAuthenticator - valid signature",
+			keyId:    "builder",
+			secret:   "hmac-secret",
+			signedAt: now.Add(-time.Minute),
+		},
+		{
+			name:     "Test HMACAuthenticator - wrong secret",
+			keyId:    "builder",
+			secret:   "other-secret",
+			signedAt: now,
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			name:     "Test HMACAuthenticator - unknown key",
+			keyId:    "unknown",
+			secret:   "hmac-secret",
+			signedAt: now,
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			name:     "Test HMACAuthenticator - expired signature",
+			keyId:    "builder",
+			secret:   "hmac-secret",
+			signedAt: now.Add(-10 * time.Minute),
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			name:     "Test HMACAuthenticator - tampered query",
+			keyId:    "builder",
+			secret:   "hmac-secret",
+			signedAt: now,
+			tamper: func(r *http.Request) {
+				r.URL.RawQuery = "force=false"
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:     "Test HMACAuthenticator - tampered body",
+			keyId:    "builder",
+			secret:   "hmac-secret",
+			signedAt: now,
+			tamper: func(r *http.Request) {
+				r.Body = http.NoBody
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest(http.MethodPost, "/internal/apis/1/build?force=true", strings.NewReader(`{"a":1}`))
+			require.NoError(t, Sign(r, tt.keyId, tt.secret, tt.signedAt))
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+			principal, err := authenticator.Authenticate(r)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				return
+			}
+			require.Equal(t, &Principal{Subject: "builder", Method: MethodHMAC, Scopes: []string{ScopeTriggerBuilds}}, principal)
+			// the body is left for the handler
+			body := make([]byte, 7)
+			_, _ = r.Body.Read(body)
+			require.Equal(t, `{"a":1}`, string(body))
+		})
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+	keys, err := ParseJWKS(jwks)
+	require.NoError(t, err)
+	authenticator := NewJWTAuthenticator(keys, "https://sso.chotot.org", "api-document", "scope")
+
+	validClaims := jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://sso.chotot.org",
+		"aud":   "api-document",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "docs:read samples:read",
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range validClaims {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+			return claims
+		}
+		claims[key] = value
+		return claims
+	}
+	tests := []struct {
+		name          string
+		claims        jwt.MapClaims
+		signingKey    *rsa.PrivateKey
+		wantPrincipal *Principal
+		wantErr       error
+	}{
+		{
+			name:       "Test JWTAuthenticator - valid token",
+			claims:     validClaims,
+			signingKey: privateKey,
+			wantPrincipal: &Principal{
+				Subject: "alice",
+				Method:  MethodJWT,
+				Scopes:  []string{ScopeReadDocs, ScopeReadSamples},
+			},
+		},
+		{
+			name:       "Test JWTAuthenticator - scopes as an array",
+			claims:     with("scope", []string{ScopeTriggerBuilds}),
+			signingKey: privateKey,
+			wantPrincipal: &Principal{
+				Subject: "alice",
+				Method:  MethodJWT,
+				Scopes:  []string{ScopeTriggerBuilds},
+			},
+		},
+		{
+			name:       "Test JWTAuthenticator - unknown signing key",
+			claims:     validClaims,
+			signingKey: otherKey,
+			wantErr:    ErrInvalidCredentials,
+		},
+		{
+			name:       "Test JWTAuthenticator - expired token",
+			claims:     with("exp", time.Now().Add(-time.Hour).Unix()),
+			signingKey: privateKey,
+			wantErr:    ErrInvalidCredentials,
+		},
+		{
+			name:       "Test JWTAuthenticator - token without expiration",
+			claims:     with("exp", nil),
+			signingKey: privateKey,
+			wantErr:    ErrInvalidCredentials,
+		},
+		{
+			name:       "Test JWTAuthenticator - wrong issuer",
+			claims:     with("iss", "https://evil.example"),
+			signingKey: privateKey,
+			wantErr:    ErrInvalidCredentials,
+		},
+		{
+			name:       "Test JWTAuthenticator - wrong audience",
+			claims:     with("aud", "other-service"),
+			signingKey: privateKey,
+			wantErr:    ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, tt.claims)
+			token.Header["kid"] = "key-1"
+			tokenString, err := token.SignedString(tt.signingKey)
+			require.NoError(t, err)
+			r := httptest.NewRequest(http.MethodGet, "/internal/openapi.json", nil)
+			r.Header.Set("Authorization", "Bearer "+tokenString)
+			principal, err := authenticator.Authenticate(r)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantPrincipal, principal)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+	chain := Chain{
+		NewAPIKeyAuthenticator([]*APIKey{{Name: "ci", Key: "secret-key"}}),
+		NewHMACAuthenticator(nil, time.Minute),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/internal/apis", nil)
+	_, err := chain.Authenticate(r)
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	r.Header.Set(HeaderKeyId, "unknown")
+	_, err = chain.Authenticate(r)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	r.Header.Set(HeaderAPIKey, "secret-key")
+	principal, err := chain.Authenticate(r)
+	require.NoError(t, err)
+	require.Equal(t, "ci", principal.Subject)
+}
+
+func TestKeysValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		keys    *Keys
+		wantErr bool
+	}{
+		{
+			name: "Test KeysValidate - valid keys",
+			keys: &Keys{
+				APIKeys:  []*APIKey{{Name: "ci", Key: "k", Scopes: []string{ScopeReadDocs}}},
+				HMACKeys: []*HMACKey{{Id: "builder", Secret: "s", Scopes: []string{ScopeTriggerBuilds}}},
+			},
+		},
+		{
+			name:    "Test KeysValidate - unknown scope",
+			keys:    &Keys{APIKeys: []*APIKey{{Name: "ci", Key: "k", Scopes: []string{"admin"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test KeysValidate - duplicated key",
+			keys:    &Keys{APIKeys: []*APIKey{{Name: "ci", Key: "k"}, {Name: "ci", Key: "l"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test KeysValidate - missing secret",
+			keys:    &Keys{HMACKeys: []*HMACKey{{Id: "builder"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.keys.validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
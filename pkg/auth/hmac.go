@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderKeyId     = "X-Auth-Key-Id"
+	HeaderTimestamp = "X-Auth-Timestamp"
+	HeaderSignature = "X-Auth-Signature"
+)
+
+// HMACAuthenticator authenticates signed requests. The signature is the hex
+// encoded HMAC-SHA256, keyed with the secret of the key id, of
+//
+//	METHOD\nREQUEST_URI\nTIMESTAMP\nHEX(SHA256(BODY))
+//
+// where the timestamp is in unix seconds and must be within maxSkew of now.
+// A signed request can be replayed until it is too old.
+type HMACAuthenticator struct {
+	keys    map[string]*HMACKey
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+var _ Authenticator = (*HMACAuthenticator)(nil)
+
+func NewHMACAuthenticator(hmacKeys []*HMACKey, maxSkew time.Duration) *HMACAuthenticator {
+	keys := make(map[string]*HMACKey, len(hmacKeys))
+	for _, hmacKey := range hmacKeys {
+		keys[hmacKey.Id] = hmacKey
+	}
+	return &HMACAuthenticator{
+		keys:    keys,
+		maxSkew: maxSkew,
+		now:     time.Now,
+	}
+}
+
+func (a *HMACAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	keyId := r.Header.Get(HeaderKeyId)
+	if keyId == "" {
+		return nil, ErrNoCredentials
+	}
+	hmacKey, ok := a.keys[keyId]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	timestamp := r.Header.Get(HeaderTimestamp)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	skew := a.now().Sub(time.Unix(seconds, 0))
+	if skew > a.maxSkew || skew < -a.maxSkew {
+		return nil, ErrInvalidCredentials
+	}
+	signature, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(signature, sign(hmacKey.Secret, r.Method, r.URL.RequestURI(), timestamp, body)) {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		Subject: hmacKey.Id,
+		Method:  MethodHMAC,
+		Scopes:  hmacKey.Scopes,
+	}, nil
+}
+
+// Sign sets the headers authenticating r as keyId, it is meant for the
+// clients of the service.
+func Sign(r *http.Request, keyId, secret string, now time.Time) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	r.Header.Set(HeaderKeyId, keyId)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(sign(secret, r.Method, r.URL.RequestURI(), timestamp, body)))
+	return nil
+}
+
+func sign(secret, method, requestURI, timestamp string, body []byte) []byte {
+	bodyDigest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + requestURI + "\n" + timestamp + "\n" + hex.EncodeToString(bodyDigest[:])))
+	return mac.Sum(nil)
+}
+
+// readBody reads the body of r and puts it back for the handler.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys are the static credentials of the clients. They are loaded from a
+// file, mounted from the vault, rather than from the environment.
+type Keys struct {
+	APIKeys  []*APIKey  `yaml:"api_keys"`
+	HMACKeys []*HMACKey `yaml:"hmac_keys"`
+}
+
+type APIKey struct {
+	Name   string   `yaml:"name"`
+	Key    string   `yaml:"key"`
+	Scopes []string `yaml:"scopes"`
+}
+
+type HMACKey struct {
+	Id     string   `yaml:"id"`
+	Secret string   `yaml:"secret"`
+	Scopes []string `yaml:"scopes"`
+}
+
+func LoadKeys(path string) (*Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := &Keys{}
+	if err := yaml.Unmarshal(data, keys); err != nil {
+		return nil, fmt.Errorf("parse keys file: %w", err)
+	}
+	if err := keys.validate(); err != nil {
+		return nil, fmt.Errorf("invalid keys file: %w", err)
+	}
+	return keys, nil
+}
+
+func (k *Keys) validate() error {
+	names := make(map[string]bool, len(k.APIKeys))
+	for _, apiKey := range k.APIKeys {
+		if apiKey.Name == "" || apiKey.Key == "" {
+			return fmt.Errorf("api keys need a name and a key")
+		}
+		if names[apiKey.Name] {
+			return fmt.Errorf("api key %s is defined twice", apiKey.Name)
+		}
+		names[apiKey.Name] = true
+		if err := validateScopes(apiKey.Scopes); err != nil {
+			return fmt.Errorf("api key %s: %w", apiKey.Name, err)
+		}
+	}
+	ids := make(map[string]bool, len(k.HMACKeys))
+	for _, hmacKey := range k.HMACKeys {
+		if hmacKey.Id == "" || hmacKey.Secret == "" {
+			return fmt.Errorf("hmac keys need an id and a secret")
+		}
+		if ids[hmacKey.Id] {
+			return fmt.Errorf("hmac key %s is defined twice", hmacKey.Id)
+		}
+		ids[hmacKey.Id] = true
+		if err := validateScopes(hmacKey.Scopes); err != nil {
+			return fmt.Errorf("hmac key %s: %w", hmacKey.Id, err)
+		}
+	}
+	return nil
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %s", scope)
+		}
+	}
+	return nil
+}
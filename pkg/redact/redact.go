@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces a redacted value, it is the mask kong puts on the headers it
+// redacts.
+const Mask = "REDACTED"
+
+// DefaultKeys are the names of the fields and parameters whose values are
+// always masked, they are compared normalized.
+var DefaultKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"api_key",
+	"authorization",
+	"cookie",
+	"otp",
+	"pin",
+	"cvv",
+	"card_number",
+	"phone",
+	"phone_number",
+	"email",
+	"id_number",
+	"identity_number",
+}
+
+// valuePatterns match the sensitive values found under any name.
+var valuePatterns = []*regexp.Regexp{
+	// emails
+	regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	// JWTs
+	regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	// vietnamese phone numbers
+	regexp.MustCompile(`(?:\+84|\b0)[35789][0-9]{8}\b`),
+}
+
+// Redactor masks the sensitive values of the samples before they leave the
+// service, the stored samples are left untouched.
+type Redactor struct {
+	keys map[string]bool
+}
+
+func New(extraKeys []string) *Redactor {
+	keys := make(map[string]bool, len(DefaultKeys)+len(extraKeys))
+	for _, key := range DefaultKeys {
+		keys[normalizeKey(key)] = true
+	}
+	for _, key := range extraKeys {
+		keys[normalizeKey(key)] = true
+	}
+	return &Redactor{
+		keys: keys,
+	}
+}
+
+// Body redacts a JSON body field by field, the bodies which are not JSON
+// only get their sensitive values masked.
+func (r *Redactor) Body(body string) string {
+	if body == "" {
+		return body
+	}
+	var doc any
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil || decoder.More() {
+		return r.text(body)
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.value(doc)); err != nil {
+		return r.text(body)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Parameter redacts the value of the parameter name.
+func (r *Redactor) Parameter(name, value string) string {
+	if r.IsSensitive(name) && value != "" {
+		return Mask
+	}
+	return r.text(value)
+}
+
+// IsSensitive reports whether the values of the field or parameter name are
+// masked whatever they are.
+func (r *Redactor) IsSensitive(name string) bool {
+	return r.keys[normalizeKey(name)]
+}
+
+func (r *Redactor) value(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if r.IsSensitive(key) && item != nil {
+				value[key] = Mask
+				continue
+			}
+			value[key] = r.value(item)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = r.value(item)
+		}
+		return value
+	case string:
+		return r.text(value)
+	}
+	return v
+}
+
+func (r *Redactor) text(s string) string {
+	for _, pattern := range valuePatterns {
+		s = pattern.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// normalizeKey makes phoneNumber, phone_number and Phone-Number the same key.
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
@@ -0,0 +1,59 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactorBody(t *testing.T) {
+	t.Parallel()
+	redactor := New([]string{"bank_account"})
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{
+			name:     "Test RedactorBody - sensitive keys",
+			body:     `{"phoneNumber":"0373601207","Password":"hunter2","bankAccount":"123","id":1302748}`,
+			wantBody: `{"Password":"REDACTED","bankAccount":"REDACTED","id":1302748,"phoneNumber":"REDACTED"}`,
+		},
+		{
+			name:     "Test RedactorBody - sensitive values under any key",
+			body:     `{"data":[{"created_user":"admin-ds@chotot.vn","note":"call 0373601207 after 5pm","amount":20000}]}`,
+			wantBody: `{"data":[{"amount":20000,"created_user":"REDACTED","note":"call REDACTED after 5pm"}]}`,
+		},
+		{
+			name:     "Test RedactorBody - null sensitive value",
+			body:     `{"email":null,"price":1.50}`,
+			wantBody: `{"email":null,"price":1.50}`,
+		},
+		{
+			name:     "Test RedactorBody - not JSON",
+			body:     `token=eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig&next=/ads`,
+			wantBody: `token=REDACTED&next=/ads`,
+		},
+		{
+			name:     "Test RedactorBody - empty",
+			body:     ``,
+			wantBody: ``,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantBody, redactor.Body(tt.body))
+		})
+	}
+}
+
+func TestRedactorParameter(t *testing.T) {
+	t.Parallel()
+	redactor := New(nil)
+	require.Equal(t, Mask, redactor.Parameter("access_token", "abc"))
+	require.Equal(t, "", redactor.Parameter("access_token", ""))
+	require.Equal(t, "20", redactor.Parameter("limit", "20"))
+	require.Equal(t, Mask, redactor.Parameter("q", "someone@chotot.vn"))
+}
@@ -0,0 +1,207 @@
+package jsonpathutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Operators of a predicate, a predicate without operator checks that the
+// path exists.
+const (
+	OperatorEqual          = "=="
+	OperatorNotEqual       = "!="
+	OperatorGreater        = ">"
+	OperatorGreaterOrEqual = ">="
+	OperatorLess           = "<"
+	OperatorLessOrEqual    = "<="
+)
+
+// operators are tried in order, the two characters ones first.
+var operators = []string{
+	OperatorEqual,
+	OperatorNotEqual,
+	OperatorGreaterOrEqual,
+	OperatorLessOrEqual,
+	OperatorGreater,
+	OperatorLess,
+}
+
+// wildcard is the segment matching every item of an array or every value of
+// an object.
+const wildcard = "*"
+
+var ErrInvalidPredicate = errors.New("invalid predicate")
+
+// Predicate is a condition on a JSON document such as $.ads[*].price>=100,
+// it holds when any value selected by the path satisfies it.
+type Predicate struct {
+	Path     []string
+	Operator string
+	Value    any
+}
+
+// ParsePredicate parses a path made of .name, [index], ["name"] and [*]
+// segments from the root $, followed by an operator and a JSON value. A value
+// which is not valid JSON is taken as a string.
+func ParsePredicate(s string) (*Predicate, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("%w: the path must start with $", ErrInvalidPredicate)
+	}
+	path, rest, err := parsePath(s[1:])
+	if err != nil {
+		return nil, err
+	}
+	predicate := &Predicate{Path: path}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return predicate, nil
+	}
+	for _, operator := range operators {
+		if value, ok := strings.CutPrefix(rest, operator); ok {
+			predicate.Operator = operator
+			predicate.Value = parseValue(strings.TrimSpace(value))
+			return predicate, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unexpected %q after the path", ErrInvalidPredicate, rest)
+}
+
+func parsePath(s string) ([]string, string, error) {
+	path := make([]string, 0)
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[=!<> ")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			name := s[1 : end+1]
+			if name == "" {
+				return nil, "", fmt.Errorf("%w: empty name in the path", ErrInvalidPredicate)
+			}
+			path = append(path, name)
+			s = s[end+1:]
+		case '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, "", fmt.Errorf("%w: unclosed [ in the path", ErrInvalidPredicate)
+			}
+			segment := s[1:end]
+			if unquoted, err := strconv.Unquote(segment); err == nil {
+				segment = unquoted
+			} else if _, err := strconv.Atoi(segment); err != nil && segment != wildcard {
+				return nil, "", fmt.Errorf("%w: %q is neither an index nor a quoted name", ErrInvalidPredicate, segment)
+			}
+			path = append(path, segment)
+			s = s[end+1:]
+		default:
+			return path, s, nil
+		}
+	}
+	return path, "", nil
+}
+
+func parseValue(s string) any {
+	var value any
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return s
+	}
+	return value
+}
+
+// Match evaluates the predicate on a document decoded with UseNumber.
+func (p *Predicate) Match(doc any) bool {
+	for _, value := range Select(doc, p.Path) {
+		if p.Operator == "" || compare(value, p.Operator, p.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the values of doc found at path.
+func Select(doc any, path []string) []any {
+	values := []any{doc}
+	for _, segment := range path {
+		next := make([]any, 0, len(values))
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]any:
+				if segment == wildcard {
+					for _, item := range v {
+						next = append(next, item)
+					}
+				} else if item, ok := v[segment]; ok {
+					next = append(next, item)
+				}
+			case []any:
+				if segment == wildcard {
+					next = append(next, v...)
+				} else if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(v) {
+					next = append(next, v[index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+func compare(value any, operator string, expected any) bool {
+	if number, ok := toFloat(value); ok {
+		if expectedNumber, ok := toFloat(expected); ok {
+			return compareOrdered(number, operator, expectedNumber)
+		}
+	}
+	valueString, ok := value.(string)
+	expectedString, expectedOk := expected.(string)
+	if ok && expectedOk {
+		return compareOrdered(valueString, operator, expectedString)
+	}
+	// booleans, null and mismatched types are only equal or not
+	equal := cast.ToString(value) == cast.ToString(expected) && (value == nil) == (expected == nil)
+	switch operator {
+	case OperatorEqual:
+		return equal
+	case OperatorNotEqual:
+		return !equal
+	}
+	return false
+}
+
+func compareOrdered[T float64 | string](value T, operator string, expected T) bool {
+	switch operator {
+	case OperatorEqual:
+		return value == expected
+	case OperatorNotEqual:
+		return value != expected
+	case OperatorGreater:
+		return value > expected
+	case OperatorGreaterOrEqual:
+		return value >= expected
+	case OperatorLess:
+		return value < expected
+	case OperatorLessOrEqual:
+		return value <= expected
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package jsonpathutils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePredicate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		predicate     string
+		wantPredicate *Predicate
+		wantErr       bool
+	}{
+		{
+			name:          "Test ParsePredicate - exists",
+			predicate:     "$.ads[0].subject",
+			wantPredicate: &Predicate{Path: []string{"ads", "0", "subject"}},
+		},
+		{
+			name:          "Test ParsePredicate - number",
+			predicate:     "$.ads[*].price >= 100",
+			wantPredicate: &Predicate{Path: []string{"ads", "*", "price"}, Operator: ">=", Value: json.Number("100")},
+		},
+		{
+			name:          "Test ParsePredicate - quoted name and bare string",
+			predicate:     `$["user.type"]==private`,
+			wantPredicate: &Predicate{Path: []string{"user.type"}, Operator: "==", Value: "private"},
+		},
+		{
+			name:          "Test ParsePredicate - JSON string",
+			predicate:     `$.status!="done"`,
+			wantPredicate: &Predicate{Path: []string{"status"}, Operator: "!=", Value: "done"},
+		},
+		{
+			name:      "Test ParsePredicate - missing root",
+			predicate: "ads.price>1",
+			wantErr:   true,
+		},
+		{
+			name:      "Test ParsePredicate - unknown operator",
+			predicate: "$.price=~1",
+			wantErr:   true,
+		},
+		{
+			name:      "Test ParsePredicate - invalid index",
+			predicate: "$.ads[first]",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			predicate, err := ParsePredicate(tt.predicate)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidPredicate)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPredicate, predicate)
+		})
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	t.Parallel()
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"ads": [{"price": 50, "type": "sell"}, {"price": 150, "type": "let"}],
+		"total": 2,
+		"has_more": false,
+		"next": null
+	}`))
+	decoder.UseNumber()
+	var doc any
+	require.NoError(t, decoder.Decode(&doc))
+	tests := []struct {
+		predicate string
+		wantMatch bool
+	}{
+		{predicate: "$.ads[*].price>100", wantMatch: true},
+		{predicate: "$.ads[*].price>200", wantMatch: false},
+		{predicate: "$.ads[0].type==sell", wantMatch: true},
+		{predicate: "$.ads[1].type==sell", wantMatch: false},
+		{predicate: "$.ads[5].type", wantMatch: false},
+		{predicate: "$.total==2", wantMatch: true},
+		{predicate: "$.total<=1.5", wantMatch: false},
+		{predicate: "$.has_more==false", wantMatch: true},
+		{predicate: "$.next==null", wantMatch: true},
+		{predicate: "$.next!=null", wantMatch: false},
+		{predicate: "$.next", wantMatch: true},
+		{predicate: "$.previous", wantMatch: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run("Test PredicateMatch - "+tt.predicate, func(t *testing.T) {
+			t.Parallel()
+			predicate, err := ParsePredicate(tt.predicate)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMatch, predicate.Match(doc))
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package mapsutils
+
+import "sort"
+
+// SortedKeys returns the keys of m in ascending order, the maps being rendered
+// in a stable order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
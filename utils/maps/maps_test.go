@@ -0,0 +1,34 @@
+package mapsutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedKeys(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		m        map[string]int
+		wantKeys []string
+	}{
+		{
+			name:     "Test SortedKeys - nil map",
+			m:        nil,
+			wantKeys: []string{},
+		},
+		{
+			name:     "Test SortedKeys - keys sorted",
+			m:        map[string]int{"b": 1, "a": 2, "c": 3},
+			wantKeys: []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantKeys, SortedKeys(tt.m))
+		})
+	}
+}
@@ -73,6 +73,11 @@ func AddDebugOption(opts *options.ClientOptions, lg *logger.Logger) {
 	opts.SetMonitor(cmdMonitor)
 }
 
+const defaultIterateBatchSize = 100
+
+// ErrStopIteration can be returned by an Iterate callback to stop early.
+var ErrStopIteration = errors.New("stop iteration")
+
 type BaseCollection[P any, T IEntity[P]] struct {
 	collection     *mongo.Collection
 	collectionName string
@@ -163,6 +168,82 @@ func (col *BaseCollection[P, T]) GetByBatch(ctx context.Context, filter any,
 	return ret, nil
 }
 
+// Iterate streams every document matching filter to fn, in sort order, using
+// keyset pagination: each batch resumes after the last document seen instead
+// of skipping an offset, so concurrent inserts never shift or duplicate items.
+// sort may contain at most one field besides _id, which is always added as the
+// tie-breaker; that field must be present on every matched document.
+// Returning ErrStopIteration from fn stops the iteration without an error.
+func (col *BaseCollection[P, T]) Iterate(ctx context.Context, filter any,
+	sort primitive.D, fn func(item T) error,
+) error {
+	return col.iterate(ctx, filter, sort, defaultIterateBatchSize, fn)
+}
+
+func (col *BaseCollection[P, T]) iterate(ctx context.Context, filter any,
+	sort primitive.D, batchSize int64, fn func(item T) error,
+) error {
+	keysetSort, err := buildKeysetSort(sort)
+	if err != nil {
+		return err
+	}
+	baseFilter := primitive.M{}
+	for k, v := range structToMap(filter, true) {
+		baseFilter[k] = v
+	}
+	baseFilter["deleted_at"] = bson.M{"$exists": false}
+
+	var last bson.Raw
+	for {
+		pageFilter := baseFilter
+		if last != nil {
+			pageFilter = primitive.M{
+				"$and": primitive.A{baseFilter, buildKeysetFilter(keysetSort, last)},
+			}
+		}
+		opts := options.Find().SetSort(keysetSort).SetLimit(batchSize)
+		cursor, err := col.collection.Find(ctx, pageFilter, opts)
+		if err != nil {
+			logctx.Errorf(ctx, "unable to iterate collection %s, err: %v", col.collection.Name(), err)
+			return fmt.Errorf("mongo iterate: %w", err)
+		}
+		count, err := col.consumeCursor(ctx, cursor, &last, fn)
+		if err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+		if count < batchSize {
+			return nil
+		}
+	}
+}
+
+func (col *BaseCollection[P, T]) consumeCursor(ctx context.Context, cursor *mongo.Cursor,
+	last *bson.Raw, fn func(item T) error,
+) (int64, error) {
+	defer cursor.Close(ctx)
+	count := int64(0)
+	for cursor.Next(ctx) {
+		count++
+		// cursor.Current is reused by the driver, keep a copy for the next page
+		*last = append(bson.Raw(nil), cursor.Current...)
+		item := new(P)
+		if err := cursor.Decode(item); err != nil {
+			logctx.Errorf(ctx, "unable to parse result from %s, err: %v", col.collection.Name(), err)
+			return count, fmt.Errorf("mongo iterate parse result: %w", err)
+		}
+		if err := fn(T(item)); err != nil {
+			return count, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return count, fmt.Errorf("mongo iterate cursor: %w", err)
+	}
+	return count, nil
+}
+
 func (col *BaseCollection[P, T]) Insert(ctx context.Context, item T) error {
 	item.SetCreatedAt(time.Now())
 	ret, err := col.collection.InsertOne(ctx, item)
@@ -193,6 +274,49 @@ func (col *BaseCollection[P, T]) InsertMany(ctx context.Context, items []T) erro
 	return nil
 }
 
+// UpsertRaw is UpdateRaw inserting the document when none matches filter, the
+// inserted document is made of the equality fields of filter and update.
+func (col *BaseCollection[P, T]) UpsertRaw(ctx context.Context,
+	filter any, update primitive.M,
+) (*mongo.UpdateResult, error) {
+	isUpsert := true
+	result, err := col.collection.UpdateOne(ctx, filter, update, &options.UpdateOptions{
+		Upsert: &isUpsert,
+	})
+	if err != nil {
+		logctx.Errorf(ctx, "mongo raw upsert, collection: %s, err: %v", col.collection.Name(), err)
+		return nil, fmt.Errorf("mongo raw upsert, %w", err)
+	}
+	return result, nil
+}
+
+// BulkWrite sends models in a single unordered bulk write, a failing model
+// does not stop the others.
+func (col *BaseCollection[P, T]) BulkWrite(ctx context.Context,
+	models []mongo.WriteModel,
+) (*mongo.BulkWriteResult, error) {
+	result, err := col.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		logctx.Errorf(ctx, "mongo bulk write, collection: %s, err: %v", col.collection.Name(), err)
+		return nil, fmt.Errorf("mongo bulk write, %w", err)
+	}
+	return result, nil
+}
+
+// EnsureUniqueIndex creates the unique index on keys, it is a no-op when the
+// index already exists.
+func (col *BaseCollection[P, T]) EnsureUniqueIndex(ctx context.Context, keys primitive.D) error {
+	_, err := col.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logctx.Errorf(ctx, "mongo create index, collection: %s, err: %v", col.collection.Name(), err)
+		return fmt.Errorf("mongo create index, %w", err)
+	}
+	return nil
+}
+
 // NOTED: nested struct won't be converted to Object
 func (col *BaseCollection[P, T]) Update(ctx context.Context, filter any, item any) (int64, error) {
 	params := structToMap(item, false)
@@ -243,6 +367,50 @@ func (col *BaseCollection[P, T]) UpdatePartial(ctx context.Context,
 	return result, nil
 }
 
+// UpdatePartialWithVersion sets params on the document matching filter only
+// while its "version" field still equals version, and increments it, so a writer
+// holding a stale copy matches nothing instead of overwriting a newer update.
+// Version 0 also matches documents written before they were versioned.
+func (col *BaseCollection[P, T]) UpdatePartialWithVersion(ctx context.Context,
+	filter container.Map, version int64, params container.Map,
+) (*mongo.UpdateResult, error) {
+	versionFilter := primitive.M{}
+	for k, v := range filter {
+		versionFilter[k] = v
+	}
+	versionFilter["version"] = version
+	if version == 0 {
+		versionFilter["version"] = primitive.M{"$in": primitive.A{0, nil}}
+	}
+	params = params.Except([]string{"_id", "id", "created_at", "version"})
+	params = params.Merge(container.Map{
+		"updated_at": time.Now(),
+	})
+	result, err := col.collection.UpdateOne(ctx, versionFilter, primitive.M{
+		"$set": primitive.M(params),
+		"$inc": primitive.M{"version": 1},
+	})
+	if err != nil {
+		logctx.Errorf(ctx, "mongo versioned update, collection: %s, err: %v", col.collection.Name(), err)
+		return nil, fmt.Errorf("mongo versioned update, %w", err)
+	}
+	return result, nil
+}
+
+// UpdateRaw applies update, a document of update operators such as $max or
+// $inc, to the document matching filter. Unlike UpdatePartial it leaves
+// updated_at as is, for bookkeeping fields which are not an edit of the document.
+func (col *BaseCollection[P, T]) UpdateRaw(ctx context.Context,
+	filter any, update primitive.M,
+) (*mongo.UpdateResult, error) {
+	result, err := col.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logctx.Errorf(ctx, "mongo raw update, collection: %s, err: %v", col.collection.Name(), err)
+		return nil, fmt.Errorf("mongo raw update, %w", err)
+	}
+	return result, nil
+}
+
 // NOTED: nested struct won't be converted to Object
 func (col *BaseCollection[P, T]) Upsert(ctx context.Context,
 	filter any, item T) (modifiedCount int64,
@@ -327,6 +495,59 @@ func (col *BaseCollection[P, T]) CountByFilter(ctx context.Context, filter any)
 	return col.collection.CountDocuments(ctx, filter)
 }
 
+// Distinct returns the distinct values of field among the documents matching filter.
+func (col *BaseCollection[P, T]) Distinct(ctx context.Context, field string, filter any) ([]any, error) {
+	mapFilter := structToMap(filter, true)
+	mapFilter["deleted_at"] = bson.M{"$exists": false}
+	values, err := col.collection.Distinct(ctx, field, mapFilter)
+	if err != nil {
+		logctx.Errorf(ctx, "mongo distinct, collection: %s, err: %v", col.collection.Name(), err)
+		return nil, fmt.Errorf("mongo distinct: %w", err)
+	}
+	return values, nil
+}
+
+// buildKeysetSort appends _id as the tie-breaker of sort, defaulting to _id ascending.
+func buildKeysetSort(sort primitive.D) (primitive.D, error) {
+	keysetSort := primitive.D{}
+	idDirection := any(1)
+	for _, e := range sort {
+		if e.Key == "_id" {
+			idDirection = e.Value
+			continue
+		}
+		if len(keysetSort) > 0 {
+			return nil, fmt.Errorf("keyset pagination supports one sort field besides _id, got %v", sort)
+		}
+		keysetSort = append(keysetSort, e)
+	}
+	return append(keysetSort, primitive.E{Key: "_id", Value: idDirection}), nil
+}
+
+// buildKeysetFilter matches the documents strictly after last in keysetSort order.
+func buildKeysetFilter(keysetSort primitive.D, last bson.Raw) primitive.M {
+	comparison := func(direction any) string {
+		if cast.ToInt(direction) < 0 {
+			return "$lt"
+		}
+		return "$gt"
+	}
+	idSort := keysetSort[len(keysetSort)-1]
+	idFilter := primitive.M{"_id": primitive.M{comparison(idSort.Value): last.Lookup("_id")}}
+	if len(keysetSort) == 1 {
+		return idFilter
+	}
+	field := keysetSort[0]
+	lastValue := last.Lookup(field.Key)
+	idFilter[field.Key] = lastValue
+	return primitive.M{
+		"$or": primitive.A{
+			primitive.M{field.Key: primitive.M{comparison(field.Value): lastValue}},
+			idFilter,
+		},
+	}
+}
+
 func structToMap(item any, isIgnoreEmpty bool) container.Map {
 	if fmt.Sprintf("%T", item) == fmt.Sprintf("%T", container.Map{}) {
 		itemInMap, ok := item.(container.Map)
@@ -263,6 +263,70 @@ func TestBaseCollection_UpdatePartial(t *testing.T) {
 	}
 }
 
+func TestBaseCollection_UpdatePartialWithVersion(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	user := seedUser(t, userCol)
+	filter := container.Map{"_id": user.Id}
+	ctx := context.Background()
+
+	// unversioned documents match version 0
+	result, err := userCol.UpdatePartialWithVersion(ctx, filter, 0, container.Map{"age": 1})
+	require.Nil(t, err)
+	require.Equal(t, int64(1), result.MatchedCount)
+
+	// a stale writer still holding version 0 matches nothing
+	result, err = userCol.UpdatePartialWithVersion(ctx, filter, 0, container.Map{"age": 2})
+	require.Nil(t, err)
+	require.Equal(t, int64(0), result.MatchedCount)
+
+	result, err = userCol.UpdatePartialWithVersion(ctx, filter, 1, container.Map{"age": 3})
+	require.Nil(t, err)
+	require.Equal(t, int64(1), result.MatchedCount)
+
+	updated, err := userCol.Get(ctx, filter)
+	require.Nil(t, err)
+	require.Equal(t, 3, updated.Age)
+}
+
+func TestBaseCollection_UpdateRaw(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	user := seedUser(t, userCol)
+	filter := container.Map{"_id": user.Id}
+	ctx := context.Background()
+
+	result, err := userCol.UpdateRaw(ctx, filter, primitive.M{"$max": primitive.M{"age": user.Age + 10}})
+	require.Nil(t, err)
+	require.Equal(t, int64(1), result.ModifiedCount)
+	// $max keeps the greater value
+	result, err = userCol.UpdateRaw(ctx, filter, primitive.M{"$max": primitive.M{"age": user.Age}})
+	require.Nil(t, err)
+	require.Equal(t, int64(0), result.ModifiedCount)
+
+	updated, err := userCol.Get(ctx, filter)
+	require.Nil(t, err)
+	require.Equal(t, user.Age+10, updated.Age)
+	require.Equal(t, user.UpdatedAt.Unix(), updated.UpdatedAt.Unix())
+}
+
+func TestBaseCollection_Distinct(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	ctx := context.Background()
+	accountId := gofakeit.IntRange(10000001, 20000000)
+	for _, age := range []int{20, 30, 20} {
+		user := randomUser()
+		user.AccountId = accountId
+		user.Age = age
+		require.Nil(t, userCol.Insert(ctx, user))
+	}
+
+	values, err := userCol.Distinct(ctx, "age", container.Map{"account_id": accountId})
+	require.Nil(t, err)
+	require.ElementsMatch(t, []any{int32(20), int32(30)}, values)
+}
+
 func TestBaseCollection_Upsert(t *testing.T) {
 	t.Parallel()
 	userCol := getUserCollection((t))
@@ -403,6 +467,102 @@ func TestBaseCollection_DeleteById(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestBaseCollection_Iterate(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	name := gofakeit.UUID()
+	seeded := make(map[primitive.ObjectID]bool)
+	for i := 0; i < 7; i++ {
+		user := randomUser()
+		user.Name = name
+		require.Nil(t, userCol.Insert(context.Background(), user))
+		seeded[user.Id] = true
+	}
+
+	testCases := []struct {
+		Name string
+		Sort primitive.D
+	}{
+		{"default_sort", nil},
+		{"id_desc", primitive.D{{Key: "_id", Value: -1}}},
+		{"created_at_desc", primitive.D{{Key: "created_at", Value: -1}}},
+		{"age_asc", primitive.D{{Key: "age", Value: 1}}},
+	}
+
+	for _, testCase := range testCases {
+		tc := testCase
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			visited := make(map[primitive.ObjectID]int)
+			err := userCol.iterate(context.Background(), container.Map{"name": name}, tc.Sort, 2,
+				func(user *testUser) error {
+					visited[user.Id]++
+					return nil
+				})
+			require.Nil(t, err)
+			require.Equal(t, len(seeded), len(visited))
+			for id, count := range visited {
+				require.True(t, seeded[id])
+				require.Equal(t, 1, count)
+			}
+		})
+	}
+}
+
+func TestBaseCollection_IterateWithConcurrentInsert(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	name := gofakeit.UUID()
+	seeded := make(map[primitive.ObjectID]bool)
+	for i := 0; i < 5; i++ {
+		user := randomUser()
+		user.Name = name
+		require.Nil(t, userCol.Insert(context.Background(), user))
+		seeded[user.Id] = true
+	}
+
+	// inserting while iterating must neither skip nor repeat the seeded users
+	visited := make(map[primitive.ObjectID]int)
+	err := userCol.iterate(context.Background(), container.Map{"name": name},
+		primitive.D{{Key: "created_at", Value: -1}}, 2,
+		func(user *testUser) error {
+			visited[user.Id]++
+			inserted := randomUser()
+			inserted.Name = name
+			return userCol.Insert(context.Background(), inserted)
+		})
+	require.Nil(t, err)
+	for id := range seeded {
+		require.Equal(t, 1, visited[id])
+	}
+	for _, count := range visited {
+		require.Equal(t, 1, count)
+	}
+}
+
+func TestBaseCollection_IterateStop(t *testing.T) {
+	t.Parallel()
+	userCol := getUserCollection((t))
+	name := gofakeit.UUID()
+	for i := 0; i < 3; i++ {
+		user := randomUser()
+		user.Name = name
+		require.Nil(t, userCol.Insert(context.Background(), user))
+	}
+
+	count := 0
+	err := userCol.Iterate(context.Background(), container.Map{"name": name}, nil,
+		func(_ *testUser) error {
+			count++
+			return ErrStopIteration
+		})
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+
+	_, err = buildKeysetSort(primitive.D{{Key: "age", Value: 1}, {Key: "name", Value: 1}})
+	require.Error(t, err)
+}
+
 type anotherObject struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
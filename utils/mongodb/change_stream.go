@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+)
+
+// errCodeChangeStreamHistoryLost is returned when the resume token is older than the oplog.
+const errCodeChangeStreamHistoryLost = 286
+
+// ChangeEvent is a change stream notification of an inserted document,
+// ResumeToken resumes the stream right after this event.
+type ChangeEvent[T any] struct {
+	ResumeToken   bson.Raw `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	FullDocument  T        `bson:"fullDocument"`
+	// Resumed is set when the stream resumed after the given resume token, it
+	// is not when the stream started from now and missed the earlier inserts
+	Resumed bool `bson:"-"`
+}
+
+// WatchInserts subscribes to the documents inserted into the collection and
+// calls fn for each of them until ctx is done or fn fails. The stream resumes
+// after resumeToken when given, or starts from now if the token is no longer in
+// the oplog. Change streams require a replica set.
+func (col *BaseCollection[P, T]) WatchInserts(ctx context.Context, resumeToken bson.Raw,
+	fn func(event *ChangeEvent[T]) error,
+) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": "insert"}}},
+	}
+	opts := options.ChangeStream()
+	if resumeToken != nil {
+		opts.SetStartAfter(resumeToken)
+	}
+	stream, err := col.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		var serverErr mongo.ServerError
+		if resumeToken != nil && errors.As(err, &serverErr) && serverErr.HasErrorCode(errCodeChangeStreamHistoryLost) {
+			logctx.Errorf(ctx, "resume token of collection %s is lost, watching from now", col.collection.Name())
+			return col.WatchInserts(ctx, nil, fn)
+		}
+		logctx.Errorf(ctx, "unable to watch collection %s, err: %v", col.collection.Name(), err)
+		return fmt.Errorf("mongo watch: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		event := &ChangeEvent[T]{}
+		if err := stream.Decode(event); err != nil {
+			logctx.Errorf(ctx, "unable to parse change event from %s, err: %v", col.collection.Name(), err)
+			return fmt.Errorf("mongo watch parse event: %w", err)
+		}
+		event.Resumed = resumeToken != nil
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("mongo watch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	logctx "github.com/carousell/ct-go/pkg/logger/log_context"
+)
+
+var (
+	ErrLeaseNotAcquired = errors.New("lease is held by another owner")
+	ErrLeaseLost        = errors.New("lease lost")
+)
+
+// Lease is a named lock held by Owner until ExpiresAt, the holder has to renew it
+// before it expires or any other owner may take it over.
+type Lease struct {
+	Name      string     `json:"name" bson:"_id"`
+	Owner     string     `json:"owner" bson:"owner"`
+	ExpiresAt time.Time  `json:"expires_at" bson:"expires_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// LeaseCollection stores one document per lease name, a lease is acquired by
+// upserting its document, which only succeeds when it is expired or already
+// owned by the caller; the unique _id turns a concurrent acquisition into a
+// duplicate key error.
+type LeaseCollection struct {
+	collection *mongo.Collection
+	owner      string
+}
+
+func NewLeaseCollection(db *mongo.Database, collectionName string) *LeaseCollection {
+	return &LeaseCollection{
+		collection: db.Collection(collectionName),
+		owner:      newLeaseOwner(),
+	}
+}
+
+// newLeaseOwner identifies the current process, every acquisition then gets its
+// own owner id so two goroutines of the same process never share a lease.
+func newLeaseOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
+
+// Acquire takes the lease name for ttl, it returns ErrLeaseNotAcquired when
+// another owner holds an unexpired lease.
+func (l *LeaseCollection) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	owner := fmt.Sprintf("%s/%s", l.owner, uuid.NewString())
+	lease := &Lease{Name: name, Owner: owner}
+	if err := l.upsert(ctx, lease, ttl, true); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Renew extends a lease held by lease.Owner by ttl, it returns ErrLeaseLost when
+// the lease expired and has been taken over in the meantime.
+func (l *LeaseCollection) Renew(ctx context.Context, lease *Lease, ttl time.Duration) error {
+	return l.upsert(ctx, lease, ttl, false)
+}
+
+// Release gives the lease up so the next owner does not have to wait for it to expire.
+func (l *LeaseCollection) Release(ctx context.Context, lease *Lease) error {
+	_, err := l.collection.DeleteOne(ctx, bson.M{"_id": lease.Name, "owner": lease.Owner})
+	if err != nil {
+		logctx.Errorf(ctx, "mongo release lease %s, err: %v", lease.Name, err)
+		return fmt.Errorf("mongo release lease: %w", err)
+	}
+	return nil
+}
+
+// WithLease runs fn while holding the lease name, renewing it every ttl/3.
+// The context given to fn is canceled as soon as the lease is lost, in which
+// case ErrLeaseLost is returned. ErrLeaseNotAcquired is returned without
+// running fn when another owner holds the lease.
+func (l *LeaseCollection) WithLease(ctx context.Context, name string, ttl time.Duration,
+	fn func(ctx context.Context) error,
+) error {
+	lease, err := l.Acquire(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+	leaseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		lost bool
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Renew(leaseCtx, lease, ttl); err != nil {
+					if leaseCtx.Err() != nil {
+						return
+					}
+					logctx.Errorf(ctx, "failed to renew lease %s, err: %v", name, err)
+					if errors.Is(err, ErrLeaseLost) {
+						lost = true
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	fnErr := fn(leaseCtx)
+	cancel()
+	wg.Wait()
+	if lost {
+		return ErrLeaseLost
+	}
+	// release with the parent context, the lease context is canceled already
+	if err := l.Release(ctx, lease); err != nil {
+		logctx.Errorf(ctx, "failed to release lease %s, err: %v", name, err)
+	}
+	return fnErr
+}
+
+func (l *LeaseCollection) upsert(ctx context.Context, lease *Lease, ttl time.Duration, isAcquire bool) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id": lease.Name,
+		"$or": bson.A{
+			bson.M{"owner": lease.Owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	if !isAcquire {
+		filter = bson.M{"_id": lease.Name, "owner": lease.Owner}
+	}
+	expiresAt := now.Add(ttl)
+	update := bson.M{
+		"$set": bson.M{
+			"owner":      lease.Owner,
+			"expires_at": expiresAt,
+			"updated_at": now,
+		},
+	}
+	result, err := l.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(isAcquire))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrLeaseNotAcquired
+		}
+		logctx.Errorf(ctx, "mongo upsert lease %s, err: %v", lease.Name, err)
+		return fmt.Errorf("mongo upsert lease: %w", err)
+	}
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		return ErrLeaseLost
+	}
+	lease.ExpiresAt = expiresAt
+	lease.UpdatedAt = &now
+	return nil
+}
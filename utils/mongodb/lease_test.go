@@ -0,0 +1,87 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+
+	"github.com/carousell/ct-core-uni-free-premium-service/config"
+)
+
+func TestLeaseCollection_Acquire(t *testing.T) {
+	t.Parallel()
+	leaseCol := getLeaseCollection(t)
+	anotherLeaseCol := getLeaseCollection(t)
+	name := gofakeit.UUID()
+	ctx := context.Background()
+
+	lease, err := leaseCol.Acquire(ctx, name, time.Minute)
+	require.Nil(t, err)
+	require.NotNil(t, lease)
+
+	// held by another owner
+	_, err = anotherLeaseCol.Acquire(ctx, name, time.Minute)
+	require.ErrorIs(t, err, ErrLeaseNotAcquired)
+	// the same process gets a new owner for every acquisition
+	_, err = leaseCol.Acquire(ctx, name, time.Minute)
+	require.ErrorIs(t, err, ErrLeaseNotAcquired)
+
+	require.Nil(t, leaseCol.Renew(ctx, lease, time.Minute))
+	require.Nil(t, leaseCol.Release(ctx, lease))
+
+	anotherLease, err := anotherLeaseCol.Acquire(ctx, name, time.Minute)
+	require.Nil(t, err)
+	require.ErrorIs(t, leaseCol.Renew(ctx, lease, time.Minute), ErrLeaseLost)
+	require.Nil(t, anotherLeaseCol.Release(ctx, anotherLease))
+}
+
+func TestLeaseCollection_AcquireExpired(t *testing.T) {
+	t.Parallel()
+	leaseCol := getLeaseCollection(t)
+	anotherLeaseCol := getLeaseCollection(t)
+	name := gofakeit.UUID()
+	ctx := context.Background()
+
+	lease, err := leaseCol.Acquire(ctx, name, 100*time.Millisecond)
+	require.Nil(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+
+	anotherLease, err := anotherLeaseCol.Acquire(ctx, name, time.Minute)
+	require.Nil(t, err)
+	require.ErrorIs(t, leaseCol.Renew(ctx, lease, time.Minute), ErrLeaseLost)
+	require.Nil(t, anotherLeaseCol.Release(ctx, anotherLease))
+}
+
+func TestLeaseCollection_WithLease(t *testing.T) {
+	t.Parallel()
+	leaseCol := getLeaseCollection(t)
+	anotherLeaseCol := getLeaseCollection(t)
+	name := gofakeit.UUID()
+	ctx := context.Background()
+
+	err := leaseCol.WithLease(ctx, name, 300*time.Millisecond, func(ctx context.Context) error {
+		// outlive the ttl, the heartbeat keeps the lease alive
+		time.Sleep(time.Second)
+		require.Nil(t, ctx.Err())
+		_, err := anotherLeaseCol.Acquire(ctx, name, time.Minute)
+		require.ErrorIs(t, err, ErrLeaseNotAcquired)
+		return nil
+	})
+	require.Nil(t, err)
+
+	// released once fn returns
+	lease, err := anotherLeaseCol.Acquire(ctx, name, time.Minute)
+	require.Nil(t, err)
+	require.Nil(t, anotherLeaseCol.Release(ctx, lease))
+}
+
+func getLeaseCollection(t *testing.T) *LeaseCollection {
+	cfg := config.MustLoad()
+	db, err := ConnectDatabase(context.Background(), cfg)
+	require.Nil(t, err)
+	return NewLeaseCollection(db, "test_lease")
+}
@@ -0,0 +1,126 @@
+package openapiutils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+// RecursiveRefKey replaces a local reference found again while it is being
+// resolved, the resolved document being a tree.
+const RecursiveRefKey = "x-recursive-ref"
+
+// ErrInvalidDocument is returned for a file which is not an OpenAPI 3.x
+// document, or which refers to a missing member of itself.
+var ErrInvalidDocument = errors.New("invalid openapi document")
+
+// ParseDocument decodes an OpenAPI 3.x document written in JSON or YAML, its
+// mappings decoded as map[string]any whatever their keys.
+func ParseDocument(data []byte) (map[string]any, error) {
+	// a JSON document is a YAML one
+	var decoded any
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	document, ok := normalizeYAML(decoded).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an object", ErrInvalidDocument)
+	}
+	if version := cast.ToString(document["openapi"]); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("%w: unsupported openapi version %q", ErrInvalidDocument, version)
+	}
+	return document, nil
+}
+
+// normalizeYAML turns the mappings with non string keys, such as the status
+// codes of the responses, into map[string]any.
+func normalizeYAML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = normalizeYAML(value)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[cast.ToString(key)] = normalizeYAML(value)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	}
+	return v
+}
+
+// ResolveRefs returns a copy of document with its local references ("#/...")
+// replaced by the members they refer to. A reference met again inside its own
+// resolution is replaced by an object holding it under RecursiveRefKey.
+func ResolveRefs(document map[string]any) (map[string]any, error) {
+	resolved, err := resolveRefs(document, document, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]any), nil
+}
+
+func resolveRefs(document map[string]any, v any, stack []string) (any, error) {
+	if items, ok := toSlice(v); ok {
+		resolved := make([]any, 0, len(items))
+		for _, item := range items {
+			resolvedItem, err := resolveRefs(document, item, stack)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, resolvedItem)
+		}
+		return resolved, nil
+	}
+	m, ok := toMap(v)
+	if !ok {
+		return v, nil
+	}
+	resolved := make(map[string]any, len(m))
+	ref, _ := m["$ref"].(string)
+	isLocalRef := strings.HasPrefix(ref, "#")
+	if isLocalRef {
+		for _, seen := range stack {
+			if seen == ref {
+				return map[string]any{RecursiveRefKey: ref}, nil
+			}
+		}
+		target, ok := ResolvePointer(document, ref[1:])
+		if !ok {
+			return nil, fmt.Errorf("%w: unresolved reference %q", ErrInvalidDocument, ref)
+		}
+		resolvedTarget, err := resolveRefs(document, target, append(stack, ref))
+		if err != nil {
+			return nil, err
+		}
+		targetMap, ok := resolvedTarget.(map[string]any)
+		if !ok || len(m) == 1 {
+			return resolvedTarget, nil
+		}
+		// the siblings of the reference, allowed by 3.1, win over the target
+		for key, value := range targetMap {
+			resolved[key] = value
+		}
+	}
+	for key, value := range m {
+		if isLocalRef && key == "$ref" {
+			continue
+		}
+		resolvedValue, err := resolveRefs(document, value, stack)
+		if err != nil {
+			return nil, err
+		}
+		resolved[key] = resolvedValue
+	}
+	return resolved, nil
+}
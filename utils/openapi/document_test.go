@@ -0,0 +1,187 @@
+package openapiutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDocument(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "Test ParseDocument - JSON",
+			data: `{"openapi":"3.0.3","paths":{"/v1/ads":{"get":{"responses":{"200":{"description":"ok"}}}}}}`,
+			want: map[string]any{
+				"openapi": "3.0.3",
+				"paths": map[string]any{
+					"/v1/ads": map[string]any{
+						"get": map[string]any{
+							"responses": map[string]any{"200": map[string]any{"description": "ok"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Test ParseDocument - YAML with status code keys",
+			data: "openapi: 3.1.0\npaths:\n  /v1/ads:\n    get:\n      responses:\n        200:\n          description: ok\n",
+			want: map[string]any{
+				"openapi": "3.1.0",
+				"paths": map[string]any{
+					"/v1/ads": map[string]any{
+						"get": map[string]any{
+							"responses": map[string]any{"200": map[string]any{"description": "ok"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "Test ParseDocument - swagger 2.0",
+			data:    `{"swagger":"2.0","paths":{}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Test ParseDocument - not an object",
+			data:    `[1, 2]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			document, err := ParseDocument([]byte(tt.data))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidDocument)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, document)
+		})
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		document map[string]any
+		want     map[string]any
+		wantErr  bool
+	}{
+		{
+			name: "Test ResolveRefs - nested references and siblings",
+			document: map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/Ad", "description": "the ad"},
+				"components": map[string]any{"schemas": map[string]any{
+					"Ad": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"images": map[string]any{"$ref": "#/components/schemas/Images"}},
+					},
+					"Images": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				}},
+			},
+			want: map[string]any{
+				"schema": map[string]any{
+					"type":        "object",
+					"description": "the ad",
+					"properties": map[string]any{
+						"images": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					},
+				},
+				"components": map[string]any{"schemas": map[string]any{
+					"Ad": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"images": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+						},
+					},
+					"Images": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				}},
+			},
+		},
+		{
+			name: "Test ResolveRefs - recursive reference",
+			document: map[string]any{
+				"components": map[string]any{"schemas": map[string]any{
+					"Category": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"children": map[string]any{
+								"type":  "array",
+								"items": map[string]any{"$ref": "#/components/schemas/Category"},
+							},
+						},
+					},
+				}},
+				"schema": map[string]any{"$ref": "#/components/schemas/Category"},
+			},
+			want: map[string]any{
+				"components": map[string]any{"schemas": map[string]any{
+					"Category": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"children": map[string]any{
+								"type": "array",
+								"items": map[string]any{
+									"type": "object",
+									"properties": map[string]any{
+										"children": map[string]any{
+											"type":  "array",
+											"items": map[string]any{RecursiveRefKey: "#/components/schemas/Category"},
+										},
+									},
+								},
+							},
+						},
+					},
+				}},
+				"schema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"children": map[string]any{
+							"type":  "array",
+							"items": map[string]any{RecursiveRefKey: "#/components/schemas/Category"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Test ResolveRefs - remote reference kept",
+			document: map[string]any{
+				"schema": map[string]any{"$ref": "common.yaml#/Ad"},
+			},
+			want: map[string]any{
+				"schema": map[string]any{"$ref": "common.yaml#/Ad"},
+			},
+		},
+		{
+			name: "Test ResolveRefs - missing member",
+			document: map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/Missing"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resolved, err := ResolveRefs(tt.document)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidDocument)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, resolved)
+		})
+	}
+}
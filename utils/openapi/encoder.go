@@ -0,0 +1,49 @@
+package openapiutils
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+
+	MIMEApplicationYAML = "application/yaml"
+)
+
+// Encode writes document to w as JSON, or as YAML with the fields in the same
+// order as the JSON one.
+func Encode(w io.Writer, document any, format string) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	if format != FormatYAML {
+		_, err = w.Write(data)
+		return err
+	}
+	// JSON is valid YAML, decoding it into a node keeps the field order
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return err
+	}
+	resetStyle(node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetStyle drops the flow style and quotes inherited from JSON, the encoder
+// still quotes the strings which would otherwise be read as another type.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
@@ -0,0 +1,138 @@
+package openapiutils
+
+import (
+	"github.com/carousell/ct-go/pkg/container"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	Version30 = "3.0"
+	Version31 = "3.1"
+)
+
+// SpecVersion returns the openapi field of a document of the given version.
+func SpecVersion(version string) string {
+	if version == Version31 {
+		return "3.1.0"
+	}
+	return "3.0.0"
+}
+
+// IsValidVersion reports whether version is an OpenAPI version documents can
+// be built for, an empty version stands for 3.0.
+func IsValidVersion(version string) bool {
+	return version == "" || version == Version30 || version == Version31
+}
+
+// ConvertTo31 rewrites in place the schemas found under v, an operation or a
+// document, from the OpenAPI 3.0 dialect to JSON Schema 2020-12.
+func ConvertTo31(v any) {
+	if m, ok := toMap(v); ok {
+		for key, value := range m {
+			switch key {
+			case "schema":
+				ConvertSchemaTo31(value)
+			case "schemas":
+				if schemas, ok := toMap(value); ok {
+					for _, schema := range schemas {
+						ConvertSchemaTo31(schema)
+					}
+				}
+			default:
+				ConvertTo31(value)
+			}
+		}
+		return
+	}
+	if items, ok := toSlice(v); ok {
+		for _, item := range items {
+			ConvertTo31(item)
+		}
+	}
+}
+
+// ConvertSchemaTo31 rewrites in place a 3.0 schema object: nullable becomes a
+// "null" entry of the type array and example becomes examples.
+func ConvertSchemaTo31(v any) {
+	schema, ok := toMap(v)
+	if !ok {
+		return
+	}
+	if nullable, ok := schema["nullable"].(bool); ok {
+		delete(schema, "nullable")
+		if typ, ok := schema["type"].(string); ok && nullable {
+			schema["type"] = []string{typ, "null"}
+		}
+	}
+	if example, ok := schema["example"]; ok {
+		delete(schema, "example")
+		if _, ok := schema["examples"]; !ok {
+			schema["examples"] = []any{example}
+		}
+	}
+	if properties, ok := toMap(schema["properties"]); ok {
+		for _, property := range properties {
+			ConvertSchemaTo31(property)
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		ConvertSchemaTo31(schema[key])
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if subSchemas, ok := toSlice(schema[key]); ok {
+			for _, subSchema := range subSchemas {
+				ConvertSchemaTo31(subSchema)
+			}
+		}
+	}
+}
+
+// CopySchema returns a deep copy of v made of map[string]any and []any, so
+// the copy can be rewritten without altering v.
+func CopySchema(v any) any {
+	if m, ok := toMap(v); ok {
+		copied := make(map[string]any, len(m))
+		for key, value := range m {
+			copied[key] = CopySchema(value)
+		}
+		return copied
+	}
+	if items, ok := toSlice(v); ok {
+		copied := make([]any, 0, len(items))
+		for _, item := range items {
+			copied = append(copied, CopySchema(item))
+		}
+		return copied
+	}
+	return v
+}
+
+// toMap accepts the map types schemas are made of, whether they are built in
+// memory or decoded from mongo.
+func toMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case container.Map:
+		return m, true
+	case primitive.M:
+		return m, true
+	}
+	return nil, false
+}
+
+func toSlice(v any) ([]any, bool) {
+	switch s := v.(type) {
+	case []any:
+		return s, true
+	case primitive.A:
+		return s, true
+	case []container.Map:
+		items := make([]any, 0, len(s))
+		for _, item := range s {
+			items = append(items, item)
+		}
+		return items, true
+	}
+	return nil, false
+}
@@ -0,0 +1,164 @@
+package openapiutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/carousell/ct-go/pkg/container"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestConvertSchemaTo31(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		schema any
+		want   any
+	}{
+		{
+			name:   "Test ConvertSchemaTo31 - nullable",
+			schema: map[string]any{"type": "string", "nullable": true},
+			want:   map[string]any{"type": []string{"string", "null"}},
+		},
+		{
+			name:   "Test ConvertSchemaTo31 - not nullable",
+			schema: map[string]any{"type": "string", "nullable": false},
+			want:   map[string]any{"type": "string"},
+		},
+		{
+			name:   "Test ConvertSchemaTo31 - example",
+			schema: container.Map{"type": "number", "example": 10},
+			want:   container.Map{"type": "number", "examples": []any{10}},
+		},
+		{
+			name: "Test ConvertSchemaTo31 - nested schemas decoded from mongo",
+			schema: primitive.M{
+				"type": "object",
+				"properties": primitive.M{
+					"tags": primitive.M{
+						"type":  "array",
+						"items": primitive.M{"type": "string", "nullable": true},
+					},
+					"price": primitive.M{
+						"anyOf": primitive.A{
+							primitive.M{"type": "number", "example": 1.5},
+							primitive.M{"type": "string"},
+						},
+					},
+				},
+			},
+			want: primitive.M{
+				"type": "object",
+				"properties": primitive.M{
+					"tags": primitive.M{
+						"type":  "array",
+						"items": primitive.M{"type": []string{"string", "null"}},
+					},
+					"price": primitive.M{
+						"anyOf": primitive.A{
+							primitive.M{"type": "number", "examples": []any{1.5}},
+							primitive.M{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ConvertSchemaTo31(tt.schema)
+			require.Equal(t, tt.want, tt.schema)
+		})
+	}
+}
+
+func TestConvertTo31(t *testing.T) {
+	t.Parallel()
+	operation := container.Map{
+		"parameters": []any{
+			container.Map{"name": "limit", "example": 20, "schema": container.Map{"type": "integer", "example": 20}},
+		},
+	}
+	ConvertTo31(operation)
+	// only schemas are rewritten, the example of the parameter object is still valid
+	require.Equal(t, container.Map{
+		"parameters": []any{
+			container.Map{"name": "limit", "example": 20, "schema": container.Map{"type": "integer", "examples": []any{20}}},
+		},
+	}, operation)
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	document := struct {
+		OpenApi   string        `json:"openapi"`
+		Responses container.Map `json:"responses"`
+	}{
+		OpenApi:   "3.0.0",
+		Responses: container.Map{"200": container.Map{"description": "true"}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, Encode(buf, document, FormatJSON))
+	require.Equal(t, `{"openapi":"3.0.0","responses":{"200":{"description":"true"}}}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Encode(buf, document, FormatYAML))
+	require.Equal(t, "openapi: 3.0.0\nresponses:\n  \"200\":\n    description: \"true\"\n", buf.String())
+}
+
+func TestGenerateValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		schema any
+		want   any
+	}{
+		{
+			name: "Test GenerateValue - object",
+			schema: primitive.M{
+				"type": "object",
+				"properties": primitive.M{
+					"ad_id":      primitive.M{"type": "number"},
+					"subject":    primitive.M{"type": "string", "example": "iPhone 15"},
+					"status":     primitive.M{"type": "string", "enum": primitive.A{"active", "hidden"}},
+					"created_at": primitive.M{"type": "string", "format": "date-time"},
+					"images":     primitive.M{"type": "array", "items": primitive.M{"type": "string"}},
+					"is_sticky":  primitive.M{"type": "boolean"},
+				},
+			},
+			want: map[string]any{
+				"ad_id":      0.0,
+				"subject":    "iPhone 15",
+				"status":     "active",
+				"created_at": "2024-01-01T00:00:00Z",
+				"images":     []any{"string"},
+				"is_sticky":  true,
+			},
+		},
+		{
+			name:   "Test GenerateValue - 3.1 nullable type",
+			schema: map[string]any{"type": []string{"null", "integer"}, "minimum": 1},
+			want:   1,
+		},
+		{
+			name:   "Test GenerateValue - array without items",
+			schema: map[string]any{"type": "array"},
+			want:   []any{},
+		},
+		{
+			name:   "Test GenerateValue - any",
+			schema: map[string]any{},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, GenerateValue(tt.schema))
+		})
+	}
+}
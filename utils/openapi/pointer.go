@@ -0,0 +1,68 @@
+package openapiutils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pointerTokenReplacer unescapes a reference token in a single pass, so "~01"
+// reads "~1".
+var pointerTokenReplacer = strings.NewReplacer("~1", "/", "~0", "~")
+
+// IsValidPointer reports whether pointer is a JSON pointer (RFC 6901) to a
+// member of a document, the root "" excepted.
+func IsValidPointer(pointer string) bool {
+	return strings.HasPrefix(pointer, "/")
+}
+
+// ResolvePointer returns the value pointer refers to in document, ok is false
+// when a token of the pointer does not match.
+func ResolvePointer(document any, pointer string) (any, bool) {
+	if pointer == "" {
+		return document, true
+	}
+	if !IsValidPointer(pointer) {
+		return nil, false
+	}
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = pointerTokenReplacer.Replace(token)
+		if m, ok := toMap(current); ok {
+			value, ok := m[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+			continue
+		}
+		items, ok := toSlice(current)
+		if !ok {
+			return nil, false
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(items) {
+			return nil, false
+		}
+		current = items[index]
+	}
+	return current, true
+}
+
+// ResolveSchema returns the schema object pointer refers to in schema.
+func ResolveSchema(schema any, pointer string) (map[string]any, bool) {
+	value, ok := ResolvePointer(schema, pointer)
+	if !ok {
+		return nil, false
+	}
+	return toMap(value)
+}
+
+// SplitPointer returns the pointer of the parent of the member pointer refers
+// to, and the unescaped name of that member within its parent.
+func SplitPointer(pointer string) (string, string) {
+	index := strings.LastIndex(pointer, "/")
+	if index < 0 {
+		return "", ""
+	}
+	return pointer[:index], pointerTokenReplacer.Replace(pointer[index+1:])
+}
@@ -0,0 +1,82 @@
+package openapiutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolvePointer(t *testing.T) {
+	t.Parallel()
+	schema := primitive.M{
+		"type": "object",
+		"properties": primitive.M{
+			"price": primitive.M{"type": "number"},
+			"a/b~c": primitive.M{"type": "string"},
+			"images": primitive.M{
+				"anyOf": primitive.A{
+					primitive.M{"type": "string"},
+					primitive.M{"type": "array"},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		pointer string
+		want    any
+		wantOk  bool
+	}{
+		{
+			name:    "Test ResolvePointer - root",
+			pointer: "",
+			want:    schema,
+			wantOk:  true,
+		},
+		{
+			name:    "Test ResolvePointer - property",
+			pointer: "/properties/price",
+			want:    primitive.M{"type": "number"},
+			wantOk:  true,
+		},
+		{
+			name:    "Test ResolvePointer - escaped token",
+			pointer: "/properties/a~1b~0c/type",
+			want:    "string",
+			wantOk:  true,
+		},
+		{
+			name:    "Test ResolvePointer - array index",
+			pointer: "/properties/images/anyOf/1",
+			want:    primitive.M{"type": "array"},
+			wantOk:  true,
+		},
+		{
+			name:    "Test ResolvePointer - index out of range",
+			pointer: "/properties/images/anyOf/2",
+			wantOk:  false,
+		},
+		{
+			name:    "Test ResolvePointer - missing property",
+			pointer: "/properties/subject",
+			wantOk:  false,
+		},
+		{
+			name:    "Test ResolvePointer - not a pointer",
+			pointer: "properties",
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := ResolvePointer(schema, tt.pointer)
+			require.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package openapiutils
+
+// maxValueDepth bounds the nesting of generated values, so recursive schemas
+// still produce a value.
+const maxValueDepth = 8
+
+// GenerateValue returns a value valid against schema, made of the examples,
+// defaults and enums it documents and placeholders of the right type
+// elsewhere.
+func GenerateValue(schema any) any {
+	return generateValue(schema, 0)
+}
+
+func generateValue(v any, depth int) any {
+	schema, ok := toMap(v)
+	if !ok || depth > maxValueDepth {
+		return nil
+	}
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if examples, ok := toSlice(schema["examples"]); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"const", "default"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum, ok := toSlice(schema["enum"]); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf", "allOf"} {
+		if subSchemas, ok := toSlice(schema[key]); ok && len(subSchemas) > 0 {
+			return generateValue(subSchemas[0], depth+1)
+		}
+	}
+	switch schemaType(schema) {
+	case "object":
+		value := map[string]any{}
+		if properties, ok := toMap(schema["properties"]); ok {
+			for name, property := range properties {
+				value[name] = generateValue(property, depth+1)
+			}
+		}
+		return value
+	case "array":
+		if _, ok := schema["items"]; !ok {
+			return []any{}
+		}
+		return []any{generateValue(schema["items"], depth+1)}
+	case "string":
+		return stringValue(schema)
+	case "integer":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0
+	case "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// schemaType returns the type of schema, the first one which is not null of a
+// 3.1 type array.
+func schemaType(schema map[string]any) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []string:
+		for _, item := range typ {
+			if item != "null" {
+				return item
+			}
+		}
+	default:
+		if types, ok := toSlice(typ); ok {
+			for _, item := range types {
+				if item, ok := item.(string); ok && item != "null" {
+					return item
+				}
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+func stringValue(schema map[string]any) string {
+	switch schema["format"] {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	}
+	return "string"
+}